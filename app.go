@@ -3,22 +3,58 @@ package main
 import (
 	"context"
 	"fmt"
+	"talenest/backend/api"
 )
 
 // App struct
 type App struct {
-	ctx context.Context
+	ctx         context.Context
+	session     *api.Session
+	library     *api.Library
+	preferences *api.Preferences
+	tags        *api.Tags
+	similarity  *api.Similarity
+	codex       *api.Codex
+	timeline    *api.Timeline
+	scenes      *api.Scenes
+	notes       *api.Notes
+	attachments *api.Attachments
+	statuses    *api.Statuses
+	tales       *api.Tales
+	chapters    *api.Chapters
 }
 
-// NewApp creates a new App application struct
-func NewApp() *App {
-	return &App{}
+// NewApp creates a new App application struct reading the configuration
+// from configFile, or from the default location when it's empty
+func NewApp(configFile string) *App {
+	session := api.NewSession(configFile)
+	return &App{
+		session:     session,
+		library:     api.NewLibrary(session),
+		preferences: api.NewPreferences(session),
+		tags:        api.NewTags(session),
+		similarity:  api.NewSimilarity(session),
+		codex:       api.NewCodex(session),
+		timeline:    api.NewTimeline(session),
+		scenes:      api.NewScenes(session),
+		notes:       api.NewNotes(session),
+		attachments: api.NewAttachments(session),
+		statuses:    api.NewStatuses(session),
+		tales:       api.NewTales(session),
+		chapters:    api.NewChapters(session),
+	}
 }
 
 // startup is called when the app starts. The context is saved
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.session.Startup(ctx)
+}
+
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	a.session.Shutdown(ctx)
 }
 
 // Greet returns a greeting for the given name
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"talenest/backend/internal/app/attachments"
+	"talenest/backend/internal/data"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const ATTACHMENTS_REPOSITORY = "attachments"
+
+// Attachments is bound to the frontend to keep files with the stories.
+type Attachments struct {
+	session *Session
+}
+
+// Attachment is a file kept with the stories, width and height being set
+// for images.
+type Attachment struct {
+	Id       int    `json:"id"`
+	Hash     string `json:"hash"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// AttachmentGarbage is what a garbage collection removed: the attachments
+// linked to nothing and the stored files no attachment held.
+type AttachmentGarbage struct {
+	Attachments []Attachment `json:"attachments"`
+	Files       int          `json:"files"`
+	Size        int64        `json:"size"`
+}
+
+func NewAttachments(session *Session) *Attachments {
+	return &Attachments{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (attachmentsApi *Attachments) repository() (attachments.Repository, error) {
+	return repository(attachmentsApi.session, ATTACHMENTS_REPOSITORY,
+		func(dbConn *data.DatabaseConnector) (attachments.Repository, error) {
+			store := attachments.NewStore(attachmentsApi.session.cfg.LibraryAttachmentsPath())
+			return attachments.NewRepository(dbConn, store)
+		})
+}
+
+func (attachmentsApi *Attachments) ListAttachments() ([]Attachment, error) {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return []Attachment{}, err
+	}
+	collection, err := repo.ReadAll()
+	if err != nil {
+		return []Attachment{}, err
+	}
+	return attachmentInfos(collection), nil
+}
+
+// ListTaleAttachments returns the attachments linked to a tale.
+func (attachmentsApi *Attachments) ListTaleAttachments(taleId int) ([]Attachment, error) {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return []Attachment{}, err
+	}
+	collection, err := repo.ReadByTale(taleId)
+	if err != nil {
+		return []Attachment{}, err
+	}
+	return attachmentInfos(collection), nil
+}
+
+// ListChapterAttachments returns the attachments linked to a chapter.
+func (attachmentsApi *Attachments) ListChapterAttachments(chapterId int) ([]Attachment, error) {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return []Attachment{}, err
+	}
+	collection, err := repo.ReadByChapter(chapterId)
+	if err != nil {
+		return []Attachment{}, err
+	}
+	return attachmentInfos(collection), nil
+}
+
+// AttachFiles lets the user pick files and attaches them to the chapter,
+// or to the tale when chapterId is 0. It returns the attachments added,
+// none when the user cancelled.
+func (attachmentsApi *Attachments) AttachFiles(taleId, chapterId int) ([]Attachment, error) {
+	paths, err := runtime.OpenMultipleFilesDialog(attachmentsApi.session.ctx, runtime.OpenDialogOptions{
+		Title: "Attach files",
+	})
+
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	if err != nil || len(paths) == 0 {
+		return []Attachment{}, err
+	}
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return []Attachment{}, err
+	}
+	added := []Attachment{}
+	for _, path := range paths {
+		attachment, err := repo.AddFile(path)
+		if err != nil {
+			return added, err
+		}
+		if chapterId != 0 {
+			err = repo.LinkChapter(attachment.Id, chapterId)
+		} else {
+			err = repo.LinkTale(attachment.Id, taleId)
+		}
+		if err != nil {
+			return added, err
+		}
+		added = append(added, attachmentInfo(*attachment))
+	}
+	return added, nil
+}
+
+func (attachmentsApi *Attachments) LinkToTale(attachmentId, taleId int) error {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.LinkTale(attachmentId, taleId)
+}
+
+func (attachmentsApi *Attachments) UnlinkFromTale(attachmentId, taleId int) error {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.UnlinkTale(attachmentId, taleId)
+}
+
+func (attachmentsApi *Attachments) LinkToChapter(attachmentId, chapterId int) error {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.LinkChapter(attachmentId, chapterId)
+}
+
+func (attachmentsApi *Attachments) UnlinkFromChapter(attachmentId, chapterId int) error {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.UnlinkChapter(attachmentId, chapterId)
+}
+
+// DeleteAttachment deletes an attachment, its file is removed by the next
+// garbage collection.
+func (attachmentsApi *Attachments) DeleteAttachment(id int) error {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+// GetThumbnail returns the thumbnail of an image as a data URL, empty for
+// the other attachments.
+func (attachmentsApi *Attachments) GetThumbnail(id int) (string, error) {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return "", err
+	}
+	attachment, err := repo.ReadById(id)
+	if err != nil || !attachment.IsImage() {
+		return "", err
+	}
+	thumbnail, err := os.ReadFile(repo.ThumbnailPath(attachment))
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(thumbnail), nil
+}
+
+// OpenAttachment opens an attachment with the default application of the
+// system.
+func (attachmentsApi *Attachments) OpenAttachment(id int) error {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return err
+	}
+	attachment, err := repo.ReadById(id)
+	if err != nil {
+		return err
+	}
+	// the stored file has no extension to tell the system what opens it
+	path, err := repo.Copy(attachment)
+	if err != nil {
+		return err
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// a Windows drive
+		path = "/" + path
+	}
+	runtime.BrowserOpenURL(attachmentsApi.session.ctx, (&url.URL{Scheme: "file", Path: path}).String())
+	return nil
+}
+
+// CollectAttachmentGarbage deletes the attachments linked to nothing and
+// removes the stored files no attachment holds.
+func (attachmentsApi *Attachments) CollectAttachmentGarbage() (AttachmentGarbage, error) {
+	attachmentsApi.session.mu.Lock()
+	defer attachmentsApi.session.mu.Unlock()
+	repo, err := attachmentsApi.repository()
+	if err != nil {
+		return AttachmentGarbage{Attachments: []Attachment{}}, err
+	}
+	garbage, err := repo.CollectGarbage()
+	if garbage == nil {
+		return AttachmentGarbage{Attachments: []Attachment{}}, err
+	}
+	return AttachmentGarbage{
+		Attachments: attachmentInfos(garbage.Attachments),
+		Files:       len(garbage.Hashes),
+		Size:        garbage.Size,
+	}, err
+}
+
+func attachmentInfo(attachment attachments.Attachment) Attachment {
+	return Attachment{
+		Id:       attachment.Id,
+		Hash:     attachment.Hash,
+		Name:     attachment.Name,
+		MimeType: attachment.MimeType,
+		Size:     attachment.Size,
+		Width:    attachment.Width,
+		Height:   attachment.Height,
+	}
+}
+
+func attachmentInfos(collection []attachments.Attachment) []Attachment {
+	converted := make([]Attachment, len(collection))
+	for i, attachment := range collection {
+		converted[i] = attachmentInfo(attachment)
+	}
+	return converted
+}
@@ -0,0 +1,111 @@
+package api
+
+import "talenest/backend/internal/app/chapter"
+
+// Chapters is bound to the frontend to write the chapters of the tales.
+type Chapters struct {
+	session *Session
+}
+
+type Chapter struct {
+	Id      int    `json:"id"`
+	TaleId  int    `json:"taleId"`
+	Content string `json:"content"`
+	Version int    `json:"version"`
+}
+
+// ChapterUpdate is the result of saving a chapter. Conflict is set when
+// the chapter changed since it was read, Current then holding the stored
+// chapter so it can be merged with the edits or overwritten, see
+// OverwriteChapter.
+type ChapterUpdate struct {
+	Chapter  Chapter  `json:"chapter"`
+	Conflict bool     `json:"conflict"`
+	Current  *Chapter `json:"current"`
+}
+
+func NewChapters(session *Session) *Chapters {
+	return &Chapters{
+		session: session,
+	}
+}
+
+// ListChapters returns the chapters of a tale.
+func (chaptersApi *Chapters) ListChapters(taleId int) ([]Chapter, error) {
+	chaptersApi.session.mu.Lock()
+	defer chaptersApi.session.mu.Unlock()
+	repo, err := chapterRepository(chaptersApi.session)
+	if err != nil {
+		return []Chapter{}, err
+	}
+	chapters, err := repo.ReadByTale(taleId)
+	if err != nil {
+		return []Chapter{}, err
+	}
+	converted := []Chapter{}
+	for chapter := range chapters.ChaptersStream() {
+		converted = append(converted, chapterInfo(*chapter))
+	}
+	return converted, nil
+}
+
+// UpdateChapter saves the content of a chapter read before, reporting a
+// conflict if the chapter changed since then.
+func (chaptersApi *Chapters) UpdateChapter(updated Chapter) (ChapterUpdate, error) {
+	return chaptersApi.saveChapter(updated, false)
+}
+
+// OverwriteChapter saves a chapter like UpdateChapter, replacing the
+// changes made since it was read.
+func (chaptersApi *Chapters) OverwriteChapter(updated Chapter) (ChapterUpdate, error) {
+	return chaptersApi.saveChapter(updated, true)
+}
+
+func (chaptersApi *Chapters) saveChapter(updated Chapter, overwrite bool) (ChapterUpdate, error) {
+	chaptersApi.session.mu.Lock()
+	defer chaptersApi.session.mu.Unlock()
+	result := ChapterUpdate{Chapter: updated}
+	repo, err := chapterRepository(chaptersApi.session)
+	if err != nil {
+		return result, err
+	}
+	stored, err := repo.ReadById(updated.Id)
+	if err != nil {
+		return result, err
+	}
+	stored.Content = updated.Content
+	stored.Version = updated.Version
+
+	err = repo.Update(stored)
+	current, stale := staleEntity[chapter.Chapter](err)
+	if stale && overwrite {
+		stored.Version = current.Version
+		err = repo.Update(stored)
+		current, stale = staleEntity[chapter.Chapter](err)
+	}
+	if stale {
+		info := chapterInfo(*current)
+		result.Conflict, result.Current = true, &info
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	result.Chapter = chapterInfo(*stored)
+
+	engine, err := similarityEngine(chaptersApi.session)
+	if err != nil {
+		return result, err
+	}
+	_, err = engine.UpdateTales(stored.TaleId)
+	return result, err
+}
+
+func chapterInfo(chapter chapter.Chapter) Chapter {
+	return Chapter{
+		Id:      chapter.Id,
+		TaleId:  chapter.TaleId,
+		Content: chapter.Content,
+		Version: chapter.Version,
+	}
+}
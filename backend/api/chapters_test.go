@@ -0,0 +1,57 @@
+package api
+
+import (
+	"talenest/backend/internal/app/chapter"
+	"testing"
+)
+
+func TestUpdateChapterConflict(t *testing.T) {
+	session := newTestSession(t)
+	tale, err := NewTales(session).CreateTale(Tale{Name: "tale"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := chapterRepository(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Create(&chapter.Chapter{TaleId: tale.Id, Content: "draft", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	chaptersApi := NewChapters(session)
+	chapters, err := chaptersApi.ListChapters(tale.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 1 {
+		t.Fatalf("ListChapters = %+v, want the draft", chapters)
+	}
+	read := chapters[0]
+
+	first := read
+	first.Content = "first edit"
+	if update, err := chaptersApi.UpdateChapter(first); err != nil || update.Conflict {
+		t.Fatalf("UpdateChapter = %+v, %v, want the first edit saved", update, err)
+	}
+
+	second := read
+	second.Content = "second edit"
+	update, err := chaptersApi.UpdateChapter(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !update.Conflict || update.Current == nil || update.Current.Content != "first edit" {
+		t.Fatalf("UpdateChapter of an old version = %+v, want a conflict with the first edit", update)
+	}
+
+	update, err = chaptersApi.OverwriteChapter(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Conflict || update.Chapter.Content != "second edit" || update.Chapter.Version != read.Version+2 {
+		t.Errorf("OverwriteChapter = %+v, want the second edit saved over the first", update)
+	}
+	if stored, _ := repo.ReadById(read.Id); stored.Content != "second edit" {
+		t.Errorf("the stored chapter is %q, want the second edit", stored.Content)
+	}
+}
@@ -0,0 +1,382 @@
+package api
+
+import (
+	"slices"
+	"talenest/backend/internal/app/chapter"
+	"talenest/backend/internal/app/codex"
+	"talenest/backend/internal/data"
+)
+
+const (
+	CODEX_REPOSITORY = "codex"
+	MENTION_INDEX    = "mentions"
+)
+
+// Codex is bound to the frontend to edit the characters, places, items and
+// factions of the library and link them to tales and chapters.
+type Codex struct {
+	session *Session
+}
+
+// CodexEntity is an entity of the codex, Kind being one of character,
+// place, item or faction.
+type CodexEntity struct {
+	Id          int          `json:"id"`
+	Kind        string       `json:"kind"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Fields      []CodexField `json:"fields"`
+	Aliases     []string     `json:"aliases"`
+	Version     int          `json:"version"`
+}
+
+type CodexField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CodexMention is a name of an entity found in a chapter, start and end
+// being byte offsets in the chapter content.
+type CodexMention struct {
+	EntityId  int    `json:"entityId"`
+	ChapterId int    `json:"chapterId"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	Text      string `json:"text"`
+}
+
+// CodexAppearance counts the mentions of an entity in a chapter.
+type CodexAppearance struct {
+	EntityId  int `json:"entityId"`
+	ChapterId int `json:"chapterId"`
+	Count     int `json:"count"`
+}
+
+func NewCodex(session *Session) *Codex {
+	return &Codex{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (codexApi *Codex) repository() (codex.Repository, error) {
+	return repository(codexApi.session, CODEX_REPOSITORY, codex.NewRepository)
+}
+
+// mentionIndex must be called with the session locked.
+func mentionIndex(session *Session) (*codex.MentionIndex, error) {
+	entities, err := repository(session, CODEX_REPOSITORY, codex.NewRepository)
+	if err != nil {
+		return nil, err
+	}
+	return repository(session, MENTION_INDEX, func(dbConn *data.DatabaseConnector) (*codex.MentionIndex, error) {
+		return codex.NewMentionIndex(dbConn, entities)
+	})
+}
+
+// chapterRepository returns the chapters of the library, scanned for the
+// codex mentions when they are saved. It must be called with the session
+// locked.
+func chapterRepository(session *Session) (chapter.Repository, error) {
+	index, err := mentionIndex(session)
+	if err != nil {
+		return nil, err
+	}
+	return repository(session, CHAPTERS_REPOSITORY, func(dbConn *data.DatabaseConnector) (chapter.Repository, error) {
+		chapters, err := chapter.NewRepository(dbConn)
+		if err != nil {
+			return nil, err
+		}
+		return index.Chapters(chapters), nil
+	})
+}
+
+// rebuildMentions scans the chapters again for the codex mentions.
+func rebuildMentions(session *Session) error {
+	index, err := mentionIndex(session)
+	if err != nil {
+		return err
+	}
+	chapters, err := chapterRepository(session)
+	if err != nil {
+		return err
+	}
+	return index.Rebuild(chapters)
+}
+
+// ListCodexEntities returns the entities of a kind, or all of them when
+// kind is empty.
+func (codexApi *Codex) ListCodexEntities(kind string) ([]CodexEntity, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return []CodexEntity{}, err
+	}
+	var entities []codex.Entity
+	if kind == "" {
+		entities, err = repo.ReadAll()
+	} else {
+		entities, err = repo.ReadByKind(codex.Kind(kind))
+	}
+	if err != nil {
+		return []CodexEntity{}, err
+	}
+	return codexEntities(entities), nil
+}
+
+func (codexApi *Codex) GetCodexEntity(id int) (CodexEntity, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return CodexEntity{}, err
+	}
+	entity, err := repo.ReadById(id)
+	if err != nil {
+		return CodexEntity{}, err
+	}
+	return codexEntity(*entity), nil
+}
+
+// CreateCodexEntity saves a new entity and returns it with its id.
+func (codexApi *Codex) CreateCodexEntity(created CodexEntity) (CodexEntity, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return created, err
+	}
+	entity := codex.Create(codex.Kind(created.Kind), created.Name)
+	entity.Description = created.Description
+	entity.Fields = fields(created.Fields)
+	entity.Aliases = aliases(created.Aliases)
+	if _, err := repo.Create(entity); err != nil {
+		return created, err
+	}
+	return codexEntity(*entity), rebuildMentions(codexApi.session)
+}
+
+// UpdateCodexEntity saves an entity read before, it fails if the entity
+// changed since then.
+func (codexApi *Codex) UpdateCodexEntity(updated CodexEntity) (CodexEntity, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return updated, err
+	}
+	entity, err := repo.ReadById(updated.Id)
+	if err != nil {
+		return updated, err
+	}
+	names := entity.Names()
+	entity.Kind = codex.Kind(updated.Kind)
+	entity.Name = updated.Name
+	entity.Description = updated.Description
+	entity.Fields = fields(updated.Fields)
+	entity.Aliases = aliases(updated.Aliases)
+	entity.Version = updated.Version
+	if err := repo.Update(entity); err != nil {
+		return updated, err
+	}
+	if slices.Equal(names, entity.Names()) {
+		return codexEntity(*entity), nil
+	}
+	return codexEntity(*entity), rebuildMentions(codexApi.session)
+}
+
+func (codexApi *Codex) DeleteCodexEntity(id int) error {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+// GetTaleCodex returns the entities linked to a tale.
+func (codexApi *Codex) GetTaleCodex(taleId int) ([]CodexEntity, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return []CodexEntity{}, err
+	}
+	entities, err := repo.ReadByTale(taleId)
+	if err != nil {
+		return []CodexEntity{}, err
+	}
+	return codexEntities(entities), nil
+}
+
+// GetChapterCodex returns the entities linked to a chapter.
+func (codexApi *Codex) GetChapterCodex(chapterId int) ([]CodexEntity, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return []CodexEntity{}, err
+	}
+	entities, err := repo.ReadByChapter(chapterId)
+	if err != nil {
+		return []CodexEntity{}, err
+	}
+	return codexEntities(entities), nil
+}
+
+func (codexApi *Codex) LinkToTale(entityId, taleId int) error {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.LinkTale(entityId, taleId)
+}
+
+func (codexApi *Codex) UnlinkFromTale(entityId, taleId int) error {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.UnlinkTale(entityId, taleId)
+}
+
+func (codexApi *Codex) LinkToChapter(entityId, chapterId int) error {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.LinkChapter(entityId, chapterId)
+}
+
+func (codexApi *Codex) UnlinkFromChapter(entityId, chapterId int) error {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	repo, err := codexApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.UnlinkChapter(entityId, chapterId)
+}
+
+// GetChapterMentions returns the mentions of the codex entities in a
+// chapter, in the order of the text.
+func (codexApi *Codex) GetChapterMentions(chapterId int) ([]CodexMention, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	index, err := mentionIndex(codexApi.session)
+	if err != nil {
+		return []CodexMention{}, err
+	}
+	mentions, err := index.ReadByChapter(chapterId)
+	if err != nil {
+		return []CodexMention{}, err
+	}
+	converted := make([]CodexMention, len(mentions))
+	for i, mention := range mentions {
+		converted[i] = CodexMention{
+			EntityId:  mention.EntityId,
+			ChapterId: mention.ChapterId,
+			Start:     mention.Start,
+			End:       mention.End,
+			Text:      mention.Text,
+		}
+	}
+	return converted, nil
+}
+
+// GetChaptersMentioning returns the chapters mentioning an entity.
+func (codexApi *Codex) GetChaptersMentioning(entityId int) ([]CodexAppearance, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	index, err := mentionIndex(codexApi.session)
+	if err != nil {
+		return []CodexAppearance{}, err
+	}
+	appearances, err := index.ChaptersMentioning(entityId)
+	if err != nil {
+		return []CodexAppearance{}, err
+	}
+	return codexAppearances(appearances), nil
+}
+
+// GetEntitiesInChapter returns the entities mentioned in a chapter, the
+// most mentioned first.
+func (codexApi *Codex) GetEntitiesInChapter(chapterId int) ([]CodexAppearance, error) {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	index, err := mentionIndex(codexApi.session)
+	if err != nil {
+		return []CodexAppearance{}, err
+	}
+	appearances, err := index.EntitiesIn(chapterId)
+	if err != nil {
+		return []CodexAppearance{}, err
+	}
+	return codexAppearances(appearances), nil
+}
+
+// RebuildCodexMentions scans every chapter again for the codex mentions.
+func (codexApi *Codex) RebuildCodexMentions() error {
+	codexApi.session.mu.Lock()
+	defer codexApi.session.mu.Unlock()
+	return rebuildMentions(codexApi.session)
+}
+
+func codexEntity(entity codex.Entity) CodexEntity {
+	fields := make([]CodexField, len(entity.Fields))
+	for i, field := range entity.Fields {
+		fields[i] = CodexField{Name: field.Name, Value: field.Value}
+	}
+	return CodexEntity{
+		Id:          entity.Id,
+		Kind:        string(entity.Kind),
+		Name:        entity.Name,
+		Description: entity.Description,
+		Fields:      fields,
+		Aliases:     slices.Clone(entity.Aliases),
+		Version:     entity.Version,
+	}
+}
+
+func codexEntities(entities []codex.Entity) []CodexEntity {
+	converted := make([]CodexEntity, len(entities))
+	for i, entity := range entities {
+		converted[i] = codexEntity(entity)
+	}
+	return converted
+}
+
+func fields(codexFields []CodexField) []codex.Field {
+	fields := make([]codex.Field, len(codexFields))
+	for i, field := range codexFields {
+		fields[i] = codex.Field{Name: field.Name, Value: field.Value}
+	}
+	return fields
+}
+
+func aliases(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return slices.Clone(names)
+}
+
+func codexAppearances(appearances []codex.Appearance) []CodexAppearance {
+	converted := make([]CodexAppearance, len(appearances))
+	for i, appearance := range appearances {
+		converted[i] = CodexAppearance{
+			EntityId:  appearance.EntityId,
+			ChapterId: appearance.ChapterId,
+			Count:     appearance.Count,
+		}
+	}
+	return converted
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	CAUSE_NO_LIBRARY     = "no_library"
+	CAUSE_INVALID_CONFIG = "invalid_config"
+)
+
+// Library is bound to the frontend to manage the open library.
+type Library struct {
+	session *Session
+}
+
+// StartupState tells the frontend if the library is open or, when it
+// isn't, why and which backups can be restored.
+type StartupState struct {
+	Ready   bool          `json:"ready"`
+	Library string        `json:"library"`
+	Cause   string        `json:"cause"`
+	Message string        `json:"message"`
+	Path    string        `json:"path"`
+	Backups []data.Backup `json:"backups"`
+}
+
+// LibraryInfo is a library registered in the configuration.
+type LibraryInfo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Current bool   `json:"current"`
+}
+
+func NewLibrary(session *Session) *Library {
+	return &Library{
+		session: session,
+	}
+}
+
+// GetStartupState returns the state of the current library.
+func (library *Library) GetStartupState() StartupState {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	return library.startupState()
+}
+
+func (library *Library) startupState() StartupState {
+	session := library.session
+	if err := session.checkConfig(); err != nil {
+		return StartupState{
+			Cause:   CAUSE_INVALID_CONFIG,
+			Message: err.Error(),
+		}
+	}
+	state := StartupState{
+		Ready:   session.startupErr == nil,
+		Library: session.cfg.CurrentLibrary,
+		Path:    session.cfg.SQLitePath,
+	}
+	if state.Ready {
+		return state
+	}
+
+	state.Message = session.startupErr.Error()
+	if errors.Is(session.startupErr, errNoLibrary) {
+		state.Cause = CAUSE_NO_LIBRARY
+		return state
+	}
+	state.Cause = string(data.CauseUnknown)
+	var startupErr *data.StartupError
+	if errors.As(session.startupErr, &startupErr) {
+		state.Cause = string(startupErr.Cause)
+	}
+	backups, err := data.ListBackups(session.cfg.LibraryBackupPath())
+	if err != nil {
+		state.Message += fmt.Sprintf("\nthe backups can't be listed: %v", err)
+	}
+	state.Backups = backups
+	return state
+}
+
+// changed returns the state of the library after it was opened again and
+// tells the other views about it.
+func (library *Library) changed(err error) (StartupState, error) {
+	state := library.startupState()
+	library.session.notifyLibraryChanged(state)
+	return state, err
+}
+
+// RetryStartup opens the library again, e.g. once another process released
+// its lock.
+func (library *Library) RetryStartup() (StartupState, error) {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return library.startupState(), err
+	}
+	if err := library.session.closeDatabase(); err != nil {
+		return library.startupState(), err
+	}
+	return library.changed(library.session.openDatabase())
+}
+
+// RestoreBackup replaces the library with the given backup, keeping the
+// current file aside.
+func (library *Library) RestoreBackup(backupPath string) (StartupState, error) {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return library.startupState(), err
+	}
+	backupDir := library.session.cfg.LibraryBackupPath()
+	if filepath.Dir(filepath.Clean(backupPath)) != filepath.Clean(backupDir) {
+		return library.startupState(), fmt.Errorf("%s isn't a backup of the library %q",
+			backupPath, library.session.cfg.CurrentLibrary)
+	}
+	if err := library.session.closeDatabase(); err != nil {
+		return library.startupState(), err
+	}
+	if _, err := data.RestoreBackup(backupPath, library.session.cfg.SQLitePath); err != nil {
+		return library.startupState(), err
+	}
+	return library.changed(library.session.openDatabase())
+}
+
+// ResetLibrary starts over with an empty library, keeping the current file
+// aside.
+func (library *Library) ResetLibrary() (StartupState, error) {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return library.startupState(), err
+	}
+	if err := library.session.closeDatabase(); err != nil {
+		return library.startupState(), err
+	}
+	if _, err := data.SetAside(library.session.cfg.SQLitePath); err != nil {
+		return library.startupState(), err
+	}
+	return library.changed(library.session.openDatabase())
+}
+
+// ListLibraries returns the registered libraries.
+func (library *Library) ListLibraries() []LibraryInfo {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if library.session.checkConfig() != nil {
+		return []LibraryInfo{}
+	}
+	return library.infos(library.session.cfg.Libraries)
+}
+
+// RecentLibraries returns the libraries last opened, the most recent first.
+func (library *Library) RecentLibraries() []LibraryInfo {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if library.session.checkConfig() != nil {
+		return []LibraryInfo{}
+	}
+	return library.infos(library.session.cfg.RecentLibraries())
+}
+
+func (library *Library) infos(libraries []config.LibraryConfig) []LibraryInfo {
+	infos := make([]LibraryInfo, len(libraries))
+	for i, libraryConfig := range libraries {
+		infos[i] = LibraryInfo{
+			Name:    libraryConfig.Name,
+			Path:    libraryConfig.SQLitePath,
+			Current: libraryConfig.Name == library.session.cfg.CurrentLibrary,
+		}
+	}
+	return infos
+}
+
+// CreateLibrary registers a new empty library and opens it.
+func (library *Library) CreateLibrary(name string) (StartupState, error) {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return library.startupState(), err
+	}
+	created, err := library.session.cfg.CreateLibrary(name)
+	if err != nil {
+		return library.startupState(), err
+	}
+	return library.changed(library.session.switchLibrary(created.Name))
+}
+
+// OpenLibrary closes the open library and opens the one named name.
+func (library *Library) OpenLibrary(name string) (StartupState, error) {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return library.startupState(), err
+	}
+	return library.changed(library.session.switchLibrary(name))
+}
+
+// OpenLibraryFile lets the user pick a library file, registers it and opens
+// it.
+func (library *Library) OpenLibraryFile() (StartupState, error) {
+	path, err := runtime.OpenFileDialog(library.session.ctx, runtime.OpenDialogOptions{
+		Title: "Open a library",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Talenest library (*.db)", Pattern: "*.db"},
+		},
+	})
+
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return library.startupState(), err
+	}
+	if err != nil || path == "" {
+		return library.startupState(), err
+	}
+	added, err := library.session.cfg.AddLibrary(path)
+	if err != nil {
+		return library.startupState(), err
+	}
+	return library.changed(library.session.switchLibrary(added.Name))
+}
+
+// RenameLibrary renames a registered library.
+func (library *Library) RenameLibrary(name, newName string) error {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return err
+	}
+	return library.session.cfg.RenameLibrary(name, newName)
+}
+
+// CloseLibrary closes the open library, no library is opened at the next
+// start.
+func (library *Library) CloseLibrary() (StartupState, error) {
+	library.session.mu.Lock()
+	defer library.session.mu.Unlock()
+	if err := library.session.checkConfig(); err != nil {
+		return library.startupState(), err
+	}
+	return library.changed(library.session.switchLibrary(""))
+}
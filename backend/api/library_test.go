@@ -0,0 +1,94 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+	"testing"
+)
+
+// newTestSession opens the default library of a configuration written in a
+// temporary home. The session has no Wails context, so no event is sent.
+func newTestSession(t *testing.T) *Session {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, variable := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME"} {
+		t.Setenv(variable, "")
+	}
+	cfg, err := config.Load(filepath.Join(home, config.CONFIG_FILE_NAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := NewSession("")
+	session.cfg = cfg
+	if err := session.openDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if session.startupErr != nil {
+		t.Fatal(session.startupErr)
+	}
+	t.Cleanup(func() {
+		session.closeDatabase()
+	})
+	return session
+}
+
+func TestLibraryBackupsAreSeparate(t *testing.T) {
+	session := newTestSession(t)
+	library := NewLibrary(session)
+	names := []string{"First", "Second"}
+	backups := map[string]string{}
+	for _, name := range names {
+		if _, err := library.CreateLibrary(name); err != nil {
+			t.Fatal(err)
+		}
+		migrator, err := data.NewMigrator(session.cfg.SQLitePath, session.cfg.LibraryBackupPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		backups[name], err = migrator.Backup()
+		migrator.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if filepath.Dir(backups["First"]) == filepath.Dir(backups["Second"]) {
+		t.Fatalf("both libraries are backed up in %s", filepath.Dir(backups["First"]))
+	}
+
+	for i, name := range names {
+		other := backups[names[1-i]]
+		if _, err := library.OpenLibrary(name); err != nil {
+			t.Fatal(err)
+		}
+		// break the database so the recovery lists the backups
+		if err := session.closeDatabase(); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(session.cfg.SQLitePath, []byte("not a database"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		state, err := library.RetryStartup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Ready {
+			t.Fatalf("%s opened a broken database", name)
+		}
+		if len(state.Backups) != 1 || state.Backups[0].Path != backups[name] {
+			t.Fatalf("%s lists the backups %v, want only %s", name, state.Backups, backups[name])
+		}
+
+		if _, err := library.RestoreBackup(other); err == nil {
+			t.Errorf("%s was restored from %s, a backup of another library", name, other)
+		}
+		state, err = library.RestoreBackup(backups[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !state.Ready {
+			t.Errorf("%s isn't open after its backup was restored: %s", name, state.Message)
+		}
+	}
+}
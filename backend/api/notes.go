@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"os"
+	"talenest/backend/internal/app/notes"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const NOTES_REPOSITORY = "notes"
+
+// exportExtensions are the file extensions of the draft exports.
+var exportExtensions = map[string]string{
+	notes.FORMAT_MARKDOWN: ".md",
+	notes.FORMAT_HTML:     ".html",
+	notes.FORMAT_TXT:      ".txt",
+}
+
+// Notes is bound to the frontend to comment the chapters.
+type Notes struct {
+	session *Session
+}
+
+// Note comments the characters start to end of a chapter, quote being the
+// text they held when the note was anchored.
+type Note struct {
+	Id        int    `json:"id"`
+	ChapterId int    `json:"chapterId"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	Quote     string `json:"quote"`
+	Body      string `json:"body"`
+	Resolved  bool   `json:"resolved"`
+	Version   int    `json:"version"`
+}
+
+func NewNotes(session *Session) *Notes {
+	return &Notes{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (notesApi *Notes) repository() (notes.Repository, error) {
+	return repository(notesApi.session, NOTES_REPOSITORY, notes.NewRepository)
+}
+
+// ListChapterNotes returns the notes of a chapter in the order of the text.
+func (notesApi *Notes) ListChapterNotes(chapterId int) ([]Note, error) {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	repo, err := notesApi.repository()
+	if err != nil {
+		return []Note{}, err
+	}
+	collection, err := repo.ReadByChapter(chapterId)
+	if err != nil {
+		return []Note{}, err
+	}
+	return noteInfos(collection), nil
+}
+
+// ListOpenNotes returns the notes not resolved on the chapters of a tale.
+func (notesApi *Notes) ListOpenNotes(taleId int) ([]Note, error) {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	repo, err := notesApi.repository()
+	if err != nil {
+		return []Note{}, err
+	}
+	collection, err := repo.ReadOpenByTale(taleId)
+	if err != nil {
+		return []Note{}, err
+	}
+	return noteInfos(collection), nil
+}
+
+func (notesApi *Notes) CreateNote(created Note) (Note, error) {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	repo, err := notesApi.repository()
+	if err != nil {
+		return created, err
+	}
+	note := notes.Create(created.ChapterId, created.Start, created.End, created.Body)
+	if _, err := repo.Create(note); err != nil {
+		return created, err
+	}
+	return noteInfo(*note), nil
+}
+
+// UpdateNote saves the body and range of a note read before, it fails if
+// the note changed since then.
+func (notesApi *Notes) UpdateNote(updated Note) (Note, error) {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	repo, err := notesApi.repository()
+	if err != nil {
+		return updated, err
+	}
+	note, err := repo.ReadById(updated.Id)
+	if err != nil {
+		return updated, err
+	}
+	note.Range = notes.Range{Start: updated.Start, End: updated.End}
+	note.Body = updated.Body
+	note.Version = updated.Version
+	if err := repo.Update(note); err != nil {
+		return updated, err
+	}
+	return noteInfo(*note), nil
+}
+
+func (notesApi *Notes) DeleteNote(id int) error {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	repo, err := notesApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+func (notesApi *Notes) ResolveNote(id int) error {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	repo, err := notesApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.Resolve(id)
+}
+
+func (notesApi *Notes) UnresolveNote(id int) error {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	repo, err := notesApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.Unresolve(id)
+}
+
+// ExportDraft exports the chapters of a tale with their notes to a file
+// the user picks, in the default export format when format is empty. It
+// returns the path of the file, empty when the user cancelled.
+func (notesApi *Notes) ExportDraft(taleId int, format string, includeResolved bool) (string, error) {
+	draft, format, err := notesApi.readDraft(taleId, format, includeResolved)
+	if err != nil {
+		return "", err
+	}
+	exported := bytes.Buffer{}
+	if err := draft.Export(&exported, format); err != nil {
+		return "", err
+	}
+
+	extension := exportExtensions[format]
+	path, err := runtime.SaveFileDialog(notesApi.session.ctx, runtime.SaveDialogOptions{
+		Title:           "Export the draft",
+		DefaultFilename: draft.Title + extension,
+		Filters: []runtime.FileFilter{
+			{DisplayName: format + " (*" + extension + ")", Pattern: "*" + extension},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	return path, os.WriteFile(path, exported.Bytes(), 0o644)
+}
+
+// readDraft reads the draft to export and the format to export it in.
+func (notesApi *Notes) readDraft(taleId int, format string, includeResolved bool) (*notes.Draft, string, error) {
+	notesApi.session.mu.Lock()
+	defer notesApi.session.mu.Unlock()
+	if format == "" && notesApi.session.preferences != nil {
+		format = notesApi.session.preferences.Get().DefaultExportFormat
+	}
+	repo, err := notesApi.repository()
+	if err != nil {
+		return nil, format, err
+	}
+	draft, err := repo.ReadDraft(taleId, includeResolved)
+	return draft, format, err
+}
+
+func noteInfo(note notes.Note) Note {
+	_, resolved := note.Resolved()
+	return Note{
+		Id:        note.Id,
+		ChapterId: note.ChapterId,
+		Start:     note.Range.Start,
+		End:       note.Range.End,
+		Quote:     note.Quote,
+		Body:      note.Body,
+		Resolved:  resolved,
+		Version:   note.Version,
+	}
+}
+
+func noteInfos(collection []notes.Note) []Note {
+	converted := make([]Note, len(collection))
+	for i, note := range collection {
+		converted[i] = noteInfo(note)
+	}
+	return converted
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"talenest/backend/internal/config"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Preferences is bound to the frontend to read and change the user
+// preferences. Changes, including the ones made to the file by hand, are
+// also sent as PREFERENCES_CHANGED_EVENT.
+type Preferences struct {
+	session *Session
+}
+
+func NewPreferences(session *Session) *Preferences {
+	return &Preferences{
+		session: session,
+	}
+}
+
+func (preferences *Preferences) store() (*config.PreferencesStore, error) {
+	preferences.session.mu.Lock()
+	defer preferences.session.mu.Unlock()
+	if err := preferences.session.checkConfig(); err != nil {
+		return nil, err
+	}
+	return preferences.session.preferences, nil
+}
+
+func (preferences *Preferences) GetPreferences() (config.Preferences, error) {
+	store, err := preferences.store()
+	if err != nil {
+		return config.DefaultPreferences(), err
+	}
+	return store.Get(), nil
+}
+
+// SetPreferences validates and saves the preferences, returning the ones
+// in use.
+func (preferences *Preferences) SetPreferences(changed config.Preferences) (config.Preferences, error) {
+	store, err := preferences.store()
+	if err != nil {
+		return config.DefaultPreferences(), err
+	}
+	if err := store.Set(changed); err != nil {
+		return store.Get(), err
+	}
+	runtime.EventsEmit(preferences.session.ctx, PREFERENCES_CHANGED_EVENT, changed)
+	return changed, nil
+}
+
+// ResetPreferences goes back to the default preferences.
+func (preferences *Preferences) ResetPreferences() (config.Preferences, error) {
+	return preferences.SetPreferences(config.DefaultPreferences())
+}
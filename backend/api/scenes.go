@@ -0,0 +1,206 @@
+package api
+
+import (
+	"slices"
+	"talenest/backend/internal/app/chapter"
+)
+
+const SCENES_REPOSITORY = "scenes"
+
+// Scenes is bound to the frontend to split the chapters into scenes.
+type Scenes struct {
+	session *Session
+}
+
+// Scene is a part of a chapter, povId and locationId being codex entities.
+type Scene struct {
+	Id         int    `json:"id"`
+	ChapterId  int    `json:"chapterId"`
+	Position   int    `json:"position"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	Synopsis   string `json:"synopsis"`
+	PovId      int    `json:"povId"`
+	LocationId int    `json:"locationId"`
+	StatusId   int    `json:"statusId"`
+	WordCount  int    `json:"wordCount"`
+	Version    int    `json:"version"`
+}
+
+func NewScenes(session *Session) *Scenes {
+	return &Scenes{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (scenesApi *Scenes) repository() (chapter.SceneRepository, error) {
+	return repository(scenesApi.session, SCENES_REPOSITORY, chapter.NewSceneRepository)
+}
+
+// chaptersSaved finds the codex mentions of the chapters whose scenes
+// changed and compares their tales again for similarity.
+func (scenesApi *Scenes) chaptersSaved(chapterIds ...int) error {
+	index, err := mentionIndex(scenesApi.session)
+	if err != nil {
+		return err
+	}
+	chapters, err := chapterRepository(scenesApi.session)
+	if err != nil {
+		return err
+	}
+	taleIds := []int{}
+	for _, id := range chapterIds {
+		chapter, err := chapters.ReadById(id)
+		if err != nil {
+			return err
+		}
+		if _, err := index.Scan(chapter); err != nil {
+			return err
+		}
+		if !slices.Contains(taleIds, chapter.TaleId) {
+			taleIds = append(taleIds, chapter.TaleId)
+		}
+	}
+	engine, err := similarityEngine(scenesApi.session)
+	if err != nil {
+		return err
+	}
+	_, err = engine.UpdateTales(taleIds...)
+	return err
+}
+
+// ListScenes returns the scenes of a chapter in order.
+func (scenesApi *Scenes) ListScenes(chapterId int) ([]Scene, error) {
+	scenesApi.session.mu.Lock()
+	defer scenesApi.session.mu.Unlock()
+	repo, err := scenesApi.repository()
+	if err != nil {
+		return []Scene{}, err
+	}
+	scenes, err := repo.ReadByChapter(chapterId)
+	if err != nil {
+		return []Scene{}, err
+	}
+	converted := make([]Scene, len(scenes))
+	for i, scene := range scenes {
+		converted[i] = sceneInfo(scene)
+	}
+	return converted, nil
+}
+
+// CreateScene adds a scene at the end of its chapter.
+func (scenesApi *Scenes) CreateScene(created Scene) (Scene, error) {
+	scenesApi.session.mu.Lock()
+	defer scenesApi.session.mu.Unlock()
+	repo, err := scenesApi.repository()
+	if err != nil {
+		return created, err
+	}
+	scene := newScene(created)
+	if _, err := repo.Create(scene); err != nil {
+		return created, err
+	}
+	return sceneInfo(*scene), scenesApi.chaptersSaved(scene.ChapterId)
+}
+
+// UpdateScene saves a scene read before, it fails if the scene changed
+// since then.
+func (scenesApi *Scenes) UpdateScene(updated Scene) (Scene, error) {
+	scenesApi.session.mu.Lock()
+	defer scenesApi.session.mu.Unlock()
+	repo, err := scenesApi.repository()
+	if err != nil {
+		return updated, err
+	}
+	scene := newScene(updated)
+	if err := repo.Update(scene); err != nil {
+		return updated, err
+	}
+	return sceneInfo(*scene), scenesApi.chaptersSaved(scene.ChapterId)
+}
+
+func (scenesApi *Scenes) DeleteScene(id int) error {
+	scenesApi.session.mu.Lock()
+	defer scenesApi.session.mu.Unlock()
+	repo, err := scenesApi.repository()
+	if err != nil {
+		return err
+	}
+	scene, err := repo.ReadById(id)
+	if err != nil {
+		return err
+	}
+	if err := repo.Delete(id); err != nil {
+		return err
+	}
+	return scenesApi.chaptersSaved(scene.ChapterId)
+}
+
+// ReorderScenes orders the scenes of a chapter as sceneIds, which must
+// list all of them.
+func (scenesApi *Scenes) ReorderScenes(chapterId int, sceneIds []int) error {
+	scenesApi.session.mu.Lock()
+	defer scenesApi.session.mu.Unlock()
+	repo, err := scenesApi.repository()
+	if err != nil {
+		return err
+	}
+	if err := repo.Reorder(chapterId, sceneIds); err != nil {
+		return err
+	}
+	return scenesApi.chaptersSaved(chapterId)
+}
+
+// MoveScene moves a scene to position in a chapter, last when position is
+// out of range.
+func (scenesApi *Scenes) MoveScene(sceneId, chapterId, position int) error {
+	scenesApi.session.mu.Lock()
+	defer scenesApi.session.mu.Unlock()
+	repo, err := scenesApi.repository()
+	if err != nil {
+		return err
+	}
+	scene, err := repo.ReadById(sceneId)
+	if err != nil {
+		return err
+	}
+	if err := repo.Move(sceneId, chapterId, position); err != nil {
+		return err
+	}
+	if scene.ChapterId == chapterId {
+		return scenesApi.chaptersSaved(chapterId)
+	}
+	return scenesApi.chaptersSaved(scene.ChapterId, chapterId)
+}
+
+func newScene(info Scene) *chapter.Scene {
+	return &chapter.Scene{
+		Id:         info.Id,
+		ChapterId:  info.ChapterId,
+		Position:   info.Position,
+		Title:      info.Title,
+		Content:    info.Content,
+		Synopsis:   info.Synopsis,
+		PovId:      info.PovId,
+		LocationId: info.LocationId,
+		StatusId:   info.StatusId,
+		Version:    info.Version,
+	}
+}
+
+func sceneInfo(scene chapter.Scene) Scene {
+	return Scene{
+		Id:         scene.Id,
+		ChapterId:  scene.ChapterId,
+		Position:   scene.Position,
+		Title:      scene.Title,
+		Content:    scene.Content,
+		Synopsis:   scene.Synopsis,
+		PovId:      scene.PovId,
+		LocationId: scene.LocationId,
+		StatusId:   scene.StatusId,
+		WordCount:  scene.WordCount,
+		Version:    scene.Version,
+	}
+}
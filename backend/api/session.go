@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	LIBRARY_CHANGED_EVENT     = "library:changed"
+	PREFERENCES_CHANGED_EVENT = "preferences:changed"
+)
+
+var errNoLibrary = errors.New("no library is open")
+
+// Session holds the state shared by the structs bound to the frontend: the
+// Wails context, the configuration and the database of the open library.
+// It isn't bound itself so its lifecycle methods aren't exposed.
+type Session struct {
+	mu          sync.Mutex
+	ctx         context.Context
+	configFile  string
+	cfg         *config.Config
+	preferences *config.PreferencesStore
+	dbConn      *data.DatabaseConnector
+	startupErr  error
+	// repositories are opened on the library database on first use and
+	// closed with it, see repository.
+	repositories map[string]io.Closer
+}
+
+// NewSession creates a session reading the configuration from configFile,
+// or from the default location when it's empty.
+func NewSession(configFile string) *Session {
+	return &Session{
+		configFile:   configFile,
+		repositories: map[string]io.Closer{},
+	}
+}
+
+// Startup loads the configuration and opens the library. Failures are kept
+// so the frontend can offer a recovery, see Library.GetStartupState.
+func (session *Session) Startup(ctx context.Context) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.ctx = ctx
+	cfg, err := config.Load(session.configFile)
+	if err != nil {
+		session.startupErr = err
+		return
+	}
+	session.cfg = cfg
+	session.loadPreferences()
+	if err := session.openDatabase(); err != nil {
+		log.Printf("saving the current library: %v", err)
+	}
+}
+
+func (session *Session) Shutdown(ctx context.Context) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if err := session.closeDatabase(); err != nil {
+		log.Printf("closing the library: %v", err)
+	}
+	if session.preferences != nil {
+		session.preferences.Close()
+	}
+}
+
+// loadPreferences reads the preferences and watches their file. The
+// defaults are used when the file is invalid.
+func (session *Session) loadPreferences() {
+	preferences, err := config.LoadPreferences(session.cfg.PreferencesFile())
+	if err != nil {
+		log.Printf("loading the preferences: %v", err)
+	}
+	session.preferences = preferences
+	err = preferences.Watch(func(changed config.Preferences) {
+		runtime.EventsEmit(session.ctx, PREFERENCES_CHANGED_EVENT, changed)
+	})
+	if err != nil {
+		log.Printf("watching the preferences: %v", err)
+	}
+}
+
+// checkConfig returns the error met loading the configuration, nothing can
+// be done until it's fixed.
+func (session *Session) checkConfig() error {
+	if session.cfg == nil {
+		return session.startupErr
+	}
+	return nil
+}
+
+// openDatabase opens the current library, which becomes the library opened
+// at the next start once it opens. The error is about saving that choice,
+// failures to open are kept in startupErr.
+func (session *Session) openDatabase() error {
+	if session.cfg.CurrentLibrary == "" {
+		session.dbConn, session.startupErr = nil, errNoLibrary
+		return nil
+	}
+	session.dbConn, session.startupErr = data.NewDatabaseConnector(
+		"sqlite", session.cfg.SQLitePath, session.cfg.LibraryBackupPath(), session.cfg.SQLite)
+	if session.startupErr != nil {
+		return nil
+	}
+	return session.cfg.SetCurrentLibrary(session.cfg.CurrentLibrary)
+}
+
+// closeDatabase closes the repositories and the database of the library.
+func (session *Session) closeDatabase() error {
+	errs := []error{}
+	for name, repository := range session.repositories {
+		errs = append(errs, repository.Close())
+		delete(session.repositories, name)
+	}
+	if session.dbConn != nil {
+		errs = append(errs, session.dbConn.Close())
+		session.dbConn = nil
+	}
+	return errors.Join(errs...)
+}
+
+// switchLibrary closes the open library and opens the one named name. An
+// empty name only closes the library.
+func (session *Session) switchLibrary(name string) error {
+	if _, ok := session.cfg.Library(name); name != "" && !ok {
+		return fmt.Errorf("unknown library %q", name)
+	}
+	if err := session.closeDatabase(); err != nil {
+		return err
+	}
+	if name == "" {
+		session.startupErr = errNoLibrary
+		return session.cfg.SetCurrentLibrary("")
+	}
+	session.cfg.UseLibrary(name)
+	return session.openDatabase()
+}
+
+// notifyLibraryChanged tells the frontend views to reload their data.
+func (session *Session) notifyLibraryChanged(state StartupState) {
+	if session.ctx != nil {
+		runtime.EventsEmit(session.ctx, LIBRARY_CHANGED_EVENT, state)
+	}
+}
+
+// repository returns the repository named name of the open library,
+// opening it on first use.
+func repository[T io.Closer](session *Session, name string, open func(*data.DatabaseConnector) (T, error)) (T, error) {
+	var none T
+	if session.dbConn == nil {
+		return none, errNoLibrary
+	}
+	if repository, ok := session.repositories[name]; ok {
+		return repository.(T), nil
+	}
+	repository, err := open(session.dbConn)
+	if err != nil {
+		return none, err
+	}
+	session.repositories[name] = repository
+	return repository, nil
+}
+
+// staleEntity returns the stored entity when err reports that it changed
+// since it was read, see data.StaleError.
+func staleEntity[T any](err error) (*T, bool) {
+	var stale *data.StaleError[T]
+	if errors.As(err, &stale) {
+		return stale.Current, true
+	}
+	return nil, false
+}
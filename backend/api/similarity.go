@@ -0,0 +1,110 @@
+package api
+
+import (
+	"talenest/backend/internal/app/similarity"
+	"talenest/backend/internal/data"
+)
+
+const (
+	CHAPTERS_REPOSITORY = "chapters"
+	SIMILARITY_ENGINE   = "similarity"
+)
+
+// Similarity is bound to the frontend to review the tales found similar
+// from their chapters.
+type Similarity struct {
+	session *Session
+}
+
+// SimilarPair is two tales found similar, with the estimated share of
+// their text they have in common.
+type SimilarPair struct {
+	FirstTaleId  int     `json:"firstTaleId"`
+	SecondTaleId int     `json:"secondTaleId"`
+	Score        float64 `json:"score"`
+}
+
+func NewSimilarity(session *Session) *Similarity {
+	return &Similarity{
+		session: session,
+	}
+}
+
+// engine must be called with the session locked.
+func (similarityApi *Similarity) engine() (*similarity.Engine, error) {
+	return similarityEngine(similarityApi.session)
+}
+
+// similarityEngine must be called with the session locked.
+func similarityEngine(session *Session) (*similarity.Engine, error) {
+	chapters, err := chapterRepository(session)
+	if err != nil {
+		return nil, err
+	}
+	return repository(session, SIMILARITY_ENGINE, func(dbConn *data.DatabaseConnector) (*similarity.Engine, error) {
+		return similarity.NewEngine(dbConn, chapters, session.cfg.Similarity)
+	})
+}
+
+// UpdateSimilarTales compares the tales whose chapters changed and returns
+// the similar ones found.
+func (similarityApi *Similarity) UpdateSimilarTales() ([]SimilarPair, error) {
+	similarityApi.session.mu.Lock()
+	defer similarityApi.session.mu.Unlock()
+	engine, err := similarityApi.engine()
+	if err != nil {
+		return []SimilarPair{}, err
+	}
+	result, err := engine.Update()
+	if err != nil {
+		return []SimilarPair{}, err
+	}
+	return similarPairs(result.Similar), nil
+}
+
+// GetSimilarityProposals returns the similar tales waiting for review.
+func (similarityApi *Similarity) GetSimilarityProposals() ([]SimilarPair, error) {
+	similarityApi.session.mu.Lock()
+	defer similarityApi.session.mu.Unlock()
+	engine, err := similarityApi.engine()
+	if err != nil {
+		return []SimilarPair{}, err
+	}
+	proposals, err := engine.Proposals()
+	if err != nil {
+		return []SimilarPair{}, err
+	}
+	return similarPairs(proposals), nil
+}
+
+func (similarityApi *Similarity) AcceptSimilarity(taleId, otherId int) error {
+	similarityApi.session.mu.Lock()
+	defer similarityApi.session.mu.Unlock()
+	engine, err := similarityApi.engine()
+	if err != nil {
+		return err
+	}
+	return engine.Accept(taleId, otherId)
+}
+
+func (similarityApi *Similarity) RejectSimilarity(taleId, otherId int) error {
+	similarityApi.session.mu.Lock()
+	defer similarityApi.session.mu.Unlock()
+	engine, err := similarityApi.engine()
+	if err != nil {
+		return err
+	}
+	return engine.Reject(taleId, otherId)
+}
+
+func similarPairs(pairs []similarity.Pair) []SimilarPair {
+	similar := make([]SimilarPair, len(pairs))
+	for i, pair := range pairs {
+		similar[i] = SimilarPair{
+			FirstTaleId:  pair.FirstTaleId,
+			SecondTaleId: pair.SecondTaleId,
+			Score:        pair.Score,
+		}
+	}
+	return similar
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"cmp"
+	"slices"
+	"talenest/backend/internal/app/status"
+)
+
+const STATUSES_REPOSITORY = "statuses"
+
+// Statuses is bound to the frontend to show and edit the statuses of the
+// tales and the workflow they move through.
+type Statuses struct {
+	session *Session
+}
+
+// Status is a status of the tales with its color and the color of the
+// text shown on it, both as "#rrggbb".
+type Status struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+	TextColor string `json:"textColor"`
+	Position  int    `json:"position"`
+	Terminal  bool   `json:"terminal"`
+}
+
+// StatusTransition allows tales to move from a status to another.
+type StatusTransition struct {
+	FromStatusId int `json:"fromStatusId"`
+	ToStatusId   int `json:"toStatusId"`
+}
+
+// Workflow is the statuses in order with the transitions between them.
+type Workflow struct {
+	Statuses    []Status           `json:"statuses"`
+	Transitions []StatusTransition `json:"transitions"`
+}
+
+func NewStatuses(session *Session) *Statuses {
+	return &Statuses{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (statusesApi *Statuses) repository() (status.Repository, error) {
+	return repository(statusesApi.session, STATUSES_REPOSITORY, status.NewRepository)
+}
+
+// ListStatuses returns the statuses in the order of the workflow.
+func (statusesApi *Statuses) ListStatuses() ([]Status, error) {
+	statusesApi.session.mu.Lock()
+	defer statusesApi.session.mu.Unlock()
+	repo, err := statusesApi.repository()
+	if err != nil {
+		return []Status{}, err
+	}
+	statuses, err := repo.ReadAll()
+	if err != nil {
+		return []Status{}, err
+	}
+	slices.SortFunc(statuses, func(a, b status.Status) int {
+		return cmp.Compare(a.Position, b.Position)
+	})
+	converted := make([]Status, len(statuses))
+	for i, s := range statuses {
+		converted[i] = statusInfo(s)
+	}
+	return converted, nil
+}
+
+// SetStatusColor sets the color of a status, written as hex, a CSS color
+// name or hsl(), and returns the status with the normalized color.
+func (statusesApi *Statuses) SetStatusColor(id int, color string) (Status, error) {
+	statusesApi.session.mu.Lock()
+	defer statusesApi.session.mu.Unlock()
+	repo, err := statusesApi.repository()
+	if err != nil {
+		return Status{}, err
+	}
+	s, err := repo.ReadById(id)
+	if err != nil {
+		return Status{}, err
+	}
+	if err := s.SetColor(color); err != nil {
+		return statusInfo(*s), err
+	}
+	if err := repo.Update(*s); err != nil {
+		return Status{}, err
+	}
+	return statusInfo(*s), nil
+}
+
+// GetWorkflow returns the statuses in order and the transitions allowed
+// between them.
+func (statusesApi *Statuses) GetWorkflow() (Workflow, error) {
+	statusesApi.session.mu.Lock()
+	defer statusesApi.session.mu.Unlock()
+	converted := Workflow{Statuses: []Status{}, Transitions: []StatusTransition{}}
+	repo, err := statusesApi.repository()
+	if err != nil {
+		return converted, err
+	}
+	workflow, err := repo.ReadWorkflow()
+	if err != nil {
+		return converted, err
+	}
+	for _, from := range workflow.Statuses() {
+		converted.Statuses = append(converted.Statuses, statusInfo(from))
+		for _, to := range workflow.Next(from.Id) {
+			converted.Transitions = append(converted.Transitions, StatusTransition{
+				FromStatusId: from.Id,
+				ToStatusId:   to.Id,
+			})
+		}
+	}
+	return converted, nil
+}
+
+func (statusesApi *Statuses) AddStatusTransition(fromId, toId int) error {
+	statusesApi.session.mu.Lock()
+	defer statusesApi.session.mu.Unlock()
+	repo, err := statusesApi.repository()
+	if err != nil {
+		return err
+	}
+	_, err = repo.AddTransition(fromId, toId)
+	return err
+}
+
+func (statusesApi *Statuses) RemoveStatusTransition(fromId, toId int) error {
+	statusesApi.session.mu.Lock()
+	defer statusesApi.session.mu.Unlock()
+	repo, err := statusesApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.RemoveTransition(fromId, toId)
+}
+
+// ReorderStatuses sets the workflow order of the statuses to the one of
+// statusIds.
+func (statusesApi *Statuses) ReorderStatuses(statusIds []int) error {
+	statusesApi.session.mu.Lock()
+	defer statusesApi.session.mu.Unlock()
+	repo, err := statusesApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.Reorder(statusIds)
+}
+
+func statusInfo(s status.Status) Status {
+	return Status{
+		Id:        s.Id,
+		Name:      s.Name,
+		Color:     "#" + s.GetColor().Hex(),
+		TextColor: "#" + s.TextColor().Hex(),
+		Position:  s.Position,
+		Terminal:  s.Terminal,
+	}
+}
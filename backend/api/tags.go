@@ -0,0 +1,203 @@
+package api
+
+import (
+	"talenest/backend/internal/app/suggestions"
+	"talenest/backend/internal/app/tags"
+)
+
+const TAGS_REPOSITORY = "tags"
+
+// Tags is bound to the frontend to show how the tags are used, e.g. as a
+// tag cloud or a network of the tags found on the same tales, and to
+// suggest tags from the chapters.
+type Tags struct {
+	session *Session
+}
+
+// TagInfo is a tag with the number of tales using it.
+type TagInfo struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	ParentId int    `json:"parentId"`
+	Count    int    `json:"count"`
+}
+
+// TagEdge links two tags found on Count tales together.
+type TagEdge struct {
+	Source int `json:"source"`
+	Target int `json:"target"`
+	Count  int `json:"count"`
+}
+
+type TagGraph struct {
+	Nodes []TagInfo `json:"nodes"`
+	Edges []TagEdge `json:"edges"`
+}
+
+// TagSuggestion is a tag found in the chapters of a tale, match being the
+// name or alias found and keywords its words.
+type TagSuggestion struct {
+	TagId    int      `json:"tagId"`
+	Path     string   `json:"path"`
+	Score    float64  `json:"score"`
+	Match    string   `json:"match"`
+	Keywords []string `json:"keywords"`
+}
+
+func NewTags(session *Session) *Tags {
+	return &Tags{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (tagsApi *Tags) repository() (tags.Repository, error) {
+	return repository(tagsApi.session, TAGS_REPOSITORY, tags.NewRepository)
+}
+
+// GetTagUsage returns every tag with the number of tales using it, the
+// most used first.
+func (tagsApi *Tags) GetTagUsage() ([]TagInfo, error) {
+	tagsApi.session.mu.Lock()
+	defer tagsApi.session.mu.Unlock()
+	repo, err := tagsApi.repository()
+	if err != nil {
+		return []TagInfo{}, err
+	}
+	usage, err := repo.ReadUsage()
+	if err != nil {
+		return []TagInfo{}, err
+	}
+	return tagInfos(usage), nil
+}
+
+// GetUnusedTags returns the tags no tale uses.
+func (tagsApi *Tags) GetUnusedTags() ([]TagInfo, error) {
+	tagsApi.session.mu.Lock()
+	defer tagsApi.session.mu.Unlock()
+	repo, err := tagsApi.repository()
+	if err != nil {
+		return []TagInfo{}, err
+	}
+	unused, err := repo.ReadUnused()
+	if err != nil {
+		return []TagInfo{}, err
+	}
+	return unusedInfos(unused), nil
+}
+
+// GetTagGraph returns the tags and how often they are found together.
+func (tagsApi *Tags) GetTagGraph() (TagGraph, error) {
+	tagsApi.session.mu.Lock()
+	defer tagsApi.session.mu.Unlock()
+	graph := TagGraph{Nodes: []TagInfo{}, Edges: []TagEdge{}}
+	repo, err := tagsApi.repository()
+	if err != nil {
+		return graph, err
+	}
+	tagGraph, err := repo.ReadGraph()
+	if err != nil {
+		return graph, err
+	}
+	graph.Nodes = tagInfos(tagGraph.Nodes)
+	for _, edge := range tagGraph.Edges {
+		graph.Edges = append(graph.Edges, TagEdge{
+			Source: edge.FirstTagId,
+			Target: edge.SecondTagId,
+			Count:  edge.Count,
+		})
+	}
+	return graph, nil
+}
+
+// PruneUnusedTags deletes the tags no tale uses and returns them.
+func (tagsApi *Tags) PruneUnusedTags() ([]TagInfo, error) {
+	tagsApi.session.mu.Lock()
+	defer tagsApi.session.mu.Unlock()
+	repo, err := tagsApi.repository()
+	if err != nil {
+		return []TagInfo{}, err
+	}
+	pruned, err := repo.PruneUnused()
+	if err != nil {
+		return []TagInfo{}, err
+	}
+	return unusedInfos(pruned), nil
+}
+
+// suggester must be called with the session locked.
+func (tagsApi *Tags) suggester() (*suggestions.Suggester, error) {
+	repo, err := tagsApi.repository()
+	if err != nil {
+		return nil, err
+	}
+	chapters, err := chapterRepository(tagsApi.session)
+	if err != nil {
+		return nil, err
+	}
+	return suggestions.NewSuggester(chapters, repo), nil
+}
+
+// SuggestTags returns at most limit tags found in the chapters of a tale
+// it doesn't have, the best first. A limit of 0 returns them all.
+func (tagsApi *Tags) SuggestTags(taleId int, limit int) ([]TagSuggestion, error) {
+	tagsApi.session.mu.Lock()
+	defer tagsApi.session.mu.Unlock()
+	suggester, err := tagsApi.suggester()
+	if err != nil {
+		return []TagSuggestion{}, err
+	}
+	suggested, err := suggester.SuggestTags(taleId, limit)
+	if err != nil {
+		return []TagSuggestion{}, err
+	}
+	converted := make([]TagSuggestion, len(suggested))
+	for i, suggestion := range suggested {
+		converted[i] = TagSuggestion{
+			TagId:    suggestion.Tag.Id,
+			Path:     suggestion.Tag.Path,
+			Score:    suggestion.Score,
+			Match:    suggestion.Match,
+			Keywords: suggestion.Keywords,
+		}
+	}
+	return converted, nil
+}
+
+// AcceptTagSuggestion tags the tale with a suggested tag.
+func (tagsApi *Tags) AcceptTagSuggestion(taleId, tagId int) error {
+	tagsApi.session.mu.Lock()
+	defer tagsApi.session.mu.Unlock()
+	suggester, err := tagsApi.suggester()
+	if err != nil {
+		return err
+	}
+	return suggester.Accept(taleId, tagId)
+}
+
+func tagInfo(tag tags.Tag, count int) TagInfo {
+	return TagInfo{
+		Id:       tag.Id,
+		Name:     tag.Name,
+		Path:     tag.Path,
+		ParentId: tag.ParentId,
+		Count:    count,
+	}
+}
+
+func tagInfos(usage []tags.TagUsage) []TagInfo {
+	infos := make([]TagInfo, len(usage))
+	for i, tagUsage := range usage {
+		infos[i] = tagInfo(tagUsage.Tag, tagUsage.Count)
+	}
+	return infos
+}
+
+func unusedInfos(unused []tags.Tag) []TagInfo {
+	infos := make([]TagInfo, len(unused))
+	for i, tag := range unused {
+		infos[i] = tagInfo(tag, 0)
+	}
+	return infos
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"talenest/backend/internal/app/status"
+	"talenest/backend/internal/app/tales"
+	"talenest/backend/internal/data"
+	"time"
+)
+
+const TALES_REPOSITORY = "tales"
+
+// Tales is bound to the frontend to create the tales and follow their
+// status.
+type Tales struct {
+	session *Session
+}
+
+// Tale is a story of the library, nested in the tale parentId.
+type Tale struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Summary  string `json:"summary"`
+	ParentId int    `json:"parentId"`
+	StatusId int    `json:"statusId"`
+	Version  int    `json:"version"`
+}
+
+// TaleUpdate is the result of saving a tale. Conflict is set when the tale
+// changed since it was read, Current then holding the stored tale so it
+// can be merged with the edits or overwritten, see OverwriteTale.
+type TaleUpdate struct {
+	Tale     Tale  `json:"tale"`
+	Conflict bool  `json:"conflict"`
+	Current  *Tale `json:"current"`
+}
+
+// StatusChange is a move of a tale between two statuses, fromStatusId
+// being 0 for the status it was created in.
+type StatusChange struct {
+	Id           int       `json:"id"`
+	TaleId       int       `json:"taleId"`
+	FromStatusId int       `json:"fromStatusId"`
+	ToStatusId   int       `json:"toStatusId"`
+	ChangedBy    string    `json:"changedBy"`
+	ChangedAt    time.Time `json:"changedAt"`
+}
+
+// StatusDuration is the time a tale spent in a status, in seconds.
+type StatusDuration struct {
+	StatusId int     `json:"statusId"`
+	Seconds  float64 `json:"seconds"`
+}
+
+// FlowPoint counts the tales in each status, by status id, at the end of
+// a day written as "2006-01-02".
+type FlowPoint struct {
+	Date   string      `json:"date"`
+	Counts map[int]int `json:"counts"`
+}
+
+func NewTales(session *Session) *Tales {
+	return &Tales{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (talesApi *Tales) repository() (tales.Repository, error) {
+	return repository(talesApi.session, TALES_REPOSITORY, tales.NewRepository)
+}
+
+// defaultStatus returns the status of the preferences given to the new
+// tales, or the built-in one when it was deleted. It must be called with
+// the session locked.
+func (talesApi *Tales) defaultStatus() (status.Status, error) {
+	if talesApi.session.preferences == nil {
+		return status.GetDefault(), nil
+	}
+	statuses, err := repository(talesApi.session, STATUSES_REPOSITORY, status.NewRepository)
+	if err != nil {
+		return status.Status{}, err
+	}
+	preferred, err := statuses.ReadById(talesApi.session.preferences.Get().DefaultStatusId)
+	if errors.Is(err, data.ErrNotFound) {
+		return status.GetDefault(), nil
+	}
+	if err != nil {
+		return status.Status{}, err
+	}
+	return *preferred, nil
+}
+
+// CreateTale creates a tale in the default status of the preferences,
+// under the root tale when parentId is 0.
+func (talesApi *Tales) CreateTale(created Tale) (Tale, error) {
+	talesApi.session.mu.Lock()
+	defer talesApi.session.mu.Unlock()
+	repo, err := talesApi.repository()
+	if err != nil {
+		return created, err
+	}
+	defaultStatus, err := talesApi.defaultStatus()
+	if err != nil {
+		return created, err
+	}
+	tale := tales.Create()
+	tale.Name = created.Name
+	tale.Summary = created.Summary
+	if created.ParentId != 0 {
+		tale.ParentId = created.ParentId
+	}
+	tale.Status = defaultStatus
+	if _, err := repo.Create(tale); err != nil {
+		return created, err
+	}
+	return taleInfo(*tale), nil
+}
+
+// UpdateTale saves the name, summary and parent of a tale read before,
+// reporting a conflict if the tale changed since then. The status changes
+// through TransitionTaleStatus.
+func (talesApi *Tales) UpdateTale(updated Tale) (TaleUpdate, error) {
+	return talesApi.saveTale(updated, false)
+}
+
+// OverwriteTale saves a tale like UpdateTale, replacing the changes made
+// since it was read.
+func (talesApi *Tales) OverwriteTale(updated Tale) (TaleUpdate, error) {
+	return talesApi.saveTale(updated, true)
+}
+
+func (talesApi *Tales) saveTale(updated Tale, overwrite bool) (TaleUpdate, error) {
+	talesApi.session.mu.Lock()
+	defer talesApi.session.mu.Unlock()
+	result := TaleUpdate{Tale: updated}
+	repo, err := talesApi.repository()
+	if err != nil {
+		return result, err
+	}
+	tale, err := repo.ReadById(updated.Id)
+	if err != nil {
+		return result, err
+	}
+	tale.Name = updated.Name
+	tale.Summary = updated.Summary
+	if updated.ParentId != 0 {
+		tale.ParentId = updated.ParentId
+	}
+	tale.Version = updated.Version
+
+	err = repo.Update(tale)
+	current, stale := staleEntity[tales.Tale](err)
+	if stale && overwrite {
+		tale.Version = current.Version
+		err = repo.Update(tale)
+		current, stale = staleEntity[tales.Tale](err)
+	}
+	if stale {
+		info := taleInfo(*current)
+		result.Conflict, result.Current = true, &info
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	result.Tale = taleInfo(*tale)
+	return result, nil
+}
+
+// TransitionTaleStatus moves a tale to the status toStatusId if the
+// workflow allows it, the change being recorded under the name of the
+// user of the system.
+func (talesApi *Tales) TransitionTaleStatus(taleId, toStatusId int) (Tale, error) {
+	talesApi.session.mu.Lock()
+	defer talesApi.session.mu.Unlock()
+	repo, err := talesApi.repository()
+	if err != nil {
+		return Tale{}, err
+	}
+	statuses, err := repository(talesApi.session, STATUSES_REPOSITORY, status.NewRepository)
+	if err != nil {
+		return Tale{}, err
+	}
+	tale, err := repo.ReadById(taleId)
+	if err != nil {
+		return Tale{}, err
+	}
+	workflow, err := statuses.ReadWorkflow()
+	if err != nil {
+		return taleInfo(*tale), err
+	}
+	changedBy := ""
+	if current, err := user.Current(); err == nil {
+		changedBy = current.Username
+	}
+	if _, err := repo.TransitionStatus(tale, workflow, toStatusId, changedBy); err != nil {
+		return taleInfo(*tale), err
+	}
+	return taleInfo(*tale), nil
+}
+
+// ListStatusChanges returns the status changes of a tale, oldest first.
+func (talesApi *Tales) ListStatusChanges(taleId int) ([]StatusChange, error) {
+	talesApi.session.mu.Lock()
+	defer talesApi.session.mu.Unlock()
+	repo, err := talesApi.repository()
+	if err != nil {
+		return []StatusChange{}, err
+	}
+	changes, err := repo.ReadStatusChanges(taleId)
+	if err != nil {
+		return []StatusChange{}, err
+	}
+	converted := make([]StatusChange, len(changes))
+	for i, change := range changes {
+		converted[i] = StatusChange{
+			Id:           change.Id,
+			TaleId:       change.TaleId,
+			FromStatusId: change.FromStatusId,
+			ToStatusId:   change.ToStatusId,
+			ChangedBy:    change.ChangedBy,
+			ChangedAt:    change.ChangedAt,
+		}
+	}
+	return converted, nil
+}
+
+// GetTimeInStatus returns the time a tale spent in each status until now,
+// in order of first entry.
+func (talesApi *Tales) GetTimeInStatus(taleId int) ([]StatusDuration, error) {
+	talesApi.session.mu.Lock()
+	defer talesApi.session.mu.Unlock()
+	repo, err := talesApi.repository()
+	if err != nil {
+		return []StatusDuration{}, err
+	}
+	durations, err := repo.TimeInStatus(taleId)
+	if err != nil {
+		return []StatusDuration{}, err
+	}
+	converted := make([]StatusDuration, len(durations))
+	for i, duration := range durations {
+		converted[i] = StatusDuration{
+			StatusId: duration.StatusId,
+			Seconds:  duration.Duration.Seconds(),
+		}
+	}
+	return converted, nil
+}
+
+// GetCumulativeFlow counts the tales of the library in each status for
+// every day from from to to, both written as "2006-01-02".
+func (talesApi *Tales) GetCumulativeFlow(from, to string) ([]FlowPoint, error) {
+	fromDate, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return []FlowPoint{}, fmt.Errorf("invalid start date %q", from)
+	}
+	toDate, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return []FlowPoint{}, fmt.Errorf("invalid end date %q", to)
+	}
+	talesApi.session.mu.Lock()
+	defer talesApi.session.mu.Unlock()
+	repo, err := talesApi.repository()
+	if err != nil {
+		return []FlowPoint{}, err
+	}
+	points, err := repo.CumulativeFlow(fromDate, toDate)
+	if err != nil {
+		return []FlowPoint{}, err
+	}
+	converted := make([]FlowPoint, len(points))
+	for i, point := range points {
+		converted[i] = FlowPoint{
+			Date:   point.Date.Format(time.DateOnly),
+			Counts: point.Counts,
+		}
+	}
+	return converted, nil
+}
+
+func taleInfo(tale tales.Tale) Tale {
+	return Tale{
+		Id:       tale.Id,
+		Name:     tale.Name,
+		Summary:  tale.Summary,
+		ParentId: tale.ParentId,
+		StatusId: tale.Status.Id,
+		Version:  tale.Version,
+	}
+}
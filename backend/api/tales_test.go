@@ -0,0 +1,43 @@
+package api
+
+import "testing"
+
+func TestUpdateTaleConflict(t *testing.T) {
+	talesApi := NewTales(newTestSession(t))
+	created, err := talesApi.CreateTale(Tale{Name: "draft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := created
+	first.Name = "first edit"
+	update, err := talesApi.UpdateTale(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Conflict || update.Tale.Version != created.Version+1 {
+		t.Fatalf("UpdateTale = %+v, want the first edit saved", update)
+	}
+
+	second := created
+	second.Summary = "second edit"
+	update, err = talesApi.UpdateTale(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !update.Conflict || update.Current == nil || update.Current.Name != "first edit" {
+		t.Fatalf("UpdateTale of an old version = %+v, want a conflict with the first edit", update)
+	}
+	if update.Tale != second {
+		t.Errorf("the conflict returned %+v, want the rejected edit %+v", update.Tale, second)
+	}
+
+	update, err = talesApi.OverwriteTale(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Conflict || update.Tale.Name != "draft" || update.Tale.Summary != "second edit" ||
+		update.Tale.Version != created.Version+2 {
+		t.Errorf("OverwriteTale = %+v, want the second edit saved over the first", update)
+	}
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"talenest/backend/internal/app/timeline"
+)
+
+const TIMELINE_REPOSITORY = "timeline"
+
+// Timeline is bound to the frontend to edit the in-world chronology of the
+// stories.
+type Timeline struct {
+	session *Session
+}
+
+type Calendar struct {
+	Id     int             `json:"id"`
+	Name   string          `json:"name"`
+	Era    string          `json:"era"`
+	Epoch  int64           `json:"epoch"`
+	Months []CalendarMonth `json:"months"`
+}
+
+type CalendarMonth struct {
+	Name string `json:"name"`
+	Days int    `json:"days"`
+}
+
+// TimelineEvent is dated in a calendar when calendarId is set, placed
+// offset days after another event when afterId is set.
+type TimelineEvent struct {
+	Id          int    `json:"id"`
+	TaleId      int    `json:"taleId"`
+	ChapterId   int    `json:"chapterId"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	CalendarId  int    `json:"calendarId"`
+	Year        int    `json:"year"`
+	Month       int    `json:"month"`
+	Day         int    `json:"day"`
+	AfterId     int    `json:"afterId"`
+	Offset      int    `json:"offset"`
+	Position    int    `json:"position"`
+}
+
+// TimelineEntry is an event placed on the timeline, date being empty when
+// its time is unknown.
+type TimelineEntry struct {
+	Event  TimelineEvent `json:"event"`
+	Moment int64         `json:"moment"`
+	Known  bool          `json:"known"`
+	Date   string        `json:"date"`
+}
+
+// TimelineConflict is an event told after the event later happens.
+type TimelineConflict struct {
+	Event TimelineEntry `json:"event"`
+	Later TimelineEntry `json:"later"`
+}
+
+func NewTimeline(session *Session) *Timeline {
+	return &Timeline{
+		session: session,
+	}
+}
+
+// repository must be called with the session locked.
+func (timelineApi *Timeline) repository() (timeline.Repository, error) {
+	return repository(timelineApi.session, TIMELINE_REPOSITORY, timeline.NewRepository)
+}
+
+func (timelineApi *Timeline) ListCalendars() ([]Calendar, error) {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return []Calendar{}, err
+	}
+	calendars, err := repo.ReadCalendars()
+	if err != nil {
+		return []Calendar{}, err
+	}
+	converted := make([]Calendar, len(calendars))
+	for i, calendar := range calendars {
+		converted[i] = calendarInfo(calendar)
+	}
+	return converted, nil
+}
+
+func (timelineApi *Timeline) CreateCalendar(created Calendar) (Calendar, error) {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return created, err
+	}
+	calendar := newCalendar(created)
+	if _, err := repo.CreateCalendar(calendar); err != nil {
+		return created, err
+	}
+	return calendarInfo(*calendar), nil
+}
+
+func (timelineApi *Timeline) UpdateCalendar(updated Calendar) (Calendar, error) {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return updated, err
+	}
+	calendar := newCalendar(updated)
+	if err := repo.UpdateCalendar(calendar); err != nil {
+		return updated, err
+	}
+	return calendarInfo(*calendar), nil
+}
+
+func (timelineApi *Timeline) DeleteCalendar(id int) error {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.DeleteCalendar(id)
+}
+
+func (timelineApi *Timeline) CreateTimelineEvent(created TimelineEvent) (TimelineEvent, error) {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return created, err
+	}
+	event := newEvent(created)
+	if _, err := repo.CreateEvent(event); err != nil {
+		return created, err
+	}
+	return timelineEvent(*event), nil
+}
+
+func (timelineApi *Timeline) UpdateTimelineEvent(updated TimelineEvent) (TimelineEvent, error) {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return updated, err
+	}
+	event := newEvent(updated)
+	if err := repo.UpdateEvent(event); err != nil {
+		return updated, err
+	}
+	return timelineEvent(*event), nil
+}
+
+// DeleteTimelineEvent deletes an event, the events placed after it are
+// placed from where it was.
+func (timelineApi *Timeline) DeleteTimelineEvent(id int) error {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return err
+	}
+	return repo.DeleteEvent(id)
+}
+
+// GetTimeline returns the events of a tale and its descendants in
+// chronological order.
+func (timelineApi *Timeline) GetTimeline(taleId int) ([]TimelineEntry, error) {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return []TimelineEntry{}, err
+	}
+	entries, err := repo.ReadTimeline(taleId)
+	if err != nil {
+		return []TimelineEntry{}, err
+	}
+	converted := make([]TimelineEntry, len(entries))
+	for i, entry := range entries {
+		converted[i] = timelineEntry(entry)
+	}
+	return converted, nil
+}
+
+// GetTimelineConflicts returns the events of a tale and its descendants
+// told after a chapter telling a later event.
+func (timelineApi *Timeline) GetTimelineConflicts(taleId int) ([]TimelineConflict, error) {
+	timelineApi.session.mu.Lock()
+	defer timelineApi.session.mu.Unlock()
+	repo, err := timelineApi.repository()
+	if err != nil {
+		return []TimelineConflict{}, err
+	}
+	conflicts, err := repo.ReadConflicts(taleId)
+	if err != nil {
+		return []TimelineConflict{}, err
+	}
+	converted := make([]TimelineConflict, len(conflicts))
+	for i, conflict := range conflicts {
+		converted[i] = TimelineConflict{
+			Event: timelineEntry(conflict.Event),
+			Later: timelineEntry(conflict.Later),
+		}
+	}
+	return converted, nil
+}
+
+func newCalendar(info Calendar) *timeline.Calendar {
+	months := make([]timeline.Month, len(info.Months))
+	for i, month := range info.Months {
+		months[i] = timeline.Month{Name: month.Name, Days: month.Days}
+	}
+	return &timeline.Calendar{
+		Id:     info.Id,
+		Name:   info.Name,
+		Era:    info.Era,
+		Epoch:  info.Epoch,
+		Months: months,
+	}
+}
+
+func calendarInfo(calendar timeline.Calendar) Calendar {
+	months := make([]CalendarMonth, len(calendar.Months))
+	for i, month := range calendar.Months {
+		months[i] = CalendarMonth{Name: month.Name, Days: month.Days}
+	}
+	return Calendar{
+		Id:     calendar.Id,
+		Name:   calendar.Name,
+		Era:    calendar.Era,
+		Epoch:  calendar.Epoch,
+		Months: months,
+	}
+}
+
+func newEvent(info TimelineEvent) *timeline.Event {
+	return &timeline.Event{
+		Id:          info.Id,
+		TaleId:      info.TaleId,
+		ChapterId:   info.ChapterId,
+		Title:       info.Title,
+		Description: info.Description,
+		CalendarId:  info.CalendarId,
+		Date:        timeline.Date{Year: info.Year, Month: info.Month, Day: info.Day},
+		AfterId:     info.AfterId,
+		Offset:      info.Offset,
+		Position:    info.Position,
+	}
+}
+
+func timelineEvent(event timeline.Event) TimelineEvent {
+	return TimelineEvent{
+		Id:          event.Id,
+		TaleId:      event.TaleId,
+		ChapterId:   event.ChapterId,
+		Title:       event.Title,
+		Description: event.Description,
+		CalendarId:  event.CalendarId,
+		Year:        event.Date.Year,
+		Month:       event.Date.Month,
+		Day:         event.Date.Day,
+		AfterId:     event.AfterId,
+		Offset:      event.Offset,
+		Position:    event.Position,
+	}
+}
+
+func timelineEntry(entry timeline.Entry) TimelineEntry {
+	return TimelineEntry{
+		Event:  timelineEvent(entry.Event),
+		Moment: entry.Moment,
+		Known:  entry.Known,
+		Date:   entry.Date,
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"talenest/backend/internal/app/attachments"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+)
+
+func runAttachments(cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing attachments command\n\n%s", usage)
+	}
+	dbConn, err := data.NewDatabaseConnector("sqlite", cfg.SQLitePath, cfg.LibraryBackupPath(), cfg.SQLite)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	repo, err := attachments.NewRepository(dbConn, attachments.NewStore(cfg.LibraryAttachmentsPath()))
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	switch args[0] {
+	case "list":
+		collection, err := repo.ReadAll()
+		if err != nil {
+			return err
+		}
+		for _, attachment := range collection {
+			fmt.Printf("%6d  %10d  %-24s  %s\n", attachment.Id, attachment.Size, attachment.MimeType, attachment.Name)
+		}
+	case "gc":
+		return collectAttachments(repo, args[1:])
+	default:
+		return fmt.Errorf("unknown attachments command %q\n\n%s", args[0], usage)
+	}
+	return nil
+}
+
+func collectAttachments(repo attachments.Repository, args []string) error {
+	dryRun := false
+	for _, arg := range args {
+		if arg != "--dry-run" {
+			return fmt.Errorf("unknown gc argument %q", arg)
+		}
+		dryRun = true
+	}
+	if dryRun {
+		garbage, err := repo.ReadGarbage()
+		if err != nil {
+			return err
+		}
+		printGarbage(garbage)
+		fmt.Printf("%d attachments and %d files (%d bytes) would be removed\n",
+			len(garbage.Attachments), len(garbage.Hashes), garbage.Size)
+		return nil
+	}
+
+	garbage, err := repo.CollectGarbage()
+	if garbage != nil {
+		printGarbage(garbage)
+		fmt.Printf("removed %d attachments and %d files (%d bytes)\n",
+			len(garbage.Attachments), len(garbage.Hashes), garbage.Size)
+	}
+	return err
+}
+
+func printGarbage(garbage *attachments.Garbage) {
+	for _, attachment := range garbage.Attachments {
+		fmt.Printf("attachment %d: %s\n", attachment.Id, attachment.Name)
+	}
+	for _, hash := range garbage.Hashes {
+		fmt.Printf("file %s\n", hash)
+	}
+}
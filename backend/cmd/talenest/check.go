@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+)
+
+func runCheck(cfg *config.Config, args []string) error {
+	repair := false
+	for _, arg := range args {
+		if arg != "--repair" {
+			return fmt.Errorf("unknown check argument %q", arg)
+		}
+		repair = true
+	}
+
+	dbConn, err := data.NewDatabaseConnector("sqlite", cfg.SQLitePath, cfg.LibraryBackupPath(), cfg.SQLite)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	violations, err := dbConn.CheckForeignKeys()
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		fmt.Println("no foreign key violations")
+		return nil
+	}
+	for _, violation := range violations {
+		fmt.Println(violation)
+	}
+	if !repair {
+		return fmt.Errorf("%d foreign key violations, run with --repair to fix them", len(violations))
+	}
+
+	backupPath, err := backupDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("backup: %s\n", backupPath)
+
+	repaired, err := dbConn.RepairForeignKeys()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("repaired %d rows\n", len(repaired))
+
+	left, err := dbConn.CheckForeignKeys()
+	if err != nil {
+		return err
+	}
+	for _, violation := range left {
+		fmt.Println(violation)
+	}
+	if len(left) > 0 {
+		return fmt.Errorf("%d foreign key violations can't be repaired", len(left))
+	}
+	return nil
+}
+
+func backupDatabase(cfg *config.Config) (string, error) {
+	migrator, err := data.NewMigrator(cfg.SQLitePath, cfg.LibraryBackupPath())
+	if err != nil {
+		return "", err
+	}
+	defer migrator.Close()
+	return migrator.Backup()
+}
@@ -1,13 +1,81 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"talenest/backend/internal/utils"
+	"os"
+	"talenest/backend/internal/config"
 )
 
+const usage = `usage: talenest [--config <file>] <command> [arguments]
+
+options:
+  --config <file>          read the configuration from file instead of the
+                           platform config directory, TALENEST_CONFIG too
+
+commands:
+  migrate status           show the applied migration and the pending ones
+  migrate up               apply every pending migration
+  migrate down [steps]     roll back the last migrations (default 1)
+  migrate to <version>     migrate up or down to version (0 reverts all)
+  migrate force <version>  set the version and clear the dirty state
+  migrate backup           copy the database in the backup directory
+  check [--repair]         report the rows breaking a foreign key and
+                           optionally repair them, after a backup
+  tags usage               list the tags with the number of tales using them
+  tags graph               list the tags used together and how often
+  tags unused              list the tags no tale uses
+  tags prune [--dry-run]   delete the unused tags, after a backup
+  similar update [--full]  compare the tales whose chapters changed, or all
+                           of them, and propose or link the similar ones
+  similar proposals        list the similar tales waiting for review
+  similar accept <a> <b>   link two tales proposed as similar
+  similar reject <a> <b>   drop a proposal
+  attachments list         list the attachments with their size and type
+  attachments gc [--dry-run]
+                           delete the attachments linked to nothing and
+                           remove the stored files no attachment holds,
+                           except the ones added within the last hour
+`
+
 func main() {
-	cfg := utils.LoadConfig()
-	fmt.Println(cfg.SQLitePath)
-	// sqliteConn := data.NewDatabaseConnector("sqlite", cfg.SQLitePath, cfg.SQLiteMigrationsPath)
+	configFile := flag.String("config", "", "read the configuration from `file`")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if cfg.CurrentLibrary == "" {
+		fmt.Fprintln(os.Stderr, "no library is open, open one in the app first")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "migrate":
+		err = runMigrate(cfg, args[1:])
+	case "check":
+		err = runCheck(cfg, args[1:])
+	case "tags":
+		err = runTags(cfg, args[1:])
+	case "similar":
+		err = runSimilar(cfg, args[1:])
+	case "attachments":
+		err = runAttachments(cfg, args[1:])
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
 
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+)
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing migrate command")
+	}
+
+	migrator, err := data.NewMigrator(cfg.SQLitePath, cfg.LibraryBackupPath())
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "status":
+		// handled below
+	case "up":
+		err = migrator.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+		}
+		err = migrator.Down(steps)
+	case "to":
+		if len(args) < 2 {
+			return errors.New("missing version")
+		}
+		version, parseErr := strconv.ParseUint(args[1], 10, 0)
+		if parseErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		err = migrator.To(uint(version))
+	case "force":
+		if len(args) < 2 {
+			return errors.New("missing version")
+		}
+		version, parseErr := strconv.Atoi(args[1])
+		if parseErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if _, err := migrator.Backup(); err != nil {
+			return err
+		}
+		err = migrator.Force(version)
+	case "backup":
+		path, err := migrator.Backup()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	return printMigrationStatus(migrator)
+}
+
+func printMigrationStatus(migrator *data.Migrator) error {
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d", status.Version)
+	if status.Dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Printf("\nlatest:  %d\n", status.Latest)
+	if len(status.Pending) > 0 {
+		fmt.Printf("pending: %v\n", status.Pending)
+	}
+	return nil
+}
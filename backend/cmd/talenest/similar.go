@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"talenest/backend/internal/app/chapter"
+	"talenest/backend/internal/app/similarity"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+)
+
+func runSimilar(cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing similar command\n\n%s", usage)
+	}
+	dbConn, err := data.NewDatabaseConnector("sqlite", cfg.SQLitePath, cfg.LibraryBackupPath(), cfg.SQLite)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	chapters, err := chapter.NewRepository(dbConn)
+	if err != nil {
+		return err
+	}
+	defer chapters.Close()
+	engine, err := similarity.NewEngine(dbConn, chapters, cfg.Similarity)
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+
+	switch args[0] {
+	case "update":
+		return updateSimilar(engine, args[1:])
+	case "proposals":
+		proposals, err := engine.Proposals()
+		if err != nil {
+			return err
+		}
+		for _, pair := range proposals {
+			fmt.Printf("%d %d  %.2f\n", pair.FirstTaleId, pair.SecondTaleId, pair.Score)
+		}
+	case "accept", "reject":
+		if len(args) != 3 {
+			return fmt.Errorf("expected two tale ids\n\n%s", usage)
+		}
+		taleId, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid tale id %q", args[1])
+		}
+		otherId, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid tale id %q", args[2])
+		}
+		if args[0] == "accept" {
+			return engine.Accept(taleId, otherId)
+		}
+		return engine.Reject(taleId, otherId)
+	default:
+		return fmt.Errorf("unknown similar command %q\n\n%s", args[0], usage)
+	}
+	return nil
+}
+
+func updateSimilar(engine *similarity.Engine, args []string) error {
+	full := false
+	for _, arg := range args {
+		if arg != "--full" {
+			return fmt.Errorf("unknown update argument %q", arg)
+		}
+		full = true
+	}
+	update := engine.Update
+	if full {
+		update = engine.Recompute
+	}
+	result, err := update()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d tales updated\n", len(result.Updated))
+	for _, pair := range result.Similar {
+		fmt.Printf("%d %d  %.2f\n", pair.FirstTaleId, pair.SecondTaleId, pair.Score)
+	}
+	return nil
+}
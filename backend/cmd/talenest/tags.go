@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"talenest/backend/internal/app/tags"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+)
+
+func runTags(cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing tags command\n\n%s", usage)
+	}
+	dbConn, err := data.NewDatabaseConnector("sqlite", cfg.SQLitePath, cfg.LibraryBackupPath(), cfg.SQLite)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	repo, err := tags.NewRepository(dbConn)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	switch args[0] {
+	case "usage":
+		usage, err := repo.ReadUsage()
+		if err != nil {
+			return err
+		}
+		for _, tagUsage := range usage {
+			fmt.Printf("%6d  %s\n", tagUsage.Count, tagUsage.Tag.Path)
+		}
+	case "graph":
+		graph, err := repo.ReadGraph()
+		if err != nil {
+			return err
+		}
+		paths := map[int]string{}
+		for _, node := range graph.Nodes {
+			paths[node.Tag.Id] = node.Tag.Path
+		}
+		for _, edge := range graph.Edges {
+			fmt.Printf("%6d  %s + %s\n", edge.Count, paths[edge.FirstTagId], paths[edge.SecondTagId])
+		}
+	case "unused":
+		unused, err := repo.ReadUnused()
+		if err != nil {
+			return err
+		}
+		for _, tag := range unused {
+			fmt.Println(tag.Path)
+		}
+	case "prune":
+		return pruneTags(cfg, repo, args[1:])
+	default:
+		return fmt.Errorf("unknown tags command %q\n\n%s", args[0], usage)
+	}
+	return nil
+}
+
+func pruneTags(cfg *config.Config, repo tags.Repository, args []string) error {
+	dryRun := false
+	for _, arg := range args {
+		if arg != "--dry-run" {
+			return fmt.Errorf("unknown prune argument %q", arg)
+		}
+		dryRun = true
+	}
+	if dryRun {
+		unused, err := repo.ReadUnused()
+		if err != nil {
+			return err
+		}
+		for _, tag := range unused {
+			fmt.Println(tag.Path)
+		}
+		fmt.Printf("%d unused tags would be deleted\n", len(unused))
+		return nil
+	}
+
+	backupPath, err := backupDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("backup: %s\n", backupPath)
+	pruned, err := repo.PruneUnused()
+	if err != nil {
+		return err
+	}
+	for _, tag := range pruned {
+		fmt.Println(tag.Path)
+	}
+	fmt.Printf("deleted %d unused tags\n", len(pruned))
+	return nil
+}
@@ -0,0 +1,106 @@
+package attachments
+
+import (
+	"errors"
+	"fmt"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+const tableName = "attachments"
+
+// Attachment is a file kept with the stories. Width and Height are set for
+// images, which have a thumbnail.
+type Attachment struct {
+	Id       int
+	Hash     string
+	Name     string
+	MimeType string
+	Size     int64
+	Width    int
+	Height   int
+	created  time.Time
+}
+
+type attachmentMapper struct{}
+
+// IsImage tells if the attachment is an image with a thumbnail.
+func (attachment *Attachment) IsImage() bool {
+	return attachment.Width > 0 && attachment.Height > 0
+}
+
+// Created returns when the content was last added.
+func (attachment *Attachment) Created() time.Time {
+	return attachment.created
+}
+
+func (attachment *Attachment) String() string {
+	return fmt.Sprintf("Attachment %d [%s]: %s", attachment.Id, attachment.Hash[:min(12, len(attachment.Hash))], attachment.Name)
+}
+
+func getColumnNames() []string {
+	return []string{
+		"id",
+		"hash",
+		"name",
+		"mime_type",
+		"size",
+		"width",
+		"height",
+		"created_at",
+	}
+}
+
+func (attachmentMapper) TableName() string {
+	return tableName
+}
+
+func (attachmentMapper) Columns() []string {
+	return getColumnNames()
+}
+
+func (attachmentMapper) Values(attachment *Attachment) []any {
+	return []any{
+		attachment.Id,
+		attachment.Hash,
+		attachment.Name,
+		attachment.MimeType,
+		attachment.Size,
+		attachment.Width,
+		attachment.Height,
+		utils.CleanTime(attachment.created),
+	}
+}
+
+func (attachmentMapper) Scan(scanner data.Scanner) (*Attachment, error) {
+	attachment := Attachment{}
+	var createdString string
+	err := scanner.Scan(
+		&attachment.Id,
+		&attachment.Hash,
+		&attachment.Name,
+		&attachment.MimeType,
+		&attachment.Size,
+		&attachment.Width,
+		&attachment.Height,
+		&createdString,
+	)
+	if err != nil {
+		return nil, err
+	}
+	created, err := time.Parse(utils.DATETIME_FORMAT, createdString)
+	if err != nil {
+		return nil, errors.New("Failed to parse time value")
+	}
+	attachment.created = created
+	return &attachment, nil
+}
+
+func (attachmentMapper) GetId(attachment *Attachment) int {
+	return attachment.Id
+}
+
+func (attachmentMapper) SetId(attachment *Attachment, id int) {
+	attachment.Id = id
+}
@@ -0,0 +1,364 @@
+package attachments
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+const (
+	READ_BY_HASH_STATEMENT    = "READ_BY_HASH"
+	READ_BY_TALE_STATEMENT    = "READ_BY_TALE"
+	READ_BY_CHAPTER_STATEMENT = "READ_BY_CHAPTER"
+	READ_UNLINKED_STATEMENT   = "READ_UNLINKED"
+	TOUCH_STATEMENT           = "TOUCH"
+	LINK_TALE_STATEMENT       = "LINK_TALE"
+	UNLINK_TALE_STATEMENT     = "UNLINK_TALE"
+	LINK_CHAPTER_STATEMENT    = "LINK_CHAPTER"
+	UNLINK_CHAPTER_STATEMENT  = "UNLINK_CHAPTER"
+)
+
+const linksTableName = "attachment_links"
+
+// GARBAGE_GRACE_PERIOD is the age under which attachments and stored files
+// aren't collected, as they may be being added.
+const GARBAGE_GRACE_PERIOD = time.Hour
+
+// ErrInvalidAttachment is returned for files that can't be attached.
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// Repository stores the attachments of a library: their content in a
+// Store and their links to the tales and chapters in the database.
+type Repository interface {
+	// Add stores content as an attachment named name. Content already
+	// stored returns the attachment holding it, under its first name, added
+	// again so that CollectGarbage leaves it until it's linked.
+	Add(name string, content io.Reader) (*Attachment, error)
+	// AddFile adds the file at path, named after it.
+	AddFile(path string) (*Attachment, error)
+	ReadById(id int) (*Attachment, error)
+	ReadAll() ([]Attachment, error)
+	ReadByTale(taleId int) ([]Attachment, error)
+	ReadByChapter(chapterId int) ([]Attachment, error)
+	// LinkTale links an attachment to a tale, linking it twice isn't an
+	// error.
+	LinkTale(attachmentId, taleId int) error
+	UnlinkTale(attachmentId, taleId int) error
+	// LinkChapter links an attachment to a chapter, linking it twice isn't
+	// an error.
+	LinkChapter(attachmentId, chapterId int) error
+	UnlinkChapter(attachmentId, chapterId int) error
+	// Delete deletes an attachment with its links, its content is removed
+	// by CollectGarbage.
+	Delete(id int) error
+	// Path returns the file holding the content of an attachment.
+	Path(attachment *Attachment) string
+	// Copy returns a copy of the content of an attachment named after it,
+	// for other applications to open.
+	Copy(attachment *Attachment) (string, error)
+	// ThumbnailPath returns the thumbnail of an image, see IsImage.
+	ThumbnailPath(attachment *Attachment) string
+	// ReadGarbage returns what CollectGarbage would remove.
+	ReadGarbage() (*Garbage, error)
+	// CollectGarbage deletes the attachments linked to nothing and removes
+	// the stored files no attachment holds, leaving the ones added within
+	// GARBAGE_GRACE_PERIOD.
+	CollectGarbage() (*Garbage, error)
+	Close() error
+}
+
+// Garbage is what CollectGarbage removes.
+type Garbage struct {
+	// Attachments are the attachments linked to nothing.
+	Attachments []Attachment
+	// Hashes are the contents stored for no attachment but the ones above.
+	Hashes []string
+	// Size is the size of their files, thumbnails included, in bytes.
+	Size int64
+}
+
+type attachmentRepository struct {
+	dbConn   *data.DatabaseConnector
+	store    *Store
+	entities *data.Repository[Attachment]
+}
+
+func NewRepository(dbConn *data.DatabaseConnector, store *Store) (Repository, error) {
+	entities, err := data.NewRepository[Attachment](dbConn, attachmentMapper{})
+	if err != nil {
+		return nil, err
+	}
+	queries := map[string]string{
+		READ_BY_HASH_STATEMENT:    data.ReadByColumnQuery(tableName, getColumnNames(), "hash"),
+		READ_BY_TALE_STATEMENT:    readByLinkQuery("tale_id"),
+		READ_BY_CHAPTER_STATEMENT: readByLinkQuery("chapter_id"),
+		READ_UNLINKED_STATEMENT:   readUnlinkedQuery(),
+		TOUCH_STATEMENT:           data.UpdateColumnsQuery(tableName, []string{"created_at"}),
+		LINK_TALE_STATEMENT:       data.CreateIgnoreQuery(linksTableName, []string{"attachment_id", "tale_id"}),
+		UNLINK_TALE_STATEMENT:     data.DeleteByColumnsQuery(linksTableName, []string{"attachment_id", "tale_id"}),
+		LINK_CHAPTER_STATEMENT:    data.CreateIgnoreQuery(linksTableName, []string{"attachment_id", "chapter_id"}),
+		UNLINK_CHAPTER_STATEMENT:  data.DeleteByColumnsQuery(linksTableName, []string{"attachment_id", "chapter_id"}),
+	}
+	for name, query := range queries {
+		if err := entities.Prepare(name, query); err != nil {
+			entities.Close()
+			return nil, err
+		}
+	}
+	return &attachmentRepository{
+		dbConn:   dbConn,
+		store:    store,
+		entities: entities,
+	}, nil
+}
+
+// readByLinkQuery selects the attachments linked through column.
+func readByLinkQuery(column string) string {
+	id, _ := data.NewColumn("id", "")
+	attachmentId, _ := data.NewColumn("attachment_id", "")
+	linkColumn, _ := data.NewColumn(column, "")
+	orderColumn, _ := data.NewColumn(tableName+".id", "")
+	columns := []data.Column{}
+	for _, name := range getColumnNames() {
+		column, _ := data.NewColumn(tableName+"."+name, "")
+		columns = append(columns, *column)
+	}
+	builder := data.NewSelectQueryBuilder(tableName)
+	builder.SetColumns(columns)
+	builder.SetJoin(tableName, *id, linksTableName, *attachmentId, "")
+	builder.SetWhere(linksTableName, *linkColumn, "=", data.NewTokenValue("?"), "")
+	builder.AddOrderBy(*orderColumn, "ASC")
+	query, _ := builder.Build()
+	return query
+}
+
+// readUnlinkedQuery selects the attachments linked to nothing, created
+// before the given time.
+func readUnlinkedQuery() string {
+	id, _ := data.NewColumn("id", "")
+	created, _ := data.NewColumn("created_at", "")
+	linked := data.NewSelectQueryBuilder(linksTableName)
+	linked.SetColumns(data.ConvertToColumns([]string{"attachment_id"}))
+	builder := data.NewSelectQueryBuilder(tableName)
+	builder.SetColumns(data.ConvertToColumns(getColumnNames()))
+	builder.SetWhereNotInSubquery(tableName, *id, linked, "")
+	builder.SetWhere(tableName, *created, "<", data.NewTokenValue("?"), "AND")
+	builder.AddOrderBy(*id, "ASC")
+	query, _ := builder.Build()
+	return query
+}
+
+// Add stores the content before saving the attachment, a content left
+// without attachment when saving fails is removed by CollectGarbage once
+// older than GARBAGE_GRACE_PERIOD.
+func (repo attachmentRepository) Add(name string, content io.Reader) (*Attachment, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: the name is empty", ErrInvalidAttachment)
+	}
+	hash, size, err := repo.store.Put(content)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := repo.entities.ReadMany(READ_BY_HASH_STATEMENT, hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		attachment := existing[0]
+		attachment.created = time.Now()
+		if err := repo.entities.Exec(TOUCH_STATEMENT, utils.CleanTime(attachment.created), attachment.Id); err != nil {
+			return nil, err
+		}
+		return attachment, nil
+	}
+
+	path := repo.store.Path(hash)
+	attachment := &Attachment{
+		Hash:     hash,
+		Name:     name,
+		MimeType: mimeType(path, name),
+		Size:     size,
+		created:  time.Now(),
+	}
+	if width, height, ok := imageSize(path); ok {
+		// an image that can't be decoded, or is too large to, is kept as a
+		// file
+		if err := writeThumbnail(path, repo.store.ThumbnailPath(hash)); err == nil {
+			attachment.Width, attachment.Height = width, height
+		}
+	}
+	if _, err := repo.entities.Create(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// mimeType sniffs the type of the file at path, or guesses it from the
+// extension of name when the content doesn't tell.
+func mimeType(path, name string) string {
+	const unknown = "application/octet-stream"
+	detected := unknown
+	if file, err := os.Open(path); err == nil {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(file, head)
+		file.Close()
+		detected = http.DetectContentType(head[:n])
+	}
+	if detected == unknown || strings.HasPrefix(detected, "text/plain") {
+		if guessed := mime.TypeByExtension(filepath.Ext(name)); guessed != "" {
+			return guessed
+		}
+	}
+	return detected
+}
+
+func (repo attachmentRepository) AddFile(path string) (*Attachment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return repo.Add(filepath.Base(path), file)
+}
+
+func (repo attachmentRepository) ReadById(id int) (*Attachment, error) {
+	return repo.entities.ReadById(id)
+}
+
+func (repo attachmentRepository) ReadAll() ([]Attachment, error) {
+	collection, err := repo.entities.ReadAll()
+	return values(collection), err
+}
+
+func (repo attachmentRepository) ReadByTale(taleId int) ([]Attachment, error) {
+	collection, err := repo.entities.ReadMany(READ_BY_TALE_STATEMENT, taleId)
+	return values(collection), err
+}
+
+func (repo attachmentRepository) ReadByChapter(chapterId int) ([]Attachment, error) {
+	collection, err := repo.entities.ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+	return values(collection), err
+}
+
+func values(collection []*Attachment) []Attachment {
+	attachments := make([]Attachment, len(collection))
+	for i, attachment := range collection {
+		attachments[i] = *attachment
+	}
+	return attachments
+}
+
+func (repo attachmentRepository) LinkTale(attachmentId, taleId int) error {
+	_, err := repo.entities.ExecMany(LINK_TALE_STATEMENT, attachmentId, taleId)
+	return err
+}
+
+func (repo attachmentRepository) UnlinkTale(attachmentId, taleId int) error {
+	return repo.entities.Exec(UNLINK_TALE_STATEMENT, attachmentId, taleId)
+}
+
+func (repo attachmentRepository) LinkChapter(attachmentId, chapterId int) error {
+	_, err := repo.entities.ExecMany(LINK_CHAPTER_STATEMENT, attachmentId, chapterId)
+	return err
+}
+
+func (repo attachmentRepository) UnlinkChapter(attachmentId, chapterId int) error {
+	return repo.entities.Exec(UNLINK_CHAPTER_STATEMENT, attachmentId, chapterId)
+}
+
+func (repo attachmentRepository) Delete(id int) error {
+	return repo.entities.Delete(id)
+}
+
+func (repo attachmentRepository) Path(attachment *Attachment) string {
+	return repo.store.Path(attachment.Hash)
+}
+
+func (repo attachmentRepository) Copy(attachment *Attachment) (string, error) {
+	return repo.store.Copy(attachment.Hash, attachment.Name)
+}
+
+func (repo attachmentRepository) ThumbnailPath(attachment *Attachment) string {
+	return repo.store.ThumbnailPath(attachment.Hash)
+}
+
+func (repo attachmentRepository) ReadGarbage() (*Garbage, error) {
+	return repo.garbage(repo.entities)
+}
+
+// garbage finds the unlinked attachments and the contents held by no
+// other attachment, older than GARBAGE_GRACE_PERIOD.
+func (repo attachmentRepository) garbage(entities *data.Repository[Attachment]) (*Garbage, error) {
+	before := time.Now().Add(-GARBAGE_GRACE_PERIOD)
+	unlinked, err := entities.ReadMany(READ_UNLINKED_STATEMENT, utils.CleanTime(before))
+	if err != nil {
+		return nil, err
+	}
+	all, err := entities.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := repo.store.Hashes(before)
+	if err != nil {
+		return nil, err
+	}
+
+	held := map[string]bool{}
+	for _, attachment := range all {
+		held[attachment.Hash] = true
+	}
+	for _, attachment := range unlinked {
+		held[attachment.Hash] = false
+	}
+	garbage := &Garbage{Attachments: values(unlinked), Hashes: []string{}}
+	for hash, size := range stored {
+		if !held[hash] {
+			garbage.Hashes = append(garbage.Hashes, hash)
+			garbage.Size += size
+		}
+	}
+	slices.Sort(garbage.Hashes)
+	return garbage, nil
+}
+
+// CollectGarbage deletes the attachments before removing the files, a
+// file left when removing fails is removed by the next collection.
+func (repo attachmentRepository) CollectGarbage() (*Garbage, error) {
+	var garbage *Garbage
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		entities := repo.entities.WithTx(tx)
+		var err error
+		if garbage, err = repo.garbage(entities); err != nil {
+			return err
+		}
+		for _, attachment := range garbage.Attachments {
+			if err := entities.Delete(attachment.Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	errs := []error{}
+	for _, hash := range garbage.Hashes {
+		errs = append(errs, repo.store.Remove(hash))
+	}
+	return garbage, errors.Join(errs...)
+}
+
+func (repo attachmentRepository) Close() error {
+	return repo.entities.Close()
+}
@@ -0,0 +1,194 @@
+package attachments
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/data/datatest"
+	"talenest/backend/internal/utils"
+	"testing"
+	"time"
+)
+
+// newTestRepository opens an attachment repository on a new library
+// holding a tale besides the root one, with a chapter.
+func newTestRepository(t *testing.T) (Repository, *Store, *data.DatabaseConnector) {
+	t.Helper()
+	dbConn := datatest.Open(t)
+	_, err := dbConn.ExecuteQuery("INSERT INTO tales (name, created_at, updated_at) VALUES ('tale', '', '');", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dbConn.ExecuteQuery("INSERT INTO chapters (tale_id, content) VALUES (2, '');", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(t.TempDir())
+	repo, err := NewRepository(dbConn, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	return repo, store, dbConn
+}
+
+func add(t *testing.T, repo Repository, name, content string) *Attachment {
+	t.Helper()
+	attachment, err := repo.Add(name, strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attachment
+}
+
+// ageAttachments dates the attachments and their contents back by
+// GARBAGE_GRACE_PERIOD and more.
+func ageAttachments(t *testing.T, dbConn *data.DatabaseConnector, store *Store, attachments ...*Attachment) {
+	t.Helper()
+	old := utils.CleanTime(time.Now().Add(-2 * GARBAGE_GRACE_PERIOD))
+	for _, attachment := range attachments {
+		_, err := dbConn.ExecuteQuery("UPDATE attachments SET created_at = ? WHERE id = ?;", []any{old, attachment.Id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		age(t, store, attachment.Hash)
+	}
+}
+
+func ids(attachments []Attachment) []int {
+	ids := []int{}
+	for _, attachment := range attachments {
+		ids = append(ids, attachment.Id)
+	}
+	return ids
+}
+
+func TestReadLinkedAttachments(t *testing.T) {
+	repo, _, _ := newTestRepository(t)
+	notes := add(t, repo, "notes.txt", "the map of the valley")
+	letter := add(t, repo, "letter.txt", "dear Ayla")
+	sketch := add(t, repo, "sketch.txt", "a tower")
+
+	for _, attachment := range []*Attachment{letter, notes} {
+		if err := repo.LinkTale(attachment.Id, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// linking twice isn't an error
+	if err := repo.LinkTale(notes.Id, 2); err != nil {
+		t.Errorf("LinkTale() twice = %v", err)
+	}
+	if err := repo.LinkChapter(sketch.Id, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	linked, err := repo.ReadByTale(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{notes.Id, letter.Id}; !reflect.DeepEqual(ids(linked), want) {
+		t.Errorf("ReadByTale(2) = %v, want %v", ids(linked), want)
+	}
+	inChapter, err := repo.ReadByChapter(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{sketch.Id}; !reflect.DeepEqual(ids(inChapter), want) {
+		t.Errorf("ReadByChapter(1) = %v, want %v", ids(inChapter), want)
+	}
+
+	if err := repo.UnlinkTale(notes.Id, 2); err != nil {
+		t.Fatal(err)
+	}
+	linked, err = repo.ReadByTale(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{letter.Id}; !reflect.DeepEqual(ids(linked), want) {
+		t.Errorf("ReadByTale(2) after unlinking = %v, want %v", ids(linked), want)
+	}
+}
+
+func TestAddDeduplicates(t *testing.T) {
+	repo, _, _ := newTestRepository(t)
+	first := add(t, repo, "notes.txt", "the map of the valley")
+	if first.Size != int64(len("the map of the valley")) || !strings.HasPrefix(first.MimeType, "text/plain") {
+		t.Errorf("Add() = %+v", first)
+	}
+	again := add(t, repo, "copy.txt", "the map of the valley")
+	if again.Id != first.Id || again.Name != "notes.txt" {
+		t.Errorf("adding the content again returned %v, want %v", again, first)
+	}
+	all, err := repo.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 {
+		t.Errorf("the library holds %d attachments, want 1", len(all))
+	}
+	if _, err := repo.Add(" ", strings.NewReader("a tower")); !errors.Is(err, ErrInvalidAttachment) {
+		t.Errorf("Add() without name = %v, want %v", err, ErrInvalidAttachment)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	repo, store, dbConn := newTestRepository(t)
+	kept := add(t, repo, "notes.txt", "the map of the valley")
+	unlinked := add(t, repo, "letter.txt", "dear Ayla")
+	readded := add(t, repo, "sketch.txt", "a tower")
+	recent := add(t, repo, "draft.txt", "chapter one")
+	if err := repo.LinkTale(kept.Id, 2); err != nil {
+		t.Fatal(err)
+	}
+	ageAttachments(t, dbConn, store, kept, unlinked, readded)
+	orphan := put(t, store, "left by a failed add")
+	age(t, store, orphan)
+	// adding the content again keeps it until it's linked
+	add(t, repo, "sketch.txt", "a tower")
+
+	garbage, err := repo.ReadGarbage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHashes := []string{unlinked.Hash, orphan}
+	slices.Sort(wantHashes)
+	if want := []int{unlinked.Id}; !reflect.DeepEqual(ids(garbage.Attachments), want) {
+		t.Errorf("ReadGarbage() attachments = %v, want %v", ids(garbage.Attachments), want)
+	}
+	if !reflect.DeepEqual(garbage.Hashes, wantHashes) {
+		t.Errorf("ReadGarbage() hashes = %v, want %v", garbage.Hashes, wantHashes)
+	}
+	if want := int64(len("dear Ayla") + len("left by a failed add")); garbage.Size != want {
+		t.Errorf("ReadGarbage() size = %d, want %d", garbage.Size, want)
+	}
+
+	collected, err := repo.CollectGarbage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(collected, garbage) {
+		t.Errorf("CollectGarbage() = %+v, want %+v", collected, garbage)
+	}
+	all, err := repo.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{kept.Id, readded.Id, recent.Id}; !reflect.DeepEqual(ids(all), want) {
+		t.Errorf("the library keeps %v, want %v", ids(all), want)
+	}
+	for _, hash := range wantHashes {
+		if _, err := os.Stat(store.Path(hash)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("the content %s is still stored: %v", hash, err)
+		}
+	}
+	for _, attachment := range all {
+		if _, err := os.Stat(repo.Path(&attachment)); err != nil {
+			t.Errorf("the content of %v is gone: %v", attachment.Name, err)
+		}
+	}
+}
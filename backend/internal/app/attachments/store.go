@@ -0,0 +1,179 @@
+package attachments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	BLOBS_DIR      = "blobs"
+	THUMBNAILS_DIR = "thumbnails"
+	TEMP_DIR       = "tmp"
+	COPIES_DIR     = "copies"
+)
+
+var ErrInvalidHash = errors.New("invalid attachment hash")
+
+// Store keeps the content of the attachments in files named after their
+// SHA-256 hash, so that a content added twice is stored once. The files
+// are spread in directories named after the first two characters of the
+// hash.
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Put copies content in the store and returns its hash and size. The copy
+// is written aside and moved in place once complete.
+func (store *Store) Put(content io.Reader) (string, int64, error) {
+	tempDir := filepath.Join(store.dir, TEMP_DIR)
+	if err := os.MkdirAll(tempDir, 0700); err != nil {
+		return "", 0, err
+	}
+	temp, err := os.CreateTemp(tempDir, "put-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(temp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(temp, hasher), content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	path := store.Path(hash)
+	if _, err := os.Stat(path); err == nil {
+		// the content is written again as far as the garbage collection
+		// is concerned
+		now := time.Now()
+		return hash, size, os.Chtimes(path, now, now)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return hash, size, nil
+}
+
+// Path returns the file holding the content of hash.
+func (store *Store) Path(hash string) string {
+	return filepath.Join(store.dir, BLOBS_DIR, hash[:min(2, len(hash))], hash)
+}
+
+// ThumbnailPath returns the thumbnail of the image of hash.
+func (store *Store) ThumbnailPath(hash string) string {
+	return filepath.Join(store.dir, THUMBNAILS_DIR, hash+".png")
+}
+
+// Copy writes the content of hash to a file named name and returns its
+// path. The copies are handed to other applications, which may change them
+// without changing the stored content, and are kept in a directory per
+// hash so that they keep their name.
+func (store *Store) Copy(hash, name string) (string, error) {
+	if !validHash(hash) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidHash, hash)
+	}
+	name = filepath.Base(name)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		name = hash
+	}
+	path := filepath.Join(store.dir, COPIES_DIR, hash, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	source, err := os.Open(store.Path(hash))
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+	copied, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(copied, source); err != nil {
+		copied.Close()
+		return "", err
+	}
+	return path, copied.Close()
+}
+
+// Remove removes the content of hash with its thumbnail and copies, missing
+// files being ignored.
+func (store *Store) Remove(hash string) error {
+	if !validHash(hash) {
+		return fmt.Errorf("%w: %q", ErrInvalidHash, hash)
+	}
+	errs := []error{}
+	for _, path := range []string{store.Path(hash), store.ThumbnailPath(hash)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, os.RemoveAll(filepath.Join(store.dir, COPIES_DIR, hash)))
+	return errors.Join(errs...)
+}
+
+// Hashes returns the hashes of the contents and thumbnails stored before
+// a time, with the size of their files. A hash with a file written since
+// is left out.
+func (store *Store) Hashes(before time.Time) (map[string]int64, error) {
+	hashes := map[string]int64{}
+	recent := map[string]bool{}
+	for _, dir := range []string{BLOBS_DIR, THUMBNAILS_DIR} {
+		err := filepath.WalkDir(filepath.Join(store.dir, dir), func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			hash := strings.TrimSuffix(entry.Name(), ".png")
+			if !validHash(hash) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if !info.ModTime().Before(before) {
+				recent[hash] = true
+			}
+			hashes[hash] += info.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for hash := range recent {
+		delete(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	return !slices.ContainsFunc([]rune(hash), func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f')
+	})
+}
@@ -0,0 +1,107 @@
+package attachments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func put(t *testing.T, store *Store, content string) string {
+	t.Helper()
+	hash, size, err := store.Put(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(content)) {
+		t.Errorf("Put(%q) size = %d, want %d", content, size, len(content))
+	}
+	return hash
+}
+
+// age dates the files of hash back by GARBAGE_GRACE_PERIOD and more.
+func age(t *testing.T, store *Store, hash string) {
+	t.Helper()
+	old := time.Now().Add(-2 * GARBAGE_GRACE_PERIOD)
+	if err := os.Chtimes(store.Path(hash), old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorePut(t *testing.T) {
+	store := NewStore(t.TempDir())
+	hash := put(t, store, "the map of the valley")
+	if !validHash(hash) {
+		t.Fatalf("Put() hash = %q", hash)
+	}
+	stored, err := os.ReadFile(store.Path(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != "the map of the valley" {
+		t.Errorf("the store holds %q", stored)
+	}
+	if again := put(t, store, "the map of the valley"); again != hash {
+		t.Errorf("the same content has the hashes %q and %q", hash, again)
+	}
+	if other := put(t, store, "a tower"); other == hash {
+		t.Error("two contents have the same hash")
+	}
+	entries, err := os.ReadDir(filepath.Join(store.dir, TEMP_DIR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Put() left %d temporary files", len(entries))
+	}
+}
+
+func TestStoreHashes(t *testing.T) {
+	store := NewStore(t.TempDir())
+	before := time.Now().Add(-GARBAGE_GRACE_PERIOD)
+	hashes, err := store.Hashes(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 0 {
+		t.Errorf("an empty store has the hashes %v", hashes)
+	}
+
+	old := put(t, store, "the map of the valley")
+	age(t, store, old)
+	recent := put(t, store, "a tower")
+	hashes, err = store.Hashes(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{old: int64(len("the map of the valley"))}; !reflect.DeepEqual(hashes, want) {
+		t.Errorf("Hashes() = %v, want %v", hashes, want)
+	}
+
+	// putting the content again keeps it from the collection
+	put(t, store, "the map of the valley")
+	hashes, err = store.Hashes(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 0 {
+		t.Errorf("Hashes() = %v after adding the contents again, want none", hashes)
+	}
+
+	if err := store.Remove(recent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(store.Path(recent)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the removed content is still stored: %v", err)
+	}
+	// missing files are ignored
+	if err := store.Remove(recent); err != nil {
+		t.Errorf("Remove() twice = %v", err)
+	}
+	if err := store.Remove("../" + old); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("Remove() of an invalid hash = %v, want %v", err, ErrInvalidHash)
+	}
+}
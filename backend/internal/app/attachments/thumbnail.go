@@ -0,0 +1,118 @@
+package attachments
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// THUMBNAIL_SIZE is the largest side of the thumbnails, in pixels.
+const THUMBNAIL_SIZE = 256
+
+// MAX_IMAGE_PIXELS bounds the images decoded for a thumbnail, as decoding
+// allocates their whole size whatever the size of the file.
+const MAX_IMAGE_PIXELS = 50_000_000
+
+var ErrImageTooLarge = errors.New("image too large for a thumbnail")
+
+// imageSize returns the size of the image in the file at path, ok being
+// false when it isn't an image the app decodes (PNG, JPEG or GIF).
+func imageSize(path string) (width, height int, ok bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, false
+	}
+	return config.Width, config.Height, true
+}
+
+// writeThumbnail scales the image at path down to fit in THUMBNAIL_SIZE
+// and writes it as PNG at thumbnailPath. Images of more than
+// MAX_IMAGE_PIXELS aren't decoded.
+func writeThumbnail(path, thumbnailPath string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return err
+	}
+	if int64(config.Width)*int64(config.Height) > MAX_IMAGE_PIXELS {
+		return fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	source, _, err := image.Decode(file)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(thumbnailPath), 0700); err != nil {
+		return err
+	}
+	output, err := os.Create(thumbnailPath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(output, scaleDown(source, THUMBNAIL_SIZE)); err != nil {
+		output.Close()
+		os.Remove(thumbnailPath)
+		return err
+	}
+	return output.Close()
+}
+
+// scaleDown shrinks an image so that its largest side is at most size,
+// each pixel averaging the pixels of the source it covers. Smaller images
+// are only copied.
+func scaleDown(source image.Image, size int) *image.NRGBA {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	scaledWidth, scaledHeight := width, height
+	if width > size || height > size {
+		if width >= height {
+			scaledWidth, scaledHeight = size, max(1, height*size/width)
+		} else {
+			scaledWidth, scaledHeight = max(1, width*size/height), size
+		}
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, scaledWidth, scaledHeight))
+	for y := 0; y < scaledHeight; y++ {
+		top, bottom := y*height/scaledHeight, max((y+1)*height/scaledHeight, y*height/scaledHeight+1)
+		for x := 0; x < scaledWidth; x++ {
+			left, right := x*width/scaledWidth, max((x+1)*width/scaledWidth, x*width/scaledWidth+1)
+			var r, g, b, a, count uint64
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					pr, pg, pb, pa := source.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			// the sums are premultiplied by alpha
+			average := color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			}
+			scaled.Set(x, y, average)
+		}
+	}
+	return scaled
+}
@@ -7,6 +7,7 @@ type Chapter struct {
 	Content   string
 	sentiment float64
 	TaleId    int
+	Version   int
 }
 
 func (chapter *Chapter) String() string {
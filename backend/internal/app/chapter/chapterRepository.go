@@ -3,78 +3,58 @@ package chapter
 import (
 	"database/sql"
 	"errors"
-	"fmt"
 	"talenest/backend/internal/data"
 )
 
 const tableName = "chapters"
 const READ_BY_TALE_STATEMENT = "READ_BY_TALE"
 
+// Repository stores the chapters with their scenes: saving a chapter
+// splits its content on SCENE_BREAK, each part replacing the content of
+// the scene at its position.
 type Repository interface {
 	Create(chapter *Chapter) (int, error)
 	ReadById(id int) (*Chapter, error)
 	ReadByTale(tale int) (*Chapters, error)
 	ReadAll() (*Chapters, error)
-	Update(chapter Chapter) error
+	// Update fails with a *data.StaleError[Chapter] if the chapter changed
+	// since it was read.
+	Update(chapter *Chapter) error
 	Delete(id int) error
 	Close() error
 }
 
 type chapterRepository struct {
-	dbConn     *data.DatabaseConnector
-	statements map[string]*sql.Stmt
+	dbConn   *data.DatabaseConnector
+	entities *data.Repository[Chapter]
+	scenes   *data.Repository[Scene]
 }
 
-func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
-	repo := &chapterRepository{
-		dbConn: dbConn,
-	}
-	var err error
-
-	createStmt, err := dbConn.PrepareQuery(
-		data.CreateQuery(tableName, getColumnNames()))
-	if err != nil {
-		return nil, err
-	}
-	repo.statements = make(map[string]*sql.Stmt)
-	repo.statements[data.CREATE_STATEMENT] = createStmt
-
-	readByIdStmt, err := dbConn.PrepareQuery(
-		data.ReadByIdQuery(tableName, getColumnNames()))
-	if err != nil {
-		return nil, err
-	}
-	repo.statements[data.READ_STATEMENT] = readByIdStmt
+type chapterMapper struct{}
 
-	readByParentIdStmt, err := dbConn.PrepareQuery(readByTaleQuery())
+func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
+	entities, err := data.NewRepository[Chapter](dbConn, chapterMapper{})
 	if err != nil {
 		return nil, err
 	}
-	repo.statements[READ_BY_TALE_STATEMENT] = readByParentIdStmt
 
-	readAllStmt, err := dbConn.PrepareQuery(
-		data.ReadAllQuery(tableName, getColumnNames()))
+	err = entities.Prepare(READ_BY_TALE_STATEMENT,
+		data.ReadByColumnQuery(tableName, getColumnNames(), "tale_id"))
 	if err != nil {
+		entities.Close()
 		return nil, err
 	}
-	repo.statements[data.READ_ALL_STATEMENT] = readAllStmt
-
-	updateStmt, err := dbConn.PrepareQuery(
-		data.UpdateQuery(tableName, getColumnNames()))
+	scenes, err := newScenes(dbConn)
 	if err != nil {
+		entities.Close()
 		return nil, err
 	}
-	repo.statements[data.UPDATE_STATEMENT] = updateStmt
 
-	deleteStmt, err := dbConn.PrepareQuery(
-		data.DeleteQuery(tableName))
-	if err != nil {
-		return nil, err
-	}
-
-	repo.statements[data.DELETE_STATEMENT] = deleteStmt
-
-	return repo, nil
+	return &chapterRepository{
+		dbConn:   dbConn,
+		entities: entities,
+		scenes:   scenes,
+	}, nil
 }
 
 func getColumnNames() []string {
@@ -83,125 +63,167 @@ func getColumnNames() []string {
 		"content",
 		"sentiment",
 		"tale_id",
+		"version",
 	}
 }
 
-func (repo chapterRepository) Create(chapter *Chapter) (int, error) {
-	result, err := repo.statements[data.CREATE_STATEMENT].Exec(
-		nil,
+func (chapterMapper) TableName() string {
+	return tableName
+}
+
+func (chapterMapper) Columns() []string {
+	return getColumnNames()
+}
+
+func (chapterMapper) Values(chapter *Chapter) []any {
+	return []any{
+		chapter.Id,
 		chapter.Content,
 		chapter.sentiment,
 		chapter.TaleId,
-	)
-
-	if err != nil {
-		return 0, err
+		chapter.Version,
 	}
-	id, err := result.LastInsertId()
-	chapter.Id = int(id)
-	return chapter.Id, err
 }
 
-func (repo chapterRepository) ReadById(id int) (*Chapter, error) {
-	rows, err := repo.statements[data.READ_STATEMENT].Query(id)
-	defer rows.Close()
+func (chapterMapper) Scan(scanner data.Scanner) (*Chapter, error) {
+	chapter := Chapter{}
+	err := scanner.Scan(
+		&chapter.Id,
+		&chapter.Content,
+		&chapter.sentiment,
+		&chapter.TaleId,
+		&chapter.Version,
+	)
 	if err != nil {
-		return &Chapter{}, err
+		return nil, err
 	}
-	if rows.Next() {
-		chapter := Chapter{}
-		err := rows.Scan(
-			&chapter.Id,
-			&chapter.Content,
-			&chapter.sentiment,
-			&chapter.TaleId,
-		)
-
-		if err != nil {
-			return &Chapter{}, err
-		}
-		return &chapter, nil
+	return &chapter, nil
+}
+
+func (chapterMapper) GetId(chapter *Chapter) int {
+	return chapter.Id
+}
+
+func (chapterMapper) SetId(chapter *Chapter, id int) {
+	chapter.Id = id
+}
+
+func (chapterMapper) VersionColumn() string {
+	return "version"
+}
+
+func (chapterMapper) GetVersion(chapter *Chapter) int {
+	return chapter.Version
+}
+
+func (chapterMapper) SetVersion(chapter *Chapter, version int) {
+	chapter.Version = version
+}
+
+func newChapters(collection []*Chapter) *Chapters {
+	return &Chapters{
+		collection: collection,
 	}
-	return &Chapter{}, errors.New(fmt.Sprintf("Error on retrieving the row: %v", err))
-}
-
-func (repo chapterRepository) readChapters(rows *sql.Rows) (*Chapters, error) {
-	chapterCollection := &Chapters{}
-	for rows.Next() {
-		chapter := Chapter{}
-		err := rows.Scan(
-			&chapter.Id,
-			&chapter.Content,
-			&chapter.sentiment,
-			&chapter.TaleId,
-		)
-
-		if err != nil {
-			return &Chapters{}, err
-		}
+}
 
-		chapterCollection.Add(&chapter)
+func (repo chapterRepository) Create(chapter *Chapter) (int, error) {
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		if _, err := repo.entities.WithTx(tx).Create(chapter); err != nil {
+			return err
+		}
+		return syncScenes(repo.scenes.WithTx(tx), chapter)
+	})
+	if err != nil {
+		chapter.Id = 0
+		return 0, err
 	}
-	return chapterCollection, nil
+	return chapter.Id, nil
 }
 
-func readByTaleQuery() string {
-	builder := data.NewSelectQueryBuilder(tableName)
-	builder.SetColumns(data.ConvertToColumns(getColumnNames()))
-	parentIdColumn, _ := data.NewColumn("tale_id", "")
-	builder.SetWhere(tableName, *parentIdColumn, "=", data.NewTokenValue("?"), "")
-	return builder.Build()
+func (repo chapterRepository) ReadById(id int) (*Chapter, error) {
+	return repo.entities.ReadById(id)
 }
 
 func (repo chapterRepository) ReadByTale(taleId int) (*Chapters, error) {
-	rows, err := repo.statements[READ_BY_TALE_STATEMENT].Query(taleId)
-	defer rows.Close()
+	collection, err := repo.entities.ReadMany(READ_BY_TALE_STATEMENT, taleId)
 	if err != nil {
 		return &Chapters{}, err
 	}
-	return repo.readChapters(rows)
+	return newChapters(collection), nil
 }
 
 func (repo chapterRepository) ReadAll() (*Chapters, error) {
-	rows, err := repo.statements[data.READ_ALL_STATEMENT].Query()
-	defer rows.Close()
+	collection, err := repo.entities.ReadAll()
 	if err != nil {
 		return &Chapters{}, err
 	}
-	return repo.readChapters(rows)
+	return newChapters(collection), nil
 }
 
-func (repo chapterRepository) Update(chapter Chapter) error {
-	result, err := repo.statements[data.UPDATE_STATEMENT].Exec(
-		chapter.Id,
-		chapter.Content,
-		chapter.sentiment,
-		chapter.TaleId,
-		chapter.Id,
-	)
+func (repo chapterRepository) Update(chapter *Chapter) error {
+	version := chapter.Version
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		if err := repo.entities.WithTx(tx).Update(chapter); err != nil {
+			return err
+		}
+		return syncScenes(repo.scenes.WithTx(tx), chapter)
+	})
 	if err != nil {
+		chapter.Version = version
 		return err
 	}
-	if nRows, err := result.RowsAffected(); nRows != 1 || err != nil {
-		return errors.New("The rows affected are different than 1")
+	return nil
+}
+
+// syncScenes gives the scenes of a chapter the parts of its content, see
+// matchScenes. The scenes left without part are deleted, new scenes are
+// added for the extra parts.
+func syncScenes(scenes *data.Repository[Scene], chapter *Chapter) error {
+	stored, err := scenes.ReadMany(READ_BY_CHAPTER_STATEMENT, chapter.Id)
+	if err != nil {
+		return err
+	}
+	contents := make([]string, len(stored))
+	for i, scene := range stored {
+		contents[i] = scene.Content
+	}
+	parts := splitScenes(chapter.Content)
+	kept := make([]bool, len(stored))
+	for i, match := range matchScenes(contents, parts) {
+		if match < 0 {
+			scene := NewScene(chapter.Id, parts[i])
+			scene.Position = i
+			if _, err := scenes.Create(scene); err != nil {
+				return err
+			}
+			continue
+		}
+		kept[match] = true
+		scene := stored[match]
+		if scene.Content == parts[i] && scene.Position == i {
+			continue
+		}
+		scene.Content, scene.Position = parts[i], i
+		if err := scenes.Update(scene); err != nil {
+			return err
+		}
+	}
+	for i, scene := range stored {
+		if kept[i] {
+			continue
+		}
+		if err := scenes.Delete(scene.Id); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 func (repo chapterRepository) Delete(id int) error {
 	// permanent delete
-	_, err := repo.statements[data.DELETE_STATEMENT].Exec(id)
-	return err
+	return repo.entities.Delete(id)
 }
 
 func (repo chapterRepository) Close() error {
-	var errs error
-	for _, statement := range repo.statements {
-		if statement != nil {
-			if currentErr := statement.Close(); currentErr != nil {
-				errs = errors.Join(currentErr)
-			}
-		}
-	}
-	return errs
+	return errors.Join(repo.entities.Close(), repo.scenes.Close())
 }
@@ -0,0 +1,220 @@
+package chapter
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/text"
+)
+
+const scenesTableName = "scenes"
+
+// SCENE_BREAK separates the scenes in the content of a chapter.
+const SCENE_BREAK = "\n\n* * *\n\n"
+
+var ErrInvalidScene = errors.New("invalid scene")
+
+// Scene is a part of a chapter. PovId and LocationId are the codex
+// entities the scene is told by and takes place in, StatusId its progress.
+type Scene struct {
+	Id         int
+	ChapterId  int
+	Position   int
+	Title      string
+	Content    string
+	Synopsis   string
+	PovId      int
+	LocationId int
+	StatusId   int
+	// WordCount is counted from the content when the scene is read.
+	WordCount int
+	Version   int
+}
+
+type sceneMapper struct{}
+
+// NewScene returns a scene of content, to be added to a chapter.
+func NewScene(chapterId int, content string) *Scene {
+	return &Scene{
+		ChapterId: chapterId,
+		Content:   content,
+		WordCount: len(text.Tokens(content)),
+	}
+}
+
+// Validate checks that the content comes back unchanged when its chapter
+// is split into scenes: it can't hold a break, nor begin or end with the
+// part of a break that would make one with the breaks around the scene.
+func (scene *Scene) Validate() error {
+	if strings.Contains(scene.Content, SCENE_BREAK) {
+		return fmt.Errorf("%w: the content holds a scene break", ErrInvalidScene)
+	}
+	mark := strings.Trim(SCENE_BREAK, "\n")
+	if strings.HasPrefix(strings.TrimLeft(scene.Content, "\n"), mark+"\n\n") ||
+		strings.HasSuffix(strings.TrimRight(scene.Content, "\n"), "\n\n"+mark) {
+		return fmt.Errorf("%w: the content begins or ends with a scene break", ErrInvalidScene)
+	}
+	return nil
+}
+
+func (scene *Scene) String() string {
+	return fmt.Sprintf("Scene %d [%d.%d] %s", scene.Id, scene.ChapterId, scene.Position, scene.Title)
+}
+
+// joinScenes returns the content of a chapter made of scenes.
+func joinScenes(scenes []*Scene) string {
+	contents := make([]string, len(scenes))
+	for i, scene := range scenes {
+		contents[i] = scene.Content
+	}
+	return strings.Join(contents, SCENE_BREAK)
+}
+
+// splitScenes returns the contents of the scenes of a chapter.
+func splitScenes(content string) []string {
+	return strings.Split(content, SCENE_BREAK)
+}
+
+// matchScenes returns for each part the index of the stored content it
+// replaces, -1 for a new scene. The parts equal to a stored content are
+// matched first, keeping their order, then the parts left between two
+// matches take the contents left there by position.
+func matchScenes(stored, parts []string) []int {
+	// lengths[i][j] is the longest common sequence of stored[i:] and
+	// parts[j:]
+	lengths := make([][]int, len(stored)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(parts)+1)
+	}
+	for i := len(stored) - 1; i >= 0; i-- {
+		for j := len(parts) - 1; j >= 0; j-- {
+			if stored[i] == parts[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	matches := make([]int, len(parts))
+	// the contents and parts not matched since the last equal pair
+	gapStored, gapParts := []int{}, []int{}
+	closeGap := func() {
+		for k, j := range gapParts {
+			matches[j] = -1
+			if k < len(gapStored) {
+				matches[j] = gapStored[k]
+			}
+		}
+		gapStored, gapParts = gapStored[:0], gapParts[:0]
+	}
+	i, j := 0, 0
+	for i < len(stored) || j < len(parts) {
+		switch {
+		case i < len(stored) && j < len(parts) && stored[i] == parts[j]:
+			closeGap()
+			matches[j] = i
+			i, j = i+1, j+1
+		case j == len(parts) || i < len(stored) && lengths[i+1][j] >= lengths[i][j+1]:
+			gapStored = append(gapStored, i)
+			i++
+		default:
+			gapParts = append(gapParts, j)
+			j++
+		}
+	}
+	closeGap()
+	return matches
+}
+
+func getSceneColumnNames() []string {
+	return []string{
+		"id",
+		"chapter_id",
+		"position",
+		"title",
+		"content",
+		"synopsis",
+		"pov_id",
+		"location_id",
+		"status_id",
+		"version",
+	}
+}
+
+func (sceneMapper) TableName() string {
+	return scenesTableName
+}
+
+func (sceneMapper) Columns() []string {
+	return getSceneColumnNames()
+}
+
+func (sceneMapper) Values(scene *Scene) []any {
+	return []any{
+		scene.Id,
+		scene.ChapterId,
+		scene.Position,
+		scene.Title,
+		scene.Content,
+		scene.Synopsis,
+		nullableId(scene.PovId),
+		nullableId(scene.LocationId),
+		nullableId(scene.StatusId),
+		scene.Version,
+	}
+}
+
+func (sceneMapper) Scan(scanner data.Scanner) (*Scene, error) {
+	scene := Scene{}
+	var povId, locationId, statusId sql.NullInt64
+	err := scanner.Scan(
+		&scene.Id,
+		&scene.ChapterId,
+		&scene.Position,
+		&scene.Title,
+		&scene.Content,
+		&scene.Synopsis,
+		&povId,
+		&locationId,
+		&statusId,
+		&scene.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	scene.PovId = int(povId.Int64)
+	scene.LocationId = int(locationId.Int64)
+	scene.StatusId = int(statusId.Int64)
+	scene.WordCount = len(text.Tokens(scene.Content))
+	return &scene, nil
+}
+
+func (sceneMapper) GetId(scene *Scene) int {
+	return scene.Id
+}
+
+func (sceneMapper) SetId(scene *Scene, id int) {
+	scene.Id = id
+}
+
+func (sceneMapper) VersionColumn() string {
+	return "version"
+}
+
+func (sceneMapper) GetVersion(scene *Scene) int {
+	return scene.Version
+}
+
+func (sceneMapper) SetVersion(scene *Scene, version int) {
+	scene.Version = version
+}
+
+func nullableId(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
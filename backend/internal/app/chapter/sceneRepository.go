@@ -0,0 +1,257 @@
+package chapter
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"talenest/backend/internal/data"
+)
+
+const (
+	READ_BY_CHAPTER_STATEMENT = "READ_BY_CHAPTER"
+	SET_POSITION_STATEMENT    = "SET_POSITION"
+	MOVE_STATEMENT            = "MOVE"
+)
+
+// SceneRepository edits the scenes of the chapters. Every change rewrites
+// the content of the chapters it touches, so that a chapter read through
+// Repository holds its scenes joined by SCENE_BREAK.
+type SceneRepository interface {
+	// Create adds the scene at the end of its chapter.
+	Create(scene *Scene) (int, error)
+	ReadById(id int) (*Scene, error)
+	// ReadByChapter returns the scenes of a chapter in order.
+	ReadByChapter(chapterId int) ([]Scene, error)
+	// Update saves the scene but not its chapter and position, see Move
+	// and Reorder. It fails with a *data.StaleError[Scene] if the scene
+	// changed since it was read.
+	Update(scene *Scene) error
+	Delete(id int) error
+	// Reorder orders the scenes of a chapter as sceneIds, which must list
+	// all of them.
+	Reorder(chapterId int, sceneIds []int) error
+	// Move moves a scene to position in a chapter, which may be its own.
+	// The scene goes last when position is out of range.
+	Move(sceneId, chapterId, position int) error
+	Close() error
+}
+
+type sceneRepository struct {
+	dbConn   *data.DatabaseConnector
+	scenes   *data.Repository[Scene]
+	chapters *data.Repository[Chapter]
+}
+
+func NewSceneRepository(dbConn *data.DatabaseConnector) (SceneRepository, error) {
+	scenes, err := newScenes(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	chapters, err := data.NewRepository[Chapter](dbConn, chapterMapper{})
+	if err != nil {
+		scenes.Close()
+		return nil, err
+	}
+	return &sceneRepository{
+		dbConn:   dbConn,
+		scenes:   scenes,
+		chapters: chapters,
+	}, nil
+}
+
+// newScenes opens the scenes table with the statements used by both
+// repositories of the package.
+func newScenes(dbConn *data.DatabaseConnector) (*data.Repository[Scene], error) {
+	scenes, err := data.NewRepository[Scene](dbConn, sceneMapper{})
+	if err != nil {
+		return nil, err
+	}
+	queries := map[string]string{
+		READ_BY_CHAPTER_STATEMENT: data.ReadByColumnOrderedQuery(scenesTableName, getSceneColumnNames(),
+			"chapter_id", []string{"position", "id"}),
+		SET_POSITION_STATEMENT: data.UpdateColumnsQuery(scenesTableName, []string{"position"}),
+		MOVE_STATEMENT:         data.UpdateColumnsQuery(scenesTableName, []string{"chapter_id", "position"}),
+	}
+	for name, query := range queries {
+		if err := scenes.Prepare(name, query); err != nil {
+			scenes.Close()
+			return nil, err
+		}
+	}
+	return scenes, nil
+}
+
+func (repo sceneRepository) Create(scene *Scene) (int, error) {
+	if err := scene.Validate(); err != nil {
+		return 0, err
+	}
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		scenes := repo.scenes.WithTx(tx)
+		if _, err := repo.chapters.WithTx(tx).ReadById(scene.ChapterId); err != nil {
+			return err
+		}
+		siblings, err := scenes.ReadMany(READ_BY_CHAPTER_STATEMENT, scene.ChapterId)
+		if err != nil {
+			return err
+		}
+		scene.Position = len(siblings)
+		if _, err := scenes.Create(scene); err != nil {
+			return err
+		}
+		return repo.rewrite(tx, scene.ChapterId)
+	})
+	if err != nil {
+		scene.Id = 0
+		return 0, err
+	}
+	scene.WordCount = NewScene(scene.ChapterId, scene.Content).WordCount
+	return scene.Id, nil
+}
+
+func (repo sceneRepository) ReadById(id int) (*Scene, error) {
+	return repo.scenes.ReadById(id)
+}
+
+func (repo sceneRepository) ReadByChapter(chapterId int) ([]Scene, error) {
+	collection, err := repo.scenes.ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+	if err != nil {
+		return []Scene{}, err
+	}
+	scenes := make([]Scene, len(collection))
+	for i, scene := range collection {
+		scenes[i] = *scene
+	}
+	return scenes, nil
+}
+
+func (repo sceneRepository) Update(scene *Scene) error {
+	if err := scene.Validate(); err != nil {
+		return err
+	}
+	version := scene.Version
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		scenes := repo.scenes.WithTx(tx)
+		stored, err := scenes.ReadById(scene.Id)
+		if err != nil {
+			return err
+		}
+		scene.ChapterId, scene.Position = stored.ChapterId, stored.Position
+		if err := scenes.Update(scene); err != nil {
+			return err
+		}
+		return repo.rewrite(tx, scene.ChapterId)
+	})
+	if err != nil {
+		scene.Version = version
+		return err
+	}
+	scene.WordCount = NewScene(scene.ChapterId, scene.Content).WordCount
+	return nil
+}
+
+func (repo sceneRepository) Delete(id int) error {
+	return repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		scenes := repo.scenes.WithTx(tx)
+		stored, err := scenes.ReadById(id)
+		if err != nil {
+			return err
+		}
+		if err := scenes.Delete(id); err != nil {
+			return err
+		}
+		return repo.rewrite(tx, stored.ChapterId)
+	})
+}
+
+func (repo sceneRepository) Reorder(chapterId int, sceneIds []int) error {
+	return repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		scenes := repo.scenes.WithTx(tx)
+		stored, err := scenes.ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+		if err != nil {
+			return err
+		}
+		storedIds := make([]int, len(stored))
+		for i, scene := range stored {
+			storedIds[i] = scene.Id
+		}
+		sortedIds := slices.Clone(sceneIds)
+		slices.Sort(sortedIds)
+		slices.Sort(storedIds)
+		if !slices.Equal(sortedIds, storedIds) {
+			return fmt.Errorf("%w: the order doesn't list the scenes of chapter %d", ErrInvalidScene, chapterId)
+		}
+		for position, id := range sceneIds {
+			if err := scenes.Exec(SET_POSITION_STATEMENT, position, id); err != nil {
+				return err
+			}
+		}
+		return repo.rewrite(tx, chapterId)
+	})
+}
+
+func (repo sceneRepository) Move(sceneId, chapterId, position int) error {
+	return repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		scenes := repo.scenes.WithTx(tx)
+		scene, err := scenes.ReadById(sceneId)
+		if err != nil {
+			return err
+		}
+		if _, err := repo.chapters.WithTx(tx).ReadById(chapterId); err != nil {
+			return err
+		}
+		siblings, err := scenes.ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+		if err != nil {
+			return err
+		}
+		siblings = slices.DeleteFunc(siblings, func(sibling *Scene) bool {
+			return sibling.Id == sceneId
+		})
+		position = min(max(position, 0), len(siblings))
+		siblings = slices.Insert(siblings, position, scene)
+		for i, sibling := range siblings {
+			if err := scenes.Exec(MOVE_STATEMENT, chapterId, i, sibling.Id); err != nil {
+				return err
+			}
+		}
+		if scene.ChapterId != chapterId {
+			if err := repo.rewrite(tx, scene.ChapterId); err != nil {
+				return err
+			}
+		}
+		return repo.rewrite(tx, chapterId)
+	})
+}
+
+// rewrite numbers the scenes of a chapter from 0 and saves them as its
+// content.
+func (repo sceneRepository) rewrite(tx *sql.Tx, chapterId int) error {
+	scenes := repo.scenes.WithTx(tx)
+	ordered, err := scenes.ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+	if err != nil {
+		return err
+	}
+	for i, scene := range ordered {
+		if scene.Position == i {
+			continue
+		}
+		if err := scenes.Exec(SET_POSITION_STATEMENT, i, scene.Id); err != nil {
+			return err
+		}
+	}
+	chapters := repo.chapters.WithTx(tx)
+	chapter, err := chapters.ReadById(chapterId)
+	if err != nil {
+		return err
+	}
+	content := joinScenes(ordered)
+	if chapter.Content == content {
+		return nil
+	}
+	chapter.Content = content
+	return chapters.Update(chapter)
+}
+
+func (repo sceneRepository) Close() error {
+	return errors.Join(repo.scenes.Close(), repo.chapters.Close())
+}
@@ -0,0 +1,168 @@
+package chapter
+
+import (
+	"errors"
+	"reflect"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/data/datatest"
+	"testing"
+)
+
+func TestSceneValidate(t *testing.T) {
+	tests := []struct {
+		content string
+		valid   bool
+	}{
+		{"", true},
+		{"The rain fell.", true},
+		{"* * * is how the scenes are split", true},
+		{"a list\n\n* first\n* second", true},
+		{"before" + SCENE_BREAK + "after", false},
+		{"* * *\n\nafter", false},
+		{"\n* * *\n\nafter", false},
+		{"before\n\n* * *", false},
+		{"before\n\n* * *\n", false},
+		{"* * *\n\n", false},
+	}
+	for _, test := range tests {
+		err := NewScene(1, test.content).Validate()
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("Validate(%q) = %v, want valid %v", test.content, err, test.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidScene) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidScene", test.content, err)
+		}
+	}
+}
+
+func TestJoinScenesRoundTrip(t *testing.T) {
+	tests := [][]string{
+		{""},
+		{"one"},
+		{"one", "two", "three"},
+		{"", "", ""},
+		{"one\n", "\ntwo", "* * * three"},
+		{"one\n\n", "\n\ntwo"},
+	}
+	for _, contents := range tests {
+		scenes := make([]*Scene, len(contents))
+		for i, content := range contents {
+			scenes[i] = NewScene(1, content)
+			if err := scenes[i].Validate(); err != nil {
+				t.Fatalf("%q: %v", content, err)
+			}
+		}
+		joined := joinScenes(scenes)
+		if parts := splitScenes(joined); !reflect.DeepEqual(parts, contents) {
+			t.Errorf("splitScenes(joinScenes(%q)) = %q", contents, parts)
+		}
+		// an unchanged chapter keeps its scenes in place
+		matches := matchScenes(contents, splitScenes(joined))
+		for i, match := range matches {
+			if match != i {
+				t.Errorf("matchScenes of %q unchanged = %v", contents, matches)
+				break
+			}
+		}
+	}
+}
+
+func TestMatchScenes(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored []string
+		parts  []string
+		want   []int
+	}{
+		{"edited", []string{"a", "b", "c"}, []string{"a", "B", "c"}, []int{0, 1, 2}},
+		{"inserted", []string{"a", "c"}, []string{"a", "b", "c"}, []int{0, -1, 1}},
+		{"deleted", []string{"a", "b", "c"}, []string{"a", "c"}, []int{0, 2}},
+		{"appended", []string{"a"}, []string{"a", "b", "c"}, []int{0, -1, -1}},
+		{"swapped", []string{"a", "b"}, []string{"b", "a"}, []int{1, -1}},
+		{"edited and split", []string{"a", "b", "c"}, []string{"a", "b1", "b2", "c"}, []int{0, 1, -1, 2}},
+		{"all new", []string{"a", "b"}, []string{"x", "y", "z"}, []int{0, 1, -1}},
+		{"empty chapter", []string{""}, []string{"a"}, []int{0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := matchScenes(test.stored, test.parts); !reflect.DeepEqual(matches, test.want) {
+				t.Errorf("matchScenes(%q, %q) = %v, want %v", test.stored, test.parts, matches, test.want)
+			}
+		})
+	}
+}
+
+func TestSceneUpdateRestoresVersion(t *testing.T) {
+	dbConn := datatest.Open(t)
+	chapters, err := NewRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chapters.Close()
+	scenes, err := NewSceneRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scenes.Close()
+
+	// tale 1 is the root tale
+	chapter := &Chapter{TaleId: 1, Content: "one" + SCENE_BREAK + "two", Version: 1}
+	if _, err := chapters.Create(chapter); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := scenes.ReadByChapter(chapter.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("the chapter has the scenes %+v, want 2", stored)
+	}
+
+	first, stale := stored[0], stored[0]
+	first.Content = "first edit"
+	if err := scenes.Update(&first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Version != stale.Version+1 {
+		t.Errorf("the version is %d after the update, want %d", first.Version, stale.Version+1)
+	}
+
+	stale.Content = "second edit"
+	err = scenes.Update(&stale)
+	var staleErr *data.StaleError[Scene]
+	if !errors.As(err, &staleErr) || staleErr.Current.Content != "first edit" {
+		t.Fatalf("Update of an old version = %v, want a StaleError with the first edit", err)
+	}
+	if stale.Version != stored[0].Version {
+		t.Errorf("a failed update changed the version to %d", stale.Version)
+	}
+
+	stale.Version = staleErr.Current.Version
+	stale.Content = "two" + SCENE_BREAK + "three"
+	if err := scenes.Update(&stale); !errors.Is(err, ErrInvalidScene) {
+		t.Errorf("Update with a scene break = %v, want ErrInvalidScene", err)
+	}
+
+	// the scene is saved before its chapter is rewritten, a failure of
+	// the rewrite rolls it back
+	_, err = dbConn.ExecuteQuery(`CREATE TRIGGER fail_chapters BEFORE UPDATE ON chapters
+		BEGIN SELECT RAISE(ABORT, 'failed rewrite'); END;`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.Content = "third edit"
+	if err := scenes.Update(&stale); err == nil {
+		t.Fatal("Update succeeded without rewriting the chapter")
+	}
+	if stale.Version != staleErr.Current.Version {
+		t.Errorf("a failed update changed the version to %d, want %d", stale.Version, staleErr.Current.Version)
+	}
+
+	read, err := chapters.ReadById(chapter.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Content != "first edit"+SCENE_BREAK+"two" {
+		t.Errorf("the chapter holds %q after the updates", read.Content)
+	}
+}
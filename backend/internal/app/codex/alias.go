@@ -0,0 +1,59 @@
+package codex
+
+import "talenest/backend/internal/data"
+
+const aliasesTableName = "codex_aliases"
+
+// aliasRecord is an alias as stored, with its entity.
+type aliasRecord struct {
+	Id       int
+	EntityId int
+	Alias    string
+}
+
+type aliasMapper struct{}
+
+func getAliasColumnNames() []string {
+	return []string{
+		"id",
+		"entity_id",
+		"alias",
+	}
+}
+
+func (aliasMapper) TableName() string {
+	return aliasesTableName
+}
+
+func (aliasMapper) Columns() []string {
+	return getAliasColumnNames()
+}
+
+func (aliasMapper) Values(record *aliasRecord) []any {
+	return []any{
+		record.Id,
+		record.EntityId,
+		record.Alias,
+	}
+}
+
+func (aliasMapper) Scan(scanner data.Scanner) (*aliasRecord, error) {
+	record := aliasRecord{}
+	err := scanner.Scan(
+		&record.Id,
+		&record.EntityId,
+		&record.Alias,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (aliasMapper) GetId(record *aliasRecord) int {
+	return record.Id
+}
+
+func (aliasMapper) SetId(record *aliasRecord, id int) {
+	record.Id = id
+}
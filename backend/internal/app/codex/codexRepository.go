@@ -0,0 +1,477 @@
+package codex
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+const tableName = "codex_entities"
+const fieldsTableName = "codex_fields"
+
+const (
+	READ_BY_KIND_STATEMENT     = "READ_BY_KIND"
+	READ_BY_TALE_STATEMENT     = "READ_BY_TALE"
+	READ_BY_CHAPTER_STATEMENT  = "READ_BY_CHAPTER"
+	READ_BY_ENTITY_STATEMENT   = "READ_BY_ENTITY"
+	READ_BY_ENTITIES_STATEMENT = "READ_BY_ENTITIES"
+	DELETE_FIELDS_STATEMENT    = "DELETE_FIELDS"
+	DELETE_ALIASES_STATEMENT   = "DELETE_ALIASES"
+	LINK_TALE_STATEMENT        = "LINK_TALE"
+	UNLINK_TALE_STATEMENT      = "UNLINK_TALE"
+	LINK_CHAPTER_STATEMENT     = "LINK_CHAPTER"
+	UNLINK_CHAPTER_STATEMENT   = "UNLINK_CHAPTER"
+)
+
+type Repository interface {
+	// Create saves the entity with its fields and aliases.
+	Create(entity *Entity) (int, error)
+	ReadById(id int) (*Entity, error)
+	ReadAll() ([]Entity, error)
+	ReadByKind(kind Kind) ([]Entity, error)
+	// ReadByTale returns the entities linked to a tale.
+	ReadByTale(taleId int) ([]Entity, error)
+	// ReadByChapter returns the entities linked to a chapter.
+	ReadByChapter(chapterId int) ([]Entity, error)
+	// Update saves the entity and replaces its fields and aliases. It fails with a
+	// *data.StaleError[Entity] if the entity changed since it was read.
+	Update(entity *Entity) error
+	Delete(id int) error
+	// LinkTale links an entity to a tale, linking it twice isn't an error.
+	LinkTale(entityId, taleId int) error
+	UnlinkTale(entityId, taleId int) error
+	// LinkChapter links an entity to a chapter, linking it twice isn't an
+	// error.
+	LinkChapter(entityId, chapterId int) error
+	UnlinkChapter(entityId, chapterId int) error
+	Close() error
+}
+
+type codexRepository struct {
+	dbConn   *data.DatabaseConnector
+	entities *data.Repository[Entity]
+	fields   *data.Repository[fieldRecord]
+	aliases  *data.Repository[aliasRecord]
+}
+
+type entityMapper struct{}
+
+// fieldRecord is a field as stored, with its entity and position.
+type fieldRecord struct {
+	Id       int
+	EntityId int
+	Field    Field
+	Position int
+}
+
+type fieldMapper struct{}
+
+func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
+	entities, err := data.NewRepository[Entity](dbConn, entityMapper{})
+	if err != nil {
+		return nil, err
+	}
+	fields, err := data.NewRepository[fieldRecord](dbConn, fieldMapper{})
+	if err != nil {
+		entities.Close()
+		return nil, err
+	}
+	aliases, err := data.NewRepository[aliasRecord](dbConn, aliasMapper{})
+	if err != nil {
+		entities.Close()
+		fields.Close()
+		return nil, err
+	}
+	repo := &codexRepository{
+		dbConn:   dbConn,
+		entities: entities,
+		fields:   fields,
+		aliases:  aliases,
+	}
+
+	entityQueries := map[string]string{
+		READ_BY_KIND_STATEMENT:    data.ReadByColumnQuery(tableName, getColumnNames(), "kind"),
+		READ_BY_TALE_STATEMENT:    readByLinkQuery("tale_codex", "tale_id"),
+		READ_BY_CHAPTER_STATEMENT: readByLinkQuery("chapter_codex", "chapter_id"),
+		LINK_TALE_STATEMENT:       data.CreateIgnoreQuery("tale_codex", []string{"entity_id", "tale_id"}),
+		UNLINK_TALE_STATEMENT:     data.DeleteByColumnsQuery("tale_codex", []string{"entity_id", "tale_id"}),
+		LINK_CHAPTER_STATEMENT:    data.CreateIgnoreQuery("chapter_codex", []string{"entity_id", "chapter_id"}),
+		UNLINK_CHAPTER_STATEMENT:  data.DeleteByColumnsQuery("chapter_codex", []string{"entity_id", "chapter_id"}),
+	}
+	for name, query := range entityQueries {
+		if err := entities.Prepare(name, query); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+	fieldQueries := map[string]string{
+		READ_BY_ENTITY_STATEMENT: data.ReadByColumnOrderedQuery(fieldsTableName, getFieldColumnNames(),
+			"entity_id", []string{"position", "id"}),
+		READ_BY_ENTITIES_STATEMENT: data.ReadByColumnInQuery(fieldsTableName, getFieldColumnNames(),
+			"entity_id", []string{"position", "id"}),
+		DELETE_FIELDS_STATEMENT: data.DeleteByColumnsQuery(fieldsTableName, []string{"entity_id"}),
+	}
+	for name, query := range fieldQueries {
+		if err := fields.Prepare(name, query); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+	aliasQueries := map[string]string{
+		READ_BY_ENTITY_STATEMENT: data.ReadByColumnOrderedQuery(aliasesTableName, getAliasColumnNames(),
+			"entity_id", []string{"id"}),
+		READ_BY_ENTITIES_STATEMENT: data.ReadByColumnInQuery(aliasesTableName, getAliasColumnNames(),
+			"entity_id", []string{"id"}),
+		DELETE_ALIASES_STATEMENT: data.DeleteByColumnsQuery(aliasesTableName, []string{"entity_id"}),
+	}
+	for name, query := range aliasQueries {
+		if err := aliases.Prepare(name, query); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+// readByLinkQuery selects the entities linked through linkTable.
+func readByLinkQuery(linkTable, column string) string {
+	id, _ := data.NewColumn("id", "")
+	entityId, _ := data.NewColumn("entity_id", "")
+	linkColumn, _ := data.NewColumn(column, "")
+	columns := []data.Column{}
+	for _, name := range getColumnNames() {
+		column, _ := data.NewColumn(tableName+"."+name, "")
+		columns = append(columns, *column)
+	}
+	builder := data.NewSelectQueryBuilder(tableName)
+	builder.SetColumns(columns)
+	builder.SetJoin(tableName, *id, linkTable, *entityId, "")
+	builder.SetWhere(linkTable, *linkColumn, "=", data.NewTokenValue("?"), "")
+	query, _ := builder.Build()
+	return query
+}
+
+func getColumnNames() []string {
+	return []string{
+		"id",
+		"kind",
+		"name",
+		"description",
+		"created_at",
+		"updated_at",
+		"version",
+	}
+}
+
+func getFieldColumnNames() []string {
+	return []string{
+		"id",
+		"entity_id",
+		"name",
+		"value",
+		"position",
+	}
+}
+
+func (entityMapper) TableName() string {
+	return tableName
+}
+
+func (entityMapper) Columns() []string {
+	return getColumnNames()
+}
+
+func (entityMapper) Values(entity *Entity) []any {
+	return []any{
+		entity.Id,
+		string(entity.Kind),
+		entity.Name,
+		entity.Description,
+		utils.CleanTime(entity.created),
+		utils.CleanTime(entity.updated),
+		entity.Version,
+	}
+}
+
+func (entityMapper) Scan(scanner data.Scanner) (*Entity, error) {
+	entity := Entity{Fields: []Field{}, Aliases: []string{}}
+	var kind, createdString, updatedString string
+	err := scanner.Scan(
+		&entity.Id,
+		&kind,
+		&entity.Name,
+		&entity.Description,
+		&createdString,
+		&updatedString,
+		&entity.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entity.Kind = Kind(kind)
+	if err := entity.setCreated(createdString); err != nil {
+		return nil, err
+	}
+	if err := entity.setUpdated(updatedString); err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (entityMapper) GetId(entity *Entity) int {
+	return entity.Id
+}
+
+func (entityMapper) SetId(entity *Entity, id int) {
+	entity.Id = id
+}
+
+func (entityMapper) VersionColumn() string {
+	return "version"
+}
+
+func (entityMapper) GetVersion(entity *Entity) int {
+	return entity.Version
+}
+
+func (entityMapper) SetVersion(entity *Entity, version int) {
+	entity.Version = version
+}
+
+func (fieldMapper) TableName() string {
+	return fieldsTableName
+}
+
+func (fieldMapper) Columns() []string {
+	return getFieldColumnNames()
+}
+
+func (fieldMapper) Values(record *fieldRecord) []any {
+	return []any{
+		record.Id,
+		record.EntityId,
+		record.Field.Name,
+		record.Field.Value,
+		record.Position,
+	}
+}
+
+func (fieldMapper) Scan(scanner data.Scanner) (*fieldRecord, error) {
+	record := fieldRecord{}
+	err := scanner.Scan(
+		&record.Id,
+		&record.EntityId,
+		&record.Field.Name,
+		&record.Field.Value,
+		&record.Position,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (fieldMapper) GetId(record *fieldRecord) int {
+	return record.Id
+}
+
+func (fieldMapper) SetId(record *fieldRecord, id int) {
+	record.Id = id
+}
+
+func (repo codexRepository) Create(entity *Entity) (int, error) {
+	entity.Name = strings.TrimSpace(entity.Name)
+	if err := entity.Validate(); err != nil {
+		return 0, err
+	}
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		if _, err := repo.entities.WithTx(tx).Create(entity); err != nil {
+			return err
+		}
+		if err := repo.createFields(repo.fields.WithTx(tx), entity); err != nil {
+			return err
+		}
+		return repo.createAliases(repo.aliases.WithTx(tx), entity)
+	})
+	if err != nil {
+		entity.Id = 0
+		return 0, err
+	}
+	return entity.Id, nil
+}
+
+func (repo codexRepository) createFields(fields *data.Repository[fieldRecord], entity *Entity) error {
+	for i, field := range entity.Fields {
+		field.Name = strings.TrimSpace(field.Name)
+		record := &fieldRecord{EntityId: entity.Id, Field: field, Position: i}
+		if _, err := fields.Create(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo codexRepository) createAliases(aliases *data.Repository[aliasRecord], entity *Entity) error {
+	for _, alias := range entity.Aliases {
+		record := &aliasRecord{EntityId: entity.Id, Alias: strings.TrimSpace(alias)}
+		if _, err := aliases.Create(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo codexRepository) ReadById(id int) (*Entity, error) {
+	entity, err := repo.entities.ReadById(id)
+	if err != nil {
+		return nil, err
+	}
+	records, err := repo.fields.ReadMany(READ_BY_ENTITY_STATEMENT, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		entity.Fields = append(entity.Fields, record.Field)
+	}
+	aliases, err := repo.aliases.ReadMany(READ_BY_ENTITY_STATEMENT, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range aliases {
+		entity.Aliases = append(entity.Aliases, record.Alias)
+	}
+	return entity, nil
+}
+
+func (repo codexRepository) ReadAll() ([]Entity, error) {
+	collection, err := repo.entities.ReadAll()
+	if err != nil {
+		return []Entity{}, err
+	}
+	return repo.withDetails(collection)
+}
+
+func (repo codexRepository) ReadByKind(kind Kind) ([]Entity, error) {
+	collection, err := repo.entities.ReadMany(READ_BY_KIND_STATEMENT, string(kind))
+	if err != nil {
+		return []Entity{}, err
+	}
+	return repo.withDetails(collection)
+}
+
+func (repo codexRepository) ReadByTale(taleId int) ([]Entity, error) {
+	collection, err := repo.entities.ReadMany(READ_BY_TALE_STATEMENT, taleId)
+	if err != nil {
+		return []Entity{}, err
+	}
+	return repo.withDetails(collection)
+}
+
+func (repo codexRepository) ReadByChapter(chapterId int) ([]Entity, error) {
+	collection, err := repo.entities.ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+	if err != nil {
+		return []Entity{}, err
+	}
+	return repo.withDetails(collection)
+}
+
+// withDetails reads the fields and aliases of the entities, all at once.
+func (repo codexRepository) withDetails(collection []*Entity) ([]Entity, error) {
+	if len(collection) == 0 {
+		return []Entity{}, nil
+	}
+	ids := make([]int, len(collection))
+	for i, entity := range collection {
+		ids[i] = entity.Id
+	}
+	idsJson, err := json.Marshal(ids)
+	if err != nil {
+		return []Entity{}, err
+	}
+	records, err := repo.fields.ReadMany(READ_BY_ENTITIES_STATEMENT, string(idsJson))
+	if err != nil {
+		return []Entity{}, err
+	}
+	fields := map[int][]*fieldRecord{}
+	for _, record := range records {
+		fields[record.EntityId] = append(fields[record.EntityId], record)
+	}
+	aliasRecords, err := repo.aliases.ReadMany(READ_BY_ENTITIES_STATEMENT, string(idsJson))
+	if err != nil {
+		return []Entity{}, err
+	}
+	aliases := map[int][]string{}
+	for _, record := range aliasRecords {
+		aliases[record.EntityId] = append(aliases[record.EntityId], record.Alias)
+	}
+	entities := make([]Entity, 0, len(collection))
+	for _, entity := range collection {
+		for _, record := range fields[entity.Id] {
+			entity.Fields = append(entity.Fields, record.Field)
+		}
+		entity.Aliases = append(entity.Aliases, aliases[entity.Id]...)
+		entities = append(entities, *entity)
+	}
+	return entities, nil
+}
+
+func (repo codexRepository) Update(entity *Entity) error {
+	entity.Name = strings.TrimSpace(entity.Name)
+	if err := entity.Validate(); err != nil {
+		return err
+	}
+	updated, version := entity.updated, entity.Version
+	entity.updated = time.Now()
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		if err := repo.entities.WithTx(tx).Update(entity); err != nil {
+			return err
+		}
+		fields := repo.fields.WithTx(tx)
+		if _, err := fields.ExecMany(DELETE_FIELDS_STATEMENT, entity.Id); err != nil {
+			return err
+		}
+		if err := repo.createFields(fields, entity); err != nil {
+			return err
+		}
+		aliases := repo.aliases.WithTx(tx)
+		if _, err := aliases.ExecMany(DELETE_ALIASES_STATEMENT, entity.Id); err != nil {
+			return err
+		}
+		return repo.createAliases(aliases, entity)
+	})
+	if err != nil {
+		entity.updated, entity.Version = updated, version
+		return err
+	}
+	return nil
+}
+
+func (repo codexRepository) Delete(id int) error {
+	return repo.entities.Delete(id)
+}
+
+func (repo codexRepository) LinkTale(entityId, taleId int) error {
+	_, err := repo.entities.ExecMany(LINK_TALE_STATEMENT, entityId, taleId)
+	return err
+}
+
+func (repo codexRepository) UnlinkTale(entityId, taleId int) error {
+	return repo.entities.Exec(UNLINK_TALE_STATEMENT, entityId, taleId)
+}
+
+func (repo codexRepository) LinkChapter(entityId, chapterId int) error {
+	_, err := repo.entities.ExecMany(LINK_CHAPTER_STATEMENT, entityId, chapterId)
+	return err
+}
+
+func (repo codexRepository) UnlinkChapter(entityId, chapterId int) error {
+	return repo.entities.Exec(UNLINK_CHAPTER_STATEMENT, entityId, chapterId)
+}
+
+func (repo codexRepository) Close() error {
+	return errors.Join(repo.entities.Close(), repo.fields.Close(), repo.aliases.Close())
+}
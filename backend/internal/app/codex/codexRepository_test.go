@@ -0,0 +1,97 @@
+package codex
+
+import (
+	"reflect"
+	"talenest/backend/internal/data/datatest"
+	"testing"
+)
+
+func newTestRepository(t *testing.T) Repository {
+	t.Helper()
+	dbConn := datatest.Open(t)
+	_, err := dbConn.ExecuteQuery("INSERT INTO tales (name, created_at, updated_at) VALUES ('tale', '', '');", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dbConn.ExecuteQuery("INSERT INTO chapters (tale_id, content) VALUES (2, '');", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	return repo
+}
+
+func names(entities []Entity) []string {
+	names := []string{}
+	for _, entity := range entities {
+		names = append(names, entity.Name)
+	}
+	return names
+}
+
+func TestReadLinkedEntities(t *testing.T) {
+	repo := newTestRepository(t)
+	hero := Create(KIND_CHARACTER, "Ayla")
+	hero.Fields = []Field{{Name: "Age", Value: "17"}, {Name: "Eyes", Value: "green"}}
+	hero.Aliases = []string{"the Heir"}
+	city := Create(KIND_PLACE, "Varn")
+	city.Aliases = []string{"the Grey City", "Old Varn"}
+	sword := Create(KIND_ITEM, "Dawnblade")
+	for _, entity := range []*Entity{hero, city, sword} {
+		if _, err := repo.Create(entity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, entity := range []*Entity{hero, city} {
+		if err := repo.LinkTale(entity.Id, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// linking twice isn't an error
+	if err := repo.LinkTale(hero.Id, 2); err != nil {
+		t.Errorf("LinkTale() twice = %v", err)
+	}
+	if err := repo.LinkChapter(sword.Id, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	linked, err := repo.ReadByTale(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Ayla", "Varn"}; !reflect.DeepEqual(names(linked), want) {
+		t.Fatalf("ReadByTale(2) = %v, want %v", names(linked), want)
+	}
+	if !reflect.DeepEqual(linked[0].Fields, hero.Fields) || !reflect.DeepEqual(linked[0].Aliases, hero.Aliases) {
+		t.Errorf("the hero reads %v %v, want %v %v", linked[0].Fields, linked[0].Aliases, hero.Fields, hero.Aliases)
+	}
+	if !reflect.DeepEqual(linked[1].Aliases, city.Aliases) {
+		t.Errorf("the city reads the aliases %v, want %v", linked[1].Aliases, city.Aliases)
+	}
+
+	inChapter, err := repo.ReadByChapter(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Dawnblade"}; !reflect.DeepEqual(names(inChapter), want) {
+		t.Errorf("ReadByChapter(1) = %v, want %v", names(inChapter), want)
+	}
+
+	if err := repo.UnlinkTale(city.Id, 2); err != nil {
+		t.Fatal(err)
+	}
+	linked, err = repo.ReadByTale(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Ayla"}; !reflect.DeepEqual(names(linked), want) {
+		t.Errorf("ReadByTale(2) after unlinking = %v, want %v", names(linked), want)
+	}
+}
@@ -0,0 +1,136 @@
+package codex
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+// Kind is the type of a codex entity.
+type Kind string
+
+const (
+	KIND_CHARACTER Kind = "character"
+	KIND_PLACE     Kind = "place"
+	KIND_ITEM      Kind = "item"
+	KIND_FACTION   Kind = "faction"
+)
+
+var kinds = []Kind{KIND_CHARACTER, KIND_PLACE, KIND_ITEM, KIND_FACTION}
+
+// ErrInvalidEntity is returned for entities that can't be saved.
+var ErrInvalidEntity = errors.New("invalid codex entity")
+
+// Entity is a character, place, item or faction of the stories.
+type Entity struct {
+	Id          int
+	Kind        Kind
+	Name        string
+	Description string
+	// Fields are the custom fields of the entity, in the order they are
+	// shown.
+	Fields []Field
+	// Aliases are the other names the entity is mentioned by.
+	Aliases []string
+	Version int
+	created time.Time
+	updated time.Time
+}
+
+// Field is a custom field of an entity, e.g. "Age" for a character.
+type Field struct {
+	Name  string
+	Value string
+}
+
+func Create(kind Kind, name string) *Entity {
+	return &Entity{
+		Kind:    kind,
+		Name:    name,
+		Fields:  []Field{},
+		Aliases: []string{},
+		created: time.Now(),
+		updated: time.Now(),
+	}
+}
+
+// Kinds returns the kinds of entities.
+func Kinds() []Kind {
+	return slices.Clone(kinds)
+}
+
+func (entity *Entity) Validate() error {
+	errs := []error{}
+	if !slices.Contains(kinds, entity.Kind) {
+		errs = append(errs, fmt.Errorf("unknown kind %q", entity.Kind))
+	}
+	if strings.TrimSpace(entity.Name) == "" {
+		errs = append(errs, errors.New("the name is empty"))
+	}
+	names := map[string]bool{}
+	for i, field := range entity.Fields {
+		name := strings.ToLower(strings.TrimSpace(field.Name))
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("field %d has no name", i+1))
+		case names[name]:
+			errs = append(errs, fmt.Errorf("the field %q is used twice", field.Name))
+		}
+		names[name] = true
+	}
+	aliases := map[string]bool{}
+	for _, alias := range entity.Aliases {
+		key := strings.ToLower(strings.TrimSpace(alias))
+		switch {
+		case key == "":
+			errs = append(errs, errors.New("an alias is empty"))
+		case aliases[key]:
+			errs = append(errs, fmt.Errorf("the alias %q is used twice", alias))
+		}
+		aliases[key] = true
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidEntity, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Field returns the value of the field name, compared ignoring case.
+func (entity *Entity) Field(name string) (string, bool) {
+	for _, field := range entity.Fields {
+		if strings.EqualFold(field.Name, name) {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// Names returns the name of the entity followed by its aliases.
+func (entity *Entity) Names() []string {
+	return append([]string{entity.Name}, entity.Aliases...)
+}
+
+func (entity *Entity) setCreated(datetime string) error {
+	created, err := time.Parse(utils.DATETIME_FORMAT, datetime)
+	if err != nil {
+		return errors.New("Failed to parse time value")
+	}
+	entity.created = created
+	return nil
+}
+
+func (entity *Entity) setUpdated(datetime string) error {
+	updated, err := time.Parse(utils.DATETIME_FORMAT, datetime)
+	if err != nil {
+		return errors.New("Failed to parse time value")
+	}
+	entity.updated = updated
+	return nil
+}
+
+func (entity *Entity) String() string {
+	return fmt.Sprintf("%s %d: %s", entity.Kind, entity.Id, entity.Name)
+}
@@ -0,0 +1,209 @@
+package codex
+
+import (
+	"slices"
+	"strings"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/text"
+	"unicode"
+	"unicode/utf8"
+)
+
+const mentionsTableName = "codex_mentions"
+
+var possessives = []string{"'s", "’s"}
+
+// Mention is a name or an alias of an entity found in a chapter. Start and
+// End are byte offsets in the content of the chapter.
+type Mention struct {
+	Id        int
+	EntityId  int
+	ChapterId int
+	Start     int
+	End       int
+	Text      string
+}
+
+// Appearance counts the mentions of an entity in a chapter.
+type Appearance struct {
+	EntityId  int
+	ChapterId int
+	Count     int
+}
+
+type mentionMapper struct{}
+
+// pattern is a name of an entity split into words.
+type pattern struct {
+	entityId int
+	words    []string
+}
+
+// matcher finds the names and aliases of the entities in a text.
+type matcher struct {
+	// patterns are indexed by their first word, the longest first
+	patterns map[string][]pattern
+}
+
+func newMatcher(entities []Entity) *matcher {
+	matcher := &matcher{patterns: map[string][]pattern{}}
+	for _, entity := range entities {
+		for _, name := range entity.Names() {
+			tokens := text.Tokens(name)
+			if len(tokens) == 0 {
+				continue
+			}
+			words := make([]string, len(tokens))
+			for i, token := range tokens {
+				words[i] = token.Word
+			}
+			key := patternKey(words[0])
+			matcher.patterns[key] = append(matcher.patterns[key], pattern{entityId: entity.Id, words: words})
+		}
+	}
+	for _, patterns := range matcher.patterns {
+		slices.SortStableFunc(patterns, func(a, b pattern) int {
+			return len(b.words) - len(a.words)
+		})
+	}
+	return matcher
+}
+
+func patternKey(word string) string {
+	for _, suffix := range possessives {
+		word = strings.TrimSuffix(word, suffix)
+	}
+	return strings.ToLower(word)
+}
+
+// find returns the mentions in the content of a chapter. Where names
+// overlap the longest one wins, e.g. "Mary Jane" over "Mary", and a name
+// shared by several entities is a mention of each of them.
+func (matcher *matcher) find(chapterId int, content string) []Mention {
+	mentions := []Mention{}
+	tokens := text.Tokens(content)
+	for i := 0; i < len(tokens); {
+		matched := 0
+		found := map[int]bool{}
+		for _, pattern := range matcher.patterns[patternKey(tokens[i].Word)] {
+			if matched > len(pattern.words) {
+				break
+			}
+			end, ok := pattern.match(content, tokens[i:])
+			if !ok || found[pattern.entityId] {
+				continue
+			}
+			matched = len(pattern.words)
+			found[pattern.entityId] = true
+			mentions = append(mentions, Mention{
+				EntityId:  pattern.entityId,
+				ChapterId: chapterId,
+				Start:     tokens[i].Start,
+				End:       end,
+				Text:      content[tokens[i].Start:end],
+			})
+		}
+		i += max(matched, 1)
+	}
+	return mentions
+}
+
+// match tells whether the pattern starts the tokens, the words being
+// separated by spaces only, and where the mention ends.
+func (pattern pattern) match(content string, tokens []text.Token) (int, bool) {
+	if len(tokens) < len(pattern.words) {
+		return 0, false
+	}
+	end := 0
+	for i, word := range pattern.words {
+		token := tokens[i]
+		if i > 0 && strings.TrimSpace(content[tokens[i-1].End:token.Start]) != "" {
+			return 0, false
+		}
+		length, ok := matchWord(word, token.Word, i == len(pattern.words)-1)
+		if !ok {
+			return 0, false
+		}
+		end = token.Start + length
+	}
+	return end, true
+}
+
+// matchWord compares a word of a name to a word of the text ignoring case,
+// except that a capitalized name only matches capitalized words so that
+// "Hope" isn't found in "we hope". The possessive ending of the last word
+// is left out of the mention.
+func matchWord(nameWord, word string, last bool) (int, bool) {
+	if last {
+		for _, suffix := range possessives {
+			if trimmed, ok := strings.CutSuffix(word, suffix); ok {
+				if _, ok := matchWord(nameWord, trimmed, false); ok {
+					return len(trimmed), true
+				}
+			}
+		}
+	}
+	if !strings.EqualFold(nameWord, word) {
+		return 0, false
+	}
+	first, _ := utf8.DecodeRuneInString(nameWord)
+	initial, _ := utf8.DecodeRuneInString(word)
+	if unicode.IsUpper(first) && !unicode.IsUpper(initial) {
+		return 0, false
+	}
+	return len(word), true
+}
+
+func getMentionColumnNames() []string {
+	return []string{
+		"id",
+		"entity_id",
+		"chapter_id",
+		"start_pos",
+		"end_pos",
+		"text",
+	}
+}
+
+func (mentionMapper) TableName() string {
+	return mentionsTableName
+}
+
+func (mentionMapper) Columns() []string {
+	return getMentionColumnNames()
+}
+
+func (mentionMapper) Values(mention *Mention) []any {
+	return []any{
+		mention.Id,
+		mention.EntityId,
+		mention.ChapterId,
+		mention.Start,
+		mention.End,
+		mention.Text,
+	}
+}
+
+func (mentionMapper) Scan(scanner data.Scanner) (*Mention, error) {
+	mention := Mention{}
+	err := scanner.Scan(
+		&mention.Id,
+		&mention.EntityId,
+		&mention.ChapterId,
+		&mention.Start,
+		&mention.End,
+		&mention.Text,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &mention, nil
+}
+
+func (mentionMapper) GetId(mention *Mention) int {
+	return mention.Id
+}
+
+func (mentionMapper) SetId(mention *Mention, id int) {
+	mention.Id = id
+}
@@ -0,0 +1,203 @@
+package codex
+
+import (
+	"database/sql"
+	"fmt"
+	"talenest/backend/internal/app/chapter"
+	"talenest/backend/internal/data"
+)
+
+const (
+	DELETE_MENTIONS_STATEMENT     = "DELETE_MENTIONS"
+	DELETE_ALL_MENTIONS_STATEMENT = "DELETE_ALL_MENTIONS"
+)
+
+const (
+	DELETE_ALL_MENTIONS_QUERY    = "DELETE FROM codex_mentions;"
+	APPEARANCES_BY_ENTITY_QUERY  = "SELECT entity_id, chapter_id, count(*) FROM codex_mentions WHERE entity_id = ? GROUP BY chapter_id ORDER BY chapter_id;"
+	APPEARANCES_BY_CHAPTER_QUERY = "SELECT entity_id, chapter_id, count(*) FROM codex_mentions WHERE chapter_id = ? GROUP BY entity_id ORDER BY count(*) DESC, entity_id;"
+)
+
+// MentionIndex keeps the mentions of the codex entities in the chapters.
+// A chapter is scanned again when it's saved through Chapters, the whole
+// library when the names of the entities change.
+type MentionIndex struct {
+	dbConn   *data.DatabaseConnector
+	entities Repository
+	mentions *data.Repository[Mention]
+}
+
+func NewMentionIndex(dbConn *data.DatabaseConnector, entities Repository) (*MentionIndex, error) {
+	mentions, err := data.NewRepository[Mention](dbConn, mentionMapper{})
+	if err != nil {
+		return nil, err
+	}
+	queries := map[string]string{
+		READ_BY_CHAPTER_STATEMENT: data.ReadByColumnOrderedQuery(mentionsTableName, getMentionColumnNames(),
+			"chapter_id", []string{"start_pos", "entity_id"}),
+		READ_BY_ENTITY_STATEMENT: data.ReadByColumnOrderedQuery(mentionsTableName, getMentionColumnNames(),
+			"entity_id", []string{"chapter_id", "start_pos"}),
+		DELETE_MENTIONS_STATEMENT:     data.DeleteByColumnsQuery(mentionsTableName, []string{"chapter_id"}),
+		DELETE_ALL_MENTIONS_STATEMENT: DELETE_ALL_MENTIONS_QUERY,
+	}
+	for name, query := range queries {
+		if err := mentions.Prepare(name, query); err != nil {
+			mentions.Close()
+			return nil, err
+		}
+	}
+	return &MentionIndex{
+		dbConn:   dbConn,
+		entities: entities,
+		mentions: mentions,
+	}, nil
+}
+
+// Scan replaces the mentions of a chapter with the ones found in its
+// content.
+func (index *MentionIndex) Scan(chapter *chapter.Chapter) ([]Mention, error) {
+	entities, err := index.entities.ReadAll()
+	if err != nil {
+		return []Mention{}, err
+	}
+	found := newMatcher(entities).find(chapter.Id, chapter.Content)
+	err = index.dbConn.Transaction(func(tx *sql.Tx) error {
+		mentions := index.mentions.WithTx(tx)
+		if _, err := mentions.ExecMany(DELETE_MENTIONS_STATEMENT, chapter.Id); err != nil {
+			return err
+		}
+		return createMentions(mentions, found)
+	})
+	if err != nil {
+		return []Mention{}, err
+	}
+	return found, nil
+}
+
+// Rebuild scans every chapter again, e.g. after an entity was renamed or
+// given an alias.
+func (index *MentionIndex) Rebuild(chapters chapter.Repository) error {
+	entities, err := index.entities.ReadAll()
+	if err != nil {
+		return err
+	}
+	all, err := chapters.ReadAll()
+	if err != nil {
+		return err
+	}
+	matcher := newMatcher(entities)
+	return index.dbConn.Transaction(func(tx *sql.Tx) error {
+		mentions := index.mentions.WithTx(tx)
+		if _, err := mentions.ExecMany(DELETE_ALL_MENTIONS_STATEMENT); err != nil {
+			return err
+		}
+		for chapter := range all.ChaptersStream() {
+			if err := createMentions(mentions, matcher.find(chapter.Id, chapter.Content)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func createMentions(mentions *data.Repository[Mention], found []Mention) error {
+	for i := range found {
+		if _, err := mentions.Create(&found[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadByChapter returns the mentions of a chapter in the order of the
+// text.
+func (index *MentionIndex) ReadByChapter(chapterId int) ([]Mention, error) {
+	return index.read(READ_BY_CHAPTER_STATEMENT, chapterId)
+}
+
+// ReadByEntity returns the mentions of an entity by chapter.
+func (index *MentionIndex) ReadByEntity(entityId int) ([]Mention, error) {
+	return index.read(READ_BY_ENTITY_STATEMENT, entityId)
+}
+
+func (index *MentionIndex) read(statement string, id int) ([]Mention, error) {
+	collection, err := index.mentions.ReadMany(statement, id)
+	if err != nil {
+		return []Mention{}, err
+	}
+	mentions := make([]Mention, len(collection))
+	for i, mention := range collection {
+		mentions[i] = *mention
+	}
+	return mentions, nil
+}
+
+// ChaptersMentioning returns the chapters mentioning an entity.
+func (index *MentionIndex) ChaptersMentioning(entityId int) ([]Appearance, error) {
+	return index.appearances(APPEARANCES_BY_ENTITY_QUERY, entityId)
+}
+
+// EntitiesIn returns the entities mentioned in a chapter, the most
+// mentioned first.
+func (index *MentionIndex) EntitiesIn(chapterId int) ([]Appearance, error) {
+	return index.appearances(APPEARANCES_BY_CHAPTER_QUERY, chapterId)
+}
+
+func (index *MentionIndex) appearances(query string, id int) ([]Appearance, error) {
+	rows, err := index.dbConn.Query(query, []any{id})
+	if err != nil {
+		return []Appearance{}, err
+	}
+	defer rows.Close()
+	appearances := []Appearance{}
+	for rows.Next() {
+		appearance := Appearance{}
+		if err := rows.Scan(&appearance.EntityId, &appearance.ChapterId, &appearance.Count); err != nil {
+			return []Appearance{}, err
+		}
+		appearances = append(appearances, appearance)
+	}
+	return appearances, rows.Err()
+}
+
+// Chapters wraps a chapter repository so that the chapters it saves are
+// scanned for mentions.
+func (index *MentionIndex) Chapters(chapters chapter.Repository) chapter.Repository {
+	return &scanningChapters{
+		Repository: chapters,
+		index:      index,
+	}
+}
+
+func (index *MentionIndex) Close() error {
+	return index.mentions.Close()
+}
+
+type scanningChapters struct {
+	chapter.Repository
+	index *MentionIndex
+}
+
+func (chapters *scanningChapters) Create(chapter *chapter.Chapter) (int, error) {
+	id, err := chapters.Repository.Create(chapter)
+	if err != nil {
+		return id, err
+	}
+	return id, chapters.scan(chapter)
+}
+
+func (chapters *scanningChapters) Update(chapter *chapter.Chapter) error {
+	if err := chapters.Repository.Update(chapter); err != nil {
+		return err
+	}
+	return chapters.scan(chapter)
+}
+
+// scan reports the chapter as saved even when the scan fails, the
+// mentions are found again on the next save or rebuild.
+func (chapters *scanningChapters) scan(chapter *chapter.Chapter) error {
+	if _, err := chapters.index.Scan(chapter); err != nil {
+		return fmt.Errorf("chapter %d saved but not scanned for mentions: %w", chapter.Id, err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package notes
+
+import (
+	"slices"
+	"unicode"
+)
+
+// MAX_EDIT_DISTANCE bounds the differences looked for between two
+// versions of a chapter, in words and punctuation marks. Past it the
+// changed part is taken as replaced at once.
+const MAX_EDIT_DISTANCE = 1000
+
+// Range is a range of characters (runes) of a text, End excluded.
+type Range struct {
+	Start int
+	End   int
+}
+
+// edit replaces the characters [start, end) of the old text with length
+// characters.
+type edit struct {
+	start  int
+	end    int
+	length int
+}
+
+// diff returns the edits turning old into new, in the order of the text.
+func diff(old, new []rune) []edit {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix &&
+		old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	oldMiddle, newMiddle := old[prefix:len(old)-suffix], new[prefix:len(new)-suffix]
+	if len(oldMiddle) == 0 && len(newMiddle) == 0 {
+		return []edit{}
+	}
+
+	oldUnits, newUnits := units(oldMiddle), units(newMiddle)
+	edits, ok := diffUnits(oldUnits, newUnits, MAX_EDIT_DISTANCE)
+	if !ok {
+		return []edit{{start: prefix, end: prefix + len(oldMiddle), length: len(newMiddle)}}
+	}
+	oldOffsets, newOffsets := offsets(oldUnits), offsets(newUnits)
+	shift := 0
+	for i, unitEdit := range edits {
+		newStart := unitEdit.start + shift
+		edits[i] = edit{
+			start:  prefix + oldOffsets[unitEdit.start],
+			end:    prefix + oldOffsets[unitEdit.end],
+			length: newOffsets[newStart+unitEdit.length] - newOffsets[newStart],
+		}
+		shift += unitEdit.length - (unitEdit.end - unitEdit.start)
+	}
+	return edits
+}
+
+// units splits a text in words and single other characters, which are
+// compared by the diff rather than every character.
+func units(text []rune) []string {
+	units := []string{}
+	for start := 0; start < len(text); {
+		end := start + 1
+		if isWordRune(text[start]) {
+			for end < len(text) && isWordRune(text[end]) {
+				end++
+			}
+		}
+		units = append(units, string(text[start:end]))
+		start = end
+	}
+	return units
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// offsets returns the position in characters of each unit, followed by the
+// length of the text.
+func offsets(units []string) []int {
+	offsets := make([]int, len(units)+1)
+	for i, unit := range units {
+		offsets[i+1] = offsets[i] + len([]rune(unit))
+	}
+	return offsets
+}
+
+// diffUnits finds the shortest edits turning a into b with the Myers
+// algorithm. The edits are in units. It fails when more than maxDistance
+// units differ.
+func diffUnits(a, b []string, maxDistance int) ([]edit, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v, for the diagonals -d-1 to d+1, before step d
+	trace := [][]int{}
+	for d := 0; d <= min(n+m, maxDistance); d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack walks the trace of diffUnits back from the end of both texts
+// and gathers the insertions and deletions into edits.
+func backtrack(trace [][]int, n, m int) []edit {
+	// deleted[i] is true for the units of a deleted, inserted[i] counts the
+	// units of b inserted before the unit i of a
+	deleted := make([]bool, n+1)
+	inserted := make([]int, n+1)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int {
+			return v[k+d+1]
+		}
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+		}
+		if x == prevX {
+			inserted[x]++
+		} else {
+			deleted[prevX] = true
+		}
+		x, y = prevX, prevY
+	}
+
+	edits := []edit{}
+	for i := 0; i <= n; i++ {
+		if !deleted[i] && inserted[i] == 0 {
+			continue
+		}
+		current := edit{start: i, end: i}
+		for {
+			current.length += inserted[current.end]
+			if current.end == n || !deleted[current.end] {
+				break
+			}
+			current.end++
+		}
+		edits = append(edits, current)
+		// the unit at the end is kept, its insertions are counted
+		i = current.end
+	}
+	return edits
+}
+
+// rebase moves a range through the edits. Text inserted at the bounds of
+// the range is left out of it, text replaced at its bounds is taken in. A
+// range whose text was deleted shrinks to where it was.
+func (r Range) rebase(edits []edit) Range {
+	start := position(r.Start, edits, false)
+	end := position(r.End, edits, true)
+	return Range{Start: start, End: max(start, end)}
+}
+
+func position(p int, edits []edit, isEnd bool) int {
+	shift := 0
+	for _, edit := range edits {
+		if p < edit.start || (isEnd && p == edit.start) {
+			break
+		}
+		if p >= edit.end {
+			shift += edit.length - (edit.end - edit.start)
+			continue
+		}
+		if isEnd {
+			return edit.start + shift + edit.length
+		}
+		return edit.start + shift
+	}
+	return p + shift
+}
@@ -0,0 +1,121 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+)
+
+// apply rebuilds the new text from the old one and the edits, taking the
+// inserted characters from new.
+func apply(old, new []rune, edits []edit) string {
+	result := []rune{}
+	at, shift := 0, 0
+	for _, edit := range edits {
+		result = append(result, old[at:edit.start]...)
+		result = append(result, new[edit.start+shift:edit.start+shift+edit.length]...)
+		shift += edit.length - (edit.end - edit.start)
+		at = edit.end
+	}
+	return string(append(result, old[at:]...))
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		old   string
+		new   string
+		edits []edit
+	}{
+		{"same", "a tale", "a tale", []edit{}},
+		{"empty to text", "", "once", []edit{{start: 0, end: 0, length: 4}}},
+		{"text to empty", "once", "", []edit{{start: 0, end: 4, length: 0}}},
+		{"word inserted", "the fox", "the red fox", []edit{{start: 4, end: 4, length: 4}}},
+		{"word deleted", "the red fox", "the fox", []edit{{start: 4, end: 8, length: 0}}},
+		{"word replaced", "the red fox", "the grey fox", []edit{{start: 4, end: 7, length: 4}}},
+		{"two edits", "one two three four", "one 2 three 4", []edit{
+			{start: 4, end: 7, length: 1},
+			{start: 14, end: 18, length: 1},
+		}},
+		{"multibyte", "là où il va", "là où elle va", []edit{{start: 6, end: 8, length: 4}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old, new := []rune(test.old), []rune(test.new)
+			edits := diff(old, new)
+			if got := apply(old, new, edits); got != test.new {
+				t.Fatalf("applying %v gives %q, want %q", edits, got, test.new)
+			}
+			if len(edits) != len(test.edits) {
+				t.Fatalf("diff = %v, want %v", edits, test.edits)
+			}
+			for i := range edits {
+				if edits[i] != test.edits[i] {
+					t.Fatalf("diff = %v, want %v", edits, test.edits)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffApplies(t *testing.T) {
+	tests := []struct {
+		old string
+		new string
+	}{
+		{"The rain fell. The wind rose.", "The wind rose. The rain fell."},
+		{"a b c d e f", "f e d c b a"},
+		{"Chapter one.\n\nShe left.", "Chapter one, revised.\n\nShe left at dawn, alone."},
+		{"abc", "xyz"},
+		{"!!??", "?!?!"},
+	}
+	for _, test := range tests {
+		old, new := []rune(test.old), []rune(test.new)
+		if got := apply(old, new, diff(old, new)); got != test.new {
+			t.Errorf("diff(%q, %q) applies as %q", test.old, test.new, got)
+		}
+	}
+}
+
+func TestDiffUnitsDistance(t *testing.T) {
+	a := strings.Fields("a b c d")
+	b := strings.Fields("w x y z")
+	if _, ok := diffUnits(a, b, 3); ok {
+		t.Error("diffUnits found edits past the maximum distance")
+	}
+	if _, ok := diffUnits(a, b, 8); !ok {
+		t.Error("diffUnits found no edits within the maximum distance")
+	}
+}
+
+func TestRebase(t *testing.T) {
+	tests := []struct {
+		name  string
+		old   string
+		quote string
+		new   string
+		want  string
+	}{
+		{"edit before", "the quick fox", "fox", "a very quick fox", "fox"},
+		{"edit after", "the quick fox ran", "quick", "the quick fox walked", "quick"},
+		{"insertion at start", "the fox ran", "fox", "the red fox ran", "fox"},
+		{"insertion at end", "the fox ran", "fox", "the foxes ran", "fox"},
+		{"replaced inside", "the quick brown fox", "quick brown fox", "the quick red fox", "quick red fox"},
+		{"replaced at bounds", "the quick fox", "quick", "the slow fox", "slow"},
+		{"deleted", "the quick fox", "quick ", "the fox", ""},
+		{"moved text", "one two three", "three", "zero one two three", "three"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old, new := []rune(test.old), []rune(test.new)
+			start := len([]rune(test.old[:strings.Index(test.old, test.quote)]))
+			r := Range{Start: start, End: start + len([]rune(test.quote))}
+			rebased := r.rebase(diff(old, new))
+			if rebased.Start < 0 || rebased.End > len(new) || rebased.Start > rebased.End {
+				t.Fatalf("rebase(%v) = %v, out of %q", r, rebased, test.new)
+			}
+			if got := string(new[rebased.Start:rebased.End]); got != test.want {
+				t.Errorf("rebase(%v) = %v holding %q, want %q", r, rebased, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package notes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	FORMAT_MARKDOWN = "markdown"
+	FORMAT_HTML     = "html"
+	FORMAT_TXT      = "txt"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Draft is a tale with the notes of its chapters, see Repository.ReadDraft.
+type Draft struct {
+	Title    string
+	Chapters []DraftChapter
+}
+
+type DraftChapter struct {
+	Id      int
+	Content string
+	// Notes are in the order of the text.
+	Notes []Note
+}
+
+// numbered is a note with its number in the export.
+type numbered struct {
+	Note
+	number int
+	// text is the text of the range, or the quote once it was deleted
+	text string
+}
+
+// exporter writes the parts of a draft in one format.
+type exporter struct {
+	begin   func(w io.Writer, title string)
+	chapter func(w io.Writer, number int, content string)
+	notes   func(w io.Writer, notes []numbered)
+	end     func(w io.Writer)
+	// escape and marker are applied to the content of the chapters
+	escape func(text string) string
+	marker func(number int) string
+}
+
+var exporters = map[string]exporter{
+	FORMAT_MARKDOWN: {
+		begin: func(w io.Writer, title string) {
+			fmt.Fprintf(w, "# %s\n", title)
+		},
+		chapter: func(w io.Writer, number int, content string) {
+			fmt.Fprintf(w, "\n## Chapter %d\n\n%s\n", number, content)
+		},
+		notes: func(w io.Writer, notes []numbered) {
+			fmt.Fprintln(w)
+			for _, note := range notes {
+				body := strings.ReplaceAll(noteText(note), "\n", "\n    ")
+				fmt.Fprintf(w, "[^%d]: %s\n", note.number, body)
+			}
+		},
+		end:    func(w io.Writer) {},
+		escape: func(text string) string { return text },
+		marker: func(number int) string { return fmt.Sprintf("[^%d]", number) },
+	},
+	FORMAT_TXT: {
+		begin: func(w io.Writer, title string) {
+			fmt.Fprintf(w, "%s\n%s\n", title, strings.Repeat("=", len([]rune(title))))
+		},
+		chapter: func(w io.Writer, number int, content string) {
+			fmt.Fprintf(w, "\nChapter %d\n\n%s\n", number, content)
+		},
+		notes: func(w io.Writer, notes []numbered) {
+			fmt.Fprintln(w, "\nNotes")
+			for _, note := range notes {
+				fmt.Fprintf(w, "[%d] %s\n", note.number, noteText(note))
+			}
+		},
+		end:    func(w io.Writer) {},
+		escape: func(text string) string { return text },
+		marker: func(number int) string { return fmt.Sprintf("[%d]", number) },
+	},
+	FORMAT_HTML: {
+		begin: func(w io.Writer, title string) {
+			title = html.EscapeString(title)
+			fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n", title, title)
+		},
+		chapter: func(w io.Writer, number int, content string) {
+			fmt.Fprintf(w, "<h2>Chapter %d</h2>\n", number)
+			for _, paragraph := range strings.Split(content, "\n\n") {
+				if strings.TrimSpace(paragraph) != "" {
+					fmt.Fprintf(w, "<p>%s</p>\n", strings.ReplaceAll(paragraph, "\n", "<br>\n"))
+				}
+			}
+		},
+		notes: func(w io.Writer, notes []numbered) {
+			fmt.Fprintln(w, "<ol class=\"notes\">")
+			for _, note := range notes {
+				fmt.Fprintf(w, "<li value=\"%d\" id=\"note-%d\">%s</li>\n",
+					note.number, note.number, html.EscapeString(noteText(note)))
+			}
+			fmt.Fprintln(w, "</ol>")
+		},
+		end: func(w io.Writer) {
+			fmt.Fprintln(w, "</body>\n</html>")
+		},
+		escape: html.EscapeString,
+		marker: func(number int) string {
+			return fmt.Sprintf("<sup><a href=\"#note-%d\">%d</a></sup>", number, number)
+		},
+	},
+}
+
+// Export writes the draft in format, each note being marked at the end of
+// its range and listed after its chapter. The notes are numbered through
+// the draft.
+func (draft *Draft) Export(w io.Writer, format string) error {
+	exporter, ok := exporters[format]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	buffered := bufio.NewWriter(w)
+	exporter.begin(buffered, draft.Title)
+	number := 0
+	for i, chapter := range draft.Chapters {
+		content := []rune(chapter.Content)
+		notes := make([]numbered, len(chapter.Notes))
+		for j, note := range chapter.Notes {
+			notes[j] = numbered{Note: note, text: note.Quote}
+			if note.Range.Start < note.Range.End && note.Range.End <= len(content) {
+				notes[j].text = string(content[note.Range.Start:note.Range.End])
+			}
+		}
+		// numbered in the order of the markers
+		slices.SortStableFunc(notes, func(a, b numbered) int {
+			return a.Range.End - b.Range.End
+		})
+		for j := range notes {
+			number++
+			notes[j].number = number
+		}
+		exporter.chapter(buffered, i+1, marked(content, notes, exporter))
+		if len(notes) > 0 {
+			exporter.notes(buffered, notes)
+		}
+	}
+	exporter.end(buffered)
+	return buffered.Flush()
+}
+
+// marked returns the escaped content with the markers of the notes, which
+// are sorted by the end of their range.
+func marked(content []rune, notes []numbered, exporter exporter) string {
+	builder := strings.Builder{}
+	position := 0
+	for _, note := range notes {
+		end := min(note.Range.End, len(content))
+		builder.WriteString(exporter.escape(string(content[position:end])))
+		builder.WriteString(exporter.marker(note.number))
+		position = end
+	}
+	builder.WriteString(exporter.escape(string(content[position:])))
+	return builder.String()
+}
+
+func noteText(note numbered) string {
+	text := note.Body
+	if note.text != "" {
+		text = fmt.Sprintf("“%s”: %s", note.text, note.Body)
+	}
+	if _, resolved := note.Resolved(); resolved {
+		text += " (resolved)"
+	}
+	return text
+}
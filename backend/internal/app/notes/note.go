@@ -0,0 +1,175 @@
+package notes
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+const tableName = "notes"
+
+// ErrInvalidNote is returned for notes that can't be saved.
+var ErrInvalidNote = errors.New("invalid note")
+
+// Note is a comment on a range of characters of a chapter. The range
+// follows the edits of the chapter, see Repository.
+type Note struct {
+	Id        int
+	ChapterId int
+	Range     Range
+	// Quote is the text the note was anchored to, kept when that text is
+	// deleted.
+	Quote    string
+	Body     string
+	Version  int
+	resolved time.Time
+	created  time.Time
+	updated  time.Time
+}
+
+type noteMapper struct{}
+
+func Create(chapterId int, start, end int, body string) *Note {
+	return &Note{
+		ChapterId: chapterId,
+		Range:     Range{Start: start, End: end},
+		Body:      body,
+		created:   time.Now(),
+		updated:   time.Now(),
+	}
+}
+
+func (note *Note) Validate() error {
+	errs := []error{}
+	if note.ChapterId == 0 {
+		errs = append(errs, errors.New("the note has no chapter"))
+	}
+	if note.Range.Start < 0 || note.Range.End < note.Range.Start {
+		errs = append(errs, fmt.Errorf("the range %d-%d is invalid", note.Range.Start, note.Range.End))
+	}
+	if strings.TrimSpace(note.Body) == "" {
+		errs = append(errs, errors.New("the body is empty"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidNote, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Resolved tells if the note was resolved, and when.
+func (note *Note) Resolved() (time.Time, bool) {
+	return note.resolved, !note.resolved.IsZero()
+}
+
+func (note *Note) String() string {
+	return fmt.Sprintf("Note %d [%d:%d-%d]: %s", note.Id, note.ChapterId, note.Range.Start, note.Range.End, note.Body)
+}
+
+func getColumnNames() []string {
+	return []string{
+		"id",
+		"chapter_id",
+		"start_pos",
+		"end_pos",
+		"quote",
+		"body",
+		"resolved_at",
+		"created_at",
+		"updated_at",
+		"version",
+	}
+}
+
+func (noteMapper) TableName() string {
+	return tableName
+}
+
+func (noteMapper) Columns() []string {
+	return getColumnNames()
+}
+
+func (noteMapper) Values(note *Note) []any {
+	return []any{
+		note.Id,
+		note.ChapterId,
+		note.Range.Start,
+		note.Range.End,
+		note.Quote,
+		note.Body,
+		nullableTime(note.resolved),
+		utils.CleanTime(note.created),
+		utils.CleanTime(note.updated),
+		note.Version,
+	}
+}
+
+func (noteMapper) Scan(scanner data.Scanner) (*Note, error) {
+	note := Note{}
+	var resolved sql.NullString
+	var createdString, updatedString string
+	err := scanner.Scan(
+		&note.Id,
+		&note.ChapterId,
+		&note.Range.Start,
+		&note.Range.End,
+		&note.Quote,
+		&note.Body,
+		&resolved,
+		&createdString,
+		&updatedString,
+		&note.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, datetime := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{resolved.String, &note.resolved},
+		{createdString, &note.created},
+		{updatedString, &note.updated},
+	} {
+		if datetime.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(utils.DATETIME_FORMAT, datetime.value)
+		if err != nil {
+			return nil, errors.New("Failed to parse time value")
+		}
+		*datetime.target = parsed
+	}
+	return &note, nil
+}
+
+func (noteMapper) GetId(note *Note) int {
+	return note.Id
+}
+
+func (noteMapper) SetId(note *Note, id int) {
+	note.Id = id
+}
+
+func (noteMapper) VersionColumn() string {
+	return "version"
+}
+
+func (noteMapper) GetVersion(note *Note) int {
+	return note.Version
+}
+
+func (noteMapper) SetVersion(note *Note, version int) {
+	note.Version = version
+}
+
+// nullableTime stores the zero time as NULL.
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return utils.CleanTime(t)
+}
@@ -0,0 +1,326 @@
+package notes
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+const (
+	READ_BY_CHAPTER_STATEMENT = "READ_BY_CHAPTER"
+	SET_RANGE_STATEMENT       = "SET_RANGE"
+	SET_RESOLVED_STATEMENT    = "SET_RESOLVED"
+)
+
+// the bases have the chapter as key and are saved whether they exist or
+// not, which the query builders don't cover
+const (
+	READ_BASE_QUERY = "SELECT content FROM note_bases WHERE chapter_id = ?;"
+	SAVE_BASE_QUERY = `INSERT INTO note_bases (chapter_id, content) VALUES (?, ?)
+ON CONFLICT (chapter_id) DO UPDATE SET content = excluded.content;`
+	CHAPTER_CONTENT_QUERY = "SELECT ifnull(content, '') FROM chapters WHERE id = ?;"
+	TALE_CHAPTERS_QUERY   = "SELECT id, ifnull(content, '') FROM chapters WHERE tale_id = ? ORDER BY id;"
+	TALE_NAME_QUERY       = "SELECT name FROM tales WHERE id = ? AND deleted_at IS NULL;"
+)
+
+// Repository stores the notes of the chapters. The content of a chapter
+// the notes were placed on is kept, and the notes are moved through the
+// edits made since whenever the notes of the chapter are read or saved.
+type Repository interface {
+	// Create anchors the note on the current content of its chapter, the
+	// quote being the text of its range.
+	Create(note *Note) (int, error)
+	ReadById(id int) (*Note, error)
+	// ReadByChapter returns the notes of a chapter in the order of the
+	// text.
+	ReadByChapter(chapterId int) ([]Note, error)
+	// ReadOpenByTale returns the notes not resolved on the chapters of a
+	// tale, in the order of the chapters then of the text.
+	ReadOpenByTale(taleId int) ([]Note, error)
+	// Update saves the body and the range of the note, the quote is taken
+	// again when the range changed. It fails with a *data.StaleError[Note]
+	// if the note changed since it was read.
+	Update(note *Note) error
+	Resolve(id int) error
+	Unresolve(id int) error
+	Delete(id int) error
+	// ReadDraft returns the chapters of a tale with their notes, the
+	// resolved notes being left out unless includeResolved.
+	ReadDraft(taleId int, includeResolved bool) (*Draft, error)
+	Close() error
+}
+
+type noteRepository struct {
+	dbConn   *data.DatabaseConnector
+	entities *data.Repository[Note]
+}
+
+func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
+	entities, err := data.NewRepository[Note](dbConn, noteMapper{})
+	if err != nil {
+		return nil, err
+	}
+	queries := map[string]string{
+		READ_BY_CHAPTER_STATEMENT: data.ReadByColumnOrderedQuery(tableName, getColumnNames(),
+			"chapter_id", []string{"start_pos", "end_pos", "id"}),
+		SET_RANGE_STATEMENT:    data.UpdateColumnsQuery(tableName, []string{"start_pos", "end_pos"}),
+		SET_RESOLVED_STATEMENT: data.UpdateColumnsQuery(tableName, []string{"resolved_at"}),
+	}
+	for name, query := range queries {
+		if err := entities.Prepare(name, query); err != nil {
+			entities.Close()
+			return nil, err
+		}
+	}
+	return &noteRepository{
+		dbConn:   dbConn,
+		entities: entities,
+	}, nil
+}
+
+// rebase moves the notes of a chapter through the edits made to its
+// content since they were placed, and returns that content.
+func (repo noteRepository) rebase(tx *sql.Tx, chapterId int) ([]rune, error) {
+	var content string
+	err := tx.QueryRow(CHAPTER_CONTENT_QUERY, chapterId).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: chapter %d not found", ErrInvalidNote, chapterId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var base string
+	err = tx.QueryRow(READ_BASE_QUERY, chapterId).Scan(&base)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && base == content) {
+		// no note was placed on the chapter, or it wasn't edited since
+		return []rune(content), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(content)
+	edits := diff([]rune(base), runes)
+	entities := repo.entities.WithTx(tx)
+	notes, err := entities.ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		moved := note.Range.rebase(edits)
+		if moved == note.Range {
+			continue
+		}
+		if err := entities.Exec(SET_RANGE_STATEMENT, moved.Start, moved.End, note.Id); err != nil {
+			return nil, err
+		}
+	}
+	return runes, saveBase(tx, chapterId, content)
+}
+
+func saveBase(tx *sql.Tx, chapterId int, content string) error {
+	_, err := tx.Exec(SAVE_BASE_QUERY, chapterId, content)
+	return err
+}
+
+// anchor checks the range of the note against the content of its chapter
+// and takes its quote.
+func anchor(note *Note, content []rune) error {
+	if note.Range.End > len(content) {
+		return fmt.Errorf("%w: the range %d-%d is past the end of the chapter (%d)",
+			ErrInvalidNote, note.Range.Start, note.Range.End, len(content))
+	}
+	note.Quote = string(content[note.Range.Start:note.Range.End])
+	return nil
+}
+
+func (repo noteRepository) Create(note *Note) (int, error) {
+	if err := note.Validate(); err != nil {
+		return 0, err
+	}
+	quote := note.Quote
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		content, err := repo.rebase(tx, note.ChapterId)
+		if err != nil {
+			return err
+		}
+		if err := anchor(note, content); err != nil {
+			return err
+		}
+		if _, err := repo.entities.WithTx(tx).Create(note); err != nil {
+			return err
+		}
+		return saveBase(tx, note.ChapterId, string(content))
+	})
+	if err != nil {
+		note.Id, note.Quote = 0, quote
+		return 0, err
+	}
+	return note.Id, nil
+}
+
+func (repo noteRepository) ReadById(id int) (*Note, error) {
+	var note *Note
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		entities := repo.entities.WithTx(tx)
+		stored, err := entities.ReadById(id)
+		if err != nil {
+			return err
+		}
+		if _, err := repo.rebase(tx, stored.ChapterId); err != nil {
+			return err
+		}
+		note, err = entities.ReadById(id)
+		return err
+	})
+	return note, err
+}
+
+func (repo noteRepository) ReadByChapter(chapterId int) ([]Note, error) {
+	notes := []Note{}
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		var err error
+		notes, err = repo.readByChapter(tx, chapterId)
+		return err
+	})
+	if err != nil {
+		return []Note{}, err
+	}
+	return notes, nil
+}
+
+func (repo noteRepository) readByChapter(tx *sql.Tx, chapterId int) ([]Note, error) {
+	if _, err := repo.rebase(tx, chapterId); err != nil {
+		return nil, err
+	}
+	collection, err := repo.entities.WithTx(tx).ReadMany(READ_BY_CHAPTER_STATEMENT, chapterId)
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]Note, len(collection))
+	for i, note := range collection {
+		notes[i] = *note
+	}
+	return notes, nil
+}
+
+func (repo noteRepository) ReadOpenByTale(taleId int) ([]Note, error) {
+	draft, err := repo.ReadDraft(taleId, false)
+	if err != nil {
+		return []Note{}, err
+	}
+	notes := []Note{}
+	for _, chapter := range draft.Chapters {
+		notes = append(notes, chapter.Notes...)
+	}
+	return notes, nil
+}
+
+func (repo noteRepository) ReadDraft(taleId int, includeResolved bool) (*Draft, error) {
+	draft := &Draft{Chapters: []DraftChapter{}}
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		err := tx.QueryRow(TALE_NAME_QUERY, taleId).Scan(&draft.Title)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("tale %d not found", taleId)
+		}
+		if err != nil {
+			return err
+		}
+		rows, err := tx.Query(TALE_CHAPTERS_QUERY, taleId)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			chapter := DraftChapter{}
+			if err := rows.Scan(&chapter.Id, &chapter.Content); err != nil {
+				return err
+			}
+			draft.Chapters = append(draft.Chapters, chapter)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+
+		for i, chapter := range draft.Chapters {
+			notes, err := repo.readByChapter(tx, chapter.Id)
+			if err != nil {
+				return err
+			}
+			if !includeResolved {
+				notes = slices.DeleteFunc(notes, func(note Note) bool {
+					_, resolved := note.Resolved()
+					return resolved
+				})
+			}
+			draft.Chapters[i].Notes = notes
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func (repo noteRepository) Update(note *Note) error {
+	if err := note.Validate(); err != nil {
+		return err
+	}
+	anchored, quote, updated, version := note.Range, note.Quote, note.updated, note.Version
+	note.updated = time.Now()
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		entities := repo.entities.WithTx(tx)
+		stored, err := entities.ReadById(note.Id)
+		if err != nil {
+			return err
+		}
+		if stored.ChapterId != note.ChapterId {
+			return fmt.Errorf("%w: a note can't be moved to another chapter", ErrInvalidNote)
+		}
+		content, err := repo.rebase(tx, note.ChapterId)
+		if err != nil {
+			return err
+		}
+		note.resolved, note.created = stored.resolved, stored.created
+		if note.Range != stored.Range {
+			if err := anchor(note, content); err != nil {
+				return err
+			}
+			return entities.Update(note)
+		}
+		// the range wasn't changed, it follows the edits of the chapter
+		rebased, err := entities.ReadById(note.Id)
+		if err != nil {
+			return err
+		}
+		note.Range, note.Quote = rebased.Range, stored.Quote
+		return entities.Update(note)
+	})
+	if err != nil {
+		note.Range, note.Quote, note.updated, note.Version = anchored, quote, updated, version
+		return err
+	}
+	return nil
+}
+
+func (repo noteRepository) Resolve(id int) error {
+	return repo.entities.Exec(SET_RESOLVED_STATEMENT, utils.CleanTime(time.Now()), id)
+}
+
+func (repo noteRepository) Unresolve(id int) error {
+	return repo.entities.Exec(SET_RESOLVED_STATEMENT, nil, id)
+}
+
+func (repo noteRepository) Delete(id int) error {
+	return repo.entities.Delete(id)
+}
+
+func (repo noteRepository) Close() error {
+	return repo.entities.Close()
+}
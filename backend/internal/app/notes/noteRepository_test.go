@@ -0,0 +1,147 @@
+package notes
+
+import (
+	"errors"
+	"strings"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/data/datatest"
+	"testing"
+)
+
+// newTestRepository opens a note repository on a new library holding a
+// tale besides the root one, with a chapter of content.
+func newTestRepository(t *testing.T, content string) (Repository, *data.DatabaseConnector) {
+	t.Helper()
+	dbConn := datatest.Open(t)
+	_, err := dbConn.ExecuteQuery("INSERT INTO tales (name, created_at, updated_at) VALUES ('tale', '', '');", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dbConn.ExecuteQuery("INSERT INTO chapters (tale_id, content) VALUES (2, ?);", []any{content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	return repo, dbConn
+}
+
+// editChapter saves the content of the chapter, as an edit made without
+// the notes.
+func editChapter(t *testing.T, dbConn *data.DatabaseConnector, content string) {
+	t.Helper()
+	_, err := dbConn.ExecuteQuery("UPDATE chapters SET content = ? WHERE id = 1;", []any{content})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createNote places a note on the first occurrence of quote in content.
+func createNote(t *testing.T, repo Repository, content, quote, body string) *Note {
+	t.Helper()
+	start := len([]rune(content[:strings.Index(content, quote)]))
+	note := Create(1, start, start+len([]rune(quote)), body)
+	if _, err := repo.Create(note); err != nil {
+		t.Fatal(err)
+	}
+	if note.Quote != quote {
+		t.Fatalf("the note quotes %q, want %q", note.Quote, quote)
+	}
+	return note
+}
+
+// rangeText returns the text of the note in content.
+func rangeText(note Note, content string) string {
+	return string([]rune(content)[note.Range.Start:note.Range.End])
+}
+
+func TestNotesFollowTheEdits(t *testing.T) {
+	content := "The rain fell on the roofs."
+	repo, dbConn := newTestRepository(t, content)
+	rain := createNote(t, repo, content, "rain", "too plain")
+	roofs := createNote(t, repo, content, "roofs", "which roofs?")
+
+	edited := "Softly, the cold rain fell on the slate roofs."
+	editChapter(t, dbConn, edited)
+	notes, err := repo.ReadByChapter(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("ReadByChapter(1) returned %d notes, want 2", len(notes))
+	}
+	for i, want := range []string{"rain", "roofs"} {
+		if got := rangeText(notes[i], edited); got != want {
+			t.Errorf("note %d holds %q after the edit, want %q", notes[i].Id, got, want)
+		}
+	}
+
+	// the range of a note saved unchanged follows the edits made since it
+	// was read
+	edited = "Softly, the cold rain fell on the old slate roofs."
+	editChapter(t, dbConn, edited)
+	roofs = &notes[1]
+	roofs.Body = "keep slate"
+	if err := repo.Update(roofs); err != nil {
+		t.Fatal(err)
+	}
+	if got := rangeText(*roofs, edited); got != "roofs" {
+		t.Errorf("the updated note holds %q, want %q", got, "roofs")
+	}
+
+	// a note whose text is deleted keeps its quote
+	edited = "Softly, the cold fell on the old slate roofs."
+	editChapter(t, dbConn, edited)
+	stored, err := repo.ReadById(rain.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Range.Start != stored.Range.End || stored.Quote != "rain" {
+		t.Errorf("the deleted note reads %v quoting %q, want an empty range quoting %q", stored.Range, stored.Quote, "rain")
+	}
+
+	if err := repo.Resolve(rain.Id); err != nil {
+		t.Fatal(err)
+	}
+	open, err := repo.ReadOpenByTale(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].Id != roofs.Id || rangeText(open[0], edited) != "roofs" {
+		t.Errorf("ReadOpenByTale(2) = %v, want the note on roofs", open)
+	}
+}
+
+func TestNoteUpdate(t *testing.T) {
+	content := "The rain fell on the roofs."
+	repo, _ := newTestRepository(t, content)
+	note := createNote(t, repo, content, "rain", "too plain")
+	stale := *note
+
+	note.Range = Range{Start: 21, End: 26}
+	if err := repo.Update(note); err != nil {
+		t.Fatal(err)
+	}
+	if note.Quote != "roofs" {
+		t.Errorf("the moved note quotes %q, want %q", note.Quote, "roofs")
+	}
+
+	stale.Body = "edited elsewhere"
+	var staleErr *data.StaleError[Note]
+	if err := repo.Update(&stale); !errors.As(err, &staleErr) {
+		t.Errorf("Update() of a stale note = %v, want a stale error", err)
+	}
+	version := note.Version
+	note.Range = Range{Start: 20, End: 40}
+	if err := repo.Update(note); !errors.Is(err, ErrInvalidNote) {
+		t.Errorf("Update() past the end of the chapter = %v, want %v", err, ErrInvalidNote)
+	}
+	if note.Quote != "roofs" || note.Version != version {
+		t.Errorf("the failed update left the note quoting %q at version %d", note.Quote, note.Version)
+	}
+}
@@ -0,0 +1,416 @@
+package similarity
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"talenest/backend/internal/app/chapter"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/text"
+)
+
+const (
+	DELETE_SIGNATURE_STATEMENT  = "DELETE_SIGNATURE"
+	READ_PROPOSAL_STATEMENT     = "READ_PROPOSAL"
+	PROPOSE_STATEMENT           = "PROPOSE"
+	DELETE_PROPOSAL_STATEMENT   = "DELETE_PROPOSAL"
+	DELETE_PROPOSALS_STATEMENT  = "DELETE_PROPOSALS"
+	LINK_STATEMENT              = "LINK"
+	UNLINK_AUTOMATIC_STATEMENT  = "UNLINK_AUTOMATIC"
+	UNLINK_AUTOMATICS_STATEMENT = "UNLINK_AUTOMATICS"
+)
+
+// Result tells what an update of the signatures found.
+type Result struct {
+	// Updated are the tales whose signature changed.
+	Updated []int
+	// Similar are the pairs of updated tales found similar, proposed or
+	// linked depending on the configuration.
+	Similar []Pair
+}
+
+// Engine finds the similar tales of the library. The signature of each
+// tale is kept with a fingerprint of its chapters, so that only the tales
+// whose chapters changed are compared again.
+type Engine struct {
+	dbConn     *data.DatabaseConnector
+	chapters   chapter.Repository
+	signatures *data.Repository[taleSignature]
+	proposals  *data.Repository[Pair]
+	links      *data.Repository[Pair]
+	options    config.SimilarityConfig
+}
+
+func NewEngine(dbConn *data.DatabaseConnector, chapters chapter.Repository, options config.SimilarityConfig) (*Engine, error) {
+	signatures, err := data.NewRepository[taleSignature](dbConn, signatureMapper{})
+	if err != nil {
+		return nil, err
+	}
+	proposals, err := data.NewRepository[Pair](dbConn, proposalMapper{})
+	if err != nil {
+		signatures.Close()
+		return nil, err
+	}
+	links, err := data.NewRepository[Pair](dbConn, linkMapper{})
+	if err != nil {
+		signatures.Close()
+		proposals.Close()
+		return nil, err
+	}
+	engine := &Engine{
+		dbConn:     dbConn,
+		chapters:   chapters,
+		signatures: signatures,
+		proposals:  proposals,
+		links:      links,
+		options:    options,
+	}
+
+	err = signatures.Prepare(DELETE_SIGNATURE_STATEMENT,
+		data.DeleteByColumnsQuery(signaturesTableName, []string{"tale_id"}))
+	if err != nil {
+		engine.Close()
+		return nil, err
+	}
+	proposalQueries := map[string]string{
+		READ_PROPOSAL_STATEMENT: data.ReadByColumnsQuery(proposalsTableName, getProposalColumnNames(),
+			[]string{"first_tale_id", "second_tale_id"}),
+		PROPOSE_STATEMENT:          upsertPairQuery(proposalsTableName, false),
+		DELETE_PROPOSAL_STATEMENT:  data.DeleteByColumnsQuery(proposalsTableName, []string{"first_tale_id", "second_tale_id"}),
+		DELETE_PROPOSALS_STATEMENT: deleteByTaleQuery(proposalsTableName, false),
+	}
+	for name, query := range proposalQueries {
+		if err := proposals.Prepare(name, query); err != nil {
+			engine.Close()
+			return nil, err
+		}
+	}
+	linkQueries := map[string]string{
+		LINK_STATEMENT:              upsertPairQuery(linksTableName, true),
+		UNLINK_AUTOMATIC_STATEMENT:  unlinkAutomaticQuery(),
+		UNLINK_AUTOMATICS_STATEMENT: deleteByTaleQuery(linksTableName, true),
+	}
+	for name, query := range linkQueries {
+		if err := links.Prepare(name, query); err != nil {
+			engine.Close()
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// upsertPairQuery writes a pair, the lower tale id first, or updates the
+// score of the stored one. When automatic is set the manual links,
+// without score, are left as they are.
+func upsertPairQuery(tableName string, automatic bool) string {
+	columns := data.ConvertToColumns([]string{"first_tale_id", "second_tale_id", "score"})
+	builder := data.NewInsertQueryBuilder(tableName)
+	builder.SetColumns(columns)
+	builder.SetValues(data.GetTokens(len(columns), "?"))
+	var where *data.WhereGroup
+	if automatic {
+		where = data.NewWhereGroup()
+		where.SetWhereNotNull(tableName, columns[2], "")
+	}
+	builder.SetUpsert(columns[:2], columns[2:], where)
+	query, _, _ := builder.Build()
+	return query
+}
+
+// deleteByTaleQuery deletes the pairs of a tale, given twice, only the
+// automatic ones when automatic is set.
+func deleteByTaleQuery(tableName string, automatic bool) string {
+	columns := data.ConvertToColumns([]string{"first_tale_id", "second_tale_id", "score"})
+	tale := data.NewWhereGroup()
+	tale.SetWhere(tableName, columns[0], "=", data.NewTokenValue("?"), "")
+	tale.SetWhere(tableName, columns[1], "=", data.NewTokenValue("?"), "OR")
+	builder := data.NewDeleteQueryBuilder(tableName)
+	builder.SetWhereGroup(tale, "")
+	if automatic {
+		builder.SetWhereNotNull(tableName, columns[2], "AND")
+	}
+	query, _ := builder.Build()
+	return query
+}
+
+// unlinkAutomaticQuery deletes a pair linked by the engine.
+func unlinkAutomaticQuery() string {
+	columns := data.ConvertToColumns([]string{"first_tale_id", "second_tale_id", "score"})
+	builder := data.NewDeleteQueryBuilder(linksTableName)
+	builder.SetWhere(linksTableName, columns[0], "=", data.NewTokenValue("?"), "")
+	builder.SetWhere(linksTableName, columns[1], "=", data.NewTokenValue("?"), "AND")
+	builder.SetWhereNotNull(linksTableName, columns[2], "AND")
+	query, _ := builder.Build()
+	return query
+}
+
+// Update compares the tales whose chapters changed since the last update.
+func (engine *Engine) Update() (*Result, error) {
+	return engine.updateAll(false)
+}
+
+// Recompute compares every tale again, e.g. after the threshold changed.
+func (engine *Engine) Recompute() (*Result, error) {
+	return engine.updateAll(true)
+}
+
+func (engine *Engine) updateAll(force bool) (*Result, error) {
+	chapters, err := engine.chapters.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	contents := taleContents(chapters)
+	stored, err := engine.signatures.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	// the tales left without chapters lose their signature
+	for _, signature := range stored {
+		if _, ok := contents[signature.TaleId]; !ok {
+			contents[signature.TaleId] = nil
+		}
+	}
+	return engine.update(contents, force)
+}
+
+// UpdateTales compares the given tales again if their chapters changed, to
+// be called once they are saved.
+func (engine *Engine) UpdateTales(taleIds ...int) (*Result, error) {
+	contents := map[int][]string{}
+	for _, taleId := range taleIds {
+		chapters, err := engine.chapters.ReadByTale(taleId)
+		if err != nil {
+			return nil, err
+		}
+		contents[taleId] = taleContents(chapters)[taleId]
+	}
+	return engine.update(contents, false)
+}
+
+// taleContents returns the content of the chapters of each tale, in the
+// order of the chapters.
+func taleContents(chapters *chapter.Chapters) map[int][]string {
+	collection := []*chapter.Chapter{}
+	for chapter := range chapters.ChaptersStream() {
+		collection = append(collection, chapter)
+	}
+	slices.SortFunc(collection, func(a, b *chapter.Chapter) int {
+		return a.Id - b.Id
+	})
+	contents := map[int][]string{}
+	for _, chapter := range collection {
+		contents[chapter.TaleId] = append(contents[chapter.TaleId], chapter.Content)
+	}
+	return contents
+}
+
+func fingerprint(contents []string) string {
+	hash := sha256.New()
+	for _, content := range contents {
+		hash.Write([]byte(content))
+		// separates the chapters
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// update stores the signatures of the tales in contents, nil contents
+// removing the signature, and compares the tales that changed to every
+// other one.
+func (engine *Engine) update(contents map[int][]string, force bool) (*Result, error) {
+	result := &Result{Updated: []int{}, Similar: []Pair{}}
+	err := engine.dbConn.Transaction(func(tx *sql.Tx) error {
+		signatures := engine.signatures.WithTx(tx)
+		proposals := engine.proposals.WithTx(tx)
+		collection, err := signatures.ReadAll()
+		if err != nil {
+			return err
+		}
+		stored := map[int]*taleSignature{}
+		for _, signature := range collection {
+			stored[signature.TaleId] = signature
+		}
+
+		changed := []int{}
+		for taleId, taleContents := range contents {
+			previous, exists := stored[taleId]
+			if len(taleContents) == 0 {
+				if exists {
+					if err := engine.remove(tx, taleId); err != nil {
+						return err
+					}
+					delete(stored, taleId)
+					result.Updated = append(result.Updated, taleId)
+				}
+				continue
+			}
+			taleFingerprint := fingerprint(taleContents)
+			if exists && previous.Fingerprint == taleFingerprint && !force {
+				continue
+			}
+			terms := []string{}
+			for _, content := range taleContents {
+				terms = append(terms, text.Terms(content)...)
+			}
+			signature := &taleSignature{
+				TaleId:      taleId,
+				Fingerprint: taleFingerprint,
+				Signature:   NewSignature(terms),
+			}
+			if exists {
+				signature.Id = previous.Id
+				err = signatures.Update(signature)
+			} else {
+				_, err = signatures.Create(signature)
+			}
+			if err != nil {
+				return err
+			}
+			stored[taleId] = signature
+			changed = append(changed, taleId)
+			result.Updated = append(result.Updated, taleId)
+		}
+
+		links := engine.links.WithTx(tx)
+		linked, err := readLinks(links)
+		if err != nil {
+			return err
+		}
+		compared := map[[2]int]bool{}
+		for _, taleId := range changed {
+			for otherId, other := range stored {
+				pair := newPair(taleId, otherId, 0)
+				key := [2]int{pair.FirstTaleId, pair.SecondTaleId}
+				if otherId == taleId || compared[key] {
+					continue
+				}
+				compared[key] = true
+				pair.Score = stored[taleId].Signature.Similarity(other.Signature)
+				similar, err := engine.save(proposals, links, pair, linked[key])
+				if err != nil {
+					return err
+				}
+				if similar {
+					result.Similar = append(result.Similar, pair)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(result.Updated)
+	slices.SortFunc(result.Similar, func(a, b Pair) int {
+		if a.FirstTaleId != b.FirstTaleId {
+			return a.FirstTaleId - b.FirstTaleId
+		}
+		return a.SecondTaleId - b.SecondTaleId
+	})
+	return result, nil
+}
+
+// save proposes or links a pair above the threshold and withdraws it
+// otherwise. Linked pairs are never proposed.
+func (engine *Engine) save(proposals, links *data.Repository[Pair], pair Pair, linked bool) (bool, error) {
+	if pair.Score < engine.options.Threshold {
+		_, err := proposals.ExecMany(DELETE_PROPOSAL_STATEMENT, pair.FirstTaleId, pair.SecondTaleId)
+		if err != nil {
+			return false, err
+		}
+		_, err = links.ExecMany(UNLINK_AUTOMATIC_STATEMENT, pair.FirstTaleId, pair.SecondTaleId)
+		return false, err
+	}
+	if linked || engine.options.AutoLink {
+		_, err := links.ExecMany(LINK_STATEMENT, pair.FirstTaleId, pair.SecondTaleId, pair.Score)
+		if err != nil {
+			return false, err
+		}
+		_, err = proposals.ExecMany(DELETE_PROPOSAL_STATEMENT, pair.FirstTaleId, pair.SecondTaleId)
+		return true, err
+	}
+	_, err := proposals.ExecMany(PROPOSE_STATEMENT, pair.FirstTaleId, pair.SecondTaleId, pair.Score)
+	return true, err
+}
+
+// remove forgets a tale left without chapters.
+func (engine *Engine) remove(tx *sql.Tx, taleId int) error {
+	if err := engine.signatures.WithTx(tx).Exec(DELETE_SIGNATURE_STATEMENT, taleId); err != nil {
+		return err
+	}
+	if _, err := engine.proposals.WithTx(tx).ExecMany(DELETE_PROPOSALS_STATEMENT, taleId, taleId); err != nil {
+		return err
+	}
+	_, err := engine.links.WithTx(tx).ExecMany(UNLINK_AUTOMATICS_STATEMENT, taleId, taleId)
+	return err
+}
+
+// readLinks returns the pairs of is_similar.
+func readLinks(links *data.Repository[Pair]) (map[[2]int]bool, error) {
+	collection, err := links.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	linked := map[[2]int]bool{}
+	for _, pair := range collection {
+		linked[[2]int{pair.FirstTaleId, pair.SecondTaleId}] = true
+	}
+	return linked, nil
+}
+
+// Proposals returns the similar tales waiting for the user, the most
+// similar first.
+func (engine *Engine) Proposals() ([]Pair, error) {
+	collection, err := engine.proposals.ReadAll()
+	if err != nil {
+		return []Pair{}, err
+	}
+	pairs := make([]Pair, 0, len(collection))
+	for _, pair := range collection {
+		pairs = append(pairs, *pair)
+	}
+	slices.SortStableFunc(pairs, func(a, b Pair) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return pairs, nil
+}
+
+// Accept links the tales of a proposal.
+func (engine *Engine) Accept(taleId, otherId int) error {
+	pair := newPair(taleId, otherId, 0)
+	return engine.dbConn.Transaction(func(tx *sql.Tx) error {
+		proposals := engine.proposals.WithTx(tx)
+		found, err := proposals.ReadMany(READ_PROPOSAL_STATEMENT, pair.FirstTaleId, pair.SecondTaleId)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("similarity proposal %d, %d: %w", pair.FirstTaleId, pair.SecondTaleId, data.ErrNotFound)
+		}
+		if _, err := engine.links.WithTx(tx).ExecMany(LINK_STATEMENT, pair.FirstTaleId, pair.SecondTaleId, found[0].Score); err != nil {
+			return err
+		}
+		return proposals.Exec(DELETE_PROPOSAL_STATEMENT, pair.FirstTaleId, pair.SecondTaleId)
+	})
+}
+
+// Reject drops a proposal. It's proposed again if the tales change and
+// are still similar.
+func (engine *Engine) Reject(taleId, otherId int) error {
+	pair := newPair(taleId, otherId, 0)
+	return engine.proposals.Exec(DELETE_PROPOSAL_STATEMENT, pair.FirstTaleId, pair.SecondTaleId)
+}
+
+func (engine *Engine) Close() error {
+	return errors.Join(engine.signatures.Close(), engine.proposals.Close(), engine.links.Close())
+}
@@ -0,0 +1,171 @@
+package similarity
+
+import (
+	"reflect"
+	"strings"
+	"talenest/backend/internal/app/chapter"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/data/datatest"
+	"testing"
+)
+
+// newTestEngine opens an engine on a new library holding tales tales
+// besides the root one, numbered from 2.
+func newTestEngine(t *testing.T, tales int, options config.SimilarityConfig) (*Engine, chapter.Repository, *data.DatabaseConnector) {
+	t.Helper()
+	dbConn := datatest.Open(t)
+	for range tales {
+		_, err := dbConn.ExecuteQuery("INSERT INTO tales (name, created_at, updated_at) VALUES ('tale', '', '');", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	chapters, err := chapter.NewRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewEngine(dbConn, chapters, options)
+	if err != nil {
+		chapters.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		engine.Close()
+		chapters.Close()
+	})
+	return engine, chapters, dbConn
+}
+
+func createChapter(t *testing.T, chapters chapter.Repository, taleId int, content string) *chapter.Chapter {
+	t.Helper()
+	created := &chapter.Chapter{TaleId: taleId, Content: content}
+	if _, err := chapters.Create(created); err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+// readLinkedPairs returns the pairs of is_similar without their id.
+func readLinkedPairs(t *testing.T, engine *Engine) []Pair {
+	t.Helper()
+	collection, err := engine.links.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs := []Pair{}
+	for _, pair := range collection {
+		pairs = append(pairs, Pair{FirstTaleId: pair.FirstTaleId, SecondTaleId: pair.SecondTaleId, Score: pair.Score})
+	}
+	return pairs
+}
+
+func updateEngine(t *testing.T, engine *Engine) *Result {
+	t.Helper()
+	result, err := engine.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestEngineProposeAndAccept(t *testing.T) {
+	engine, chapters, _ := newTestEngine(t, 3, config.SimilarityConfig{Threshold: 0.5})
+	story := strings.Join(words("rain", 200), " ")
+	createChapter(t, chapters, 2, story)
+	createChapter(t, chapters, 3, story)
+	createChapter(t, chapters, 4, strings.Join(words("snow", 200), " "))
+
+	result := updateEngine(t, engine)
+	if want := []int{2, 3, 4}; !reflect.DeepEqual(result.Updated, want) {
+		t.Errorf("Updated = %v, want %v", result.Updated, want)
+	}
+	want := []Pair{{FirstTaleId: 2, SecondTaleId: 3, Score: 1}}
+	if !reflect.DeepEqual(result.Similar, want) {
+		t.Errorf("Similar = %v, want %v", result.Similar, want)
+	}
+	proposals, err := engine.Proposals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals) != 1 || proposals[0].FirstTaleId != 2 || proposals[0].SecondTaleId != 3 {
+		t.Fatalf("Proposals() = %v, want the pair 2, 3", proposals)
+	}
+	// nothing changed, nothing is compared again
+	if result := updateEngine(t, engine); len(result.Updated) != 0 {
+		t.Errorf("a second update changed %v", result.Updated)
+	}
+
+	if err := engine.Accept(3, 2); err != nil {
+		t.Fatal(err)
+	}
+	if proposals, _ := engine.Proposals(); len(proposals) != 0 {
+		t.Errorf("the accepted proposal is still proposed: %v", proposals)
+	}
+	if pairs := readLinkedPairs(t, engine); !reflect.DeepEqual(pairs, want) {
+		t.Errorf("links = %v, want %v", pairs, want)
+	}
+	if err := engine.Accept(2, 3); err == nil {
+		t.Error("accepting a proposal twice succeeded")
+	}
+}
+
+func TestEngineKeepsManualLinks(t *testing.T) {
+	engine, chapters, dbConn := newTestEngine(t, 3, config.SimilarityConfig{Threshold: 0.5, AutoLink: true})
+	_, err := dbConn.ExecuteQuery("INSERT INTO is_similar (first_tale_id, second_tale_id) VALUES (2, 3);", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	story := strings.Join(words("rain", 200), " ")
+	createChapter(t, chapters, 2, story)
+	createChapter(t, chapters, 3, story)
+	other := createChapter(t, chapters, 4, story)
+
+	updateEngine(t, engine)
+	// the manual link keeps no score, the similar tales are linked directly
+	want := []Pair{
+		{FirstTaleId: 2, SecondTaleId: 3},
+		{FirstTaleId: 2, SecondTaleId: 4, Score: 1},
+		{FirstTaleId: 3, SecondTaleId: 4, Score: 1},
+	}
+	if pairs := readLinkedPairs(t, engine); !reflect.DeepEqual(pairs, want) {
+		t.Errorf("links = %v, want %v", pairs, want)
+	}
+	if proposals, _ := engine.Proposals(); len(proposals) != 0 {
+		t.Errorf("linked tales are proposed: %v", proposals)
+	}
+
+	// a tale left without chapters loses its automatic links only
+	if err := chapters.Delete(other.Id); err != nil {
+		t.Fatal(err)
+	}
+	if result := updateEngine(t, engine); !reflect.DeepEqual(result.Updated, []int{4}) {
+		t.Errorf("Updated = %v, want [4]", result.Updated)
+	}
+	if pairs := readLinkedPairs(t, engine); !reflect.DeepEqual(pairs, want[:1]) {
+		t.Errorf("links = %v, want %v", pairs, want[:1])
+	}
+}
+
+func TestEngineWithdrawsDissimilarTales(t *testing.T) {
+	engine, chapters, _ := newTestEngine(t, 2, config.SimilarityConfig{Threshold: 0.5})
+	story := strings.Join(words("rain", 200), " ")
+	createChapter(t, chapters, 2, story)
+	rewritten := createChapter(t, chapters, 3, story)
+	updateEngine(t, engine)
+
+	rewritten.Content = strings.Join(words("snow", 200), " ")
+	if err := chapters.Update(rewritten); err != nil {
+		t.Fatal(err)
+	}
+	result, err := engine.UpdateTales(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Similar) != 0 {
+		t.Errorf("Similar = %v, want none", result.Similar)
+	}
+	if proposals, _ := engine.Proposals(); len(proposals) != 0 {
+		t.Errorf("the tales are still proposed: %v", proposals)
+	}
+}
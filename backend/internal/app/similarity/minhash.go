@@ -0,0 +1,95 @@
+package similarity
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"strings"
+)
+
+const (
+	// NUM_HASHES is the length of the signatures, the error of the
+	// estimated similarity is about 1/sqrt(NUM_HASHES).
+	NUM_HASHES = 128
+	// SHINGLE_SIZE is the number of words of the sequences compared.
+	SHINGLE_SIZE = 3
+)
+
+var seeds = func() [NUM_HASHES]uint64 {
+	var seeds [NUM_HASHES]uint64
+	for i := range seeds {
+		seeds[i] = mix(uint64(i) + 0x9e3779b97f4a7c15)
+	}
+	return seeds
+}()
+
+// Signature is the MinHash of the word sequences of a text: the share of
+// equal values of two signatures estimates the share of sequences the
+// texts have in common.
+type Signature []uint64
+
+// NewSignature returns the signature of the terms of a text, nil when
+// there are none.
+func NewSignature(terms []string) Signature {
+	if len(terms) == 0 {
+		return nil
+	}
+	signature := make(Signature, NUM_HASHES)
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	size := min(SHINGLE_SIZE, len(terms))
+	for start := 0; start+size <= len(terms); start++ {
+		hash := fnv.New64a()
+		hash.Write([]byte(strings.Join(terms[start:start+size], " ")))
+		shingle := hash.Sum64()
+		for i, seed := range seeds {
+			signature[i] = min(signature[i], mix(shingle^seed))
+		}
+	}
+	return signature
+}
+
+// mix is the finalizer of splitmix64, a cheap way to get independent
+// hashes from one.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Similarity estimates the Jaccard similarity of the texts, from 0 to 1.
+func (signature Signature) Similarity(other Signature) float64 {
+	if len(signature) == 0 || len(signature) != len(other) {
+		return 0
+	}
+	equal := 0
+	for i := range signature {
+		if signature[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(signature))
+}
+
+func (signature Signature) encode() []byte {
+	encoded := make([]byte, 8*len(signature))
+	for i, value := range signature {
+		binary.LittleEndian.PutUint64(encoded[8*i:], value)
+	}
+	return encoded
+}
+
+func decodeSignature(encoded []byte) (Signature, error) {
+	if len(encoded)%8 != 0 {
+		return nil, errors.New("invalid signature length")
+	}
+	signature := make(Signature, len(encoded)/8)
+	for i := range signature {
+		signature[i] = binary.LittleEndian.Uint64(encoded[8*i:])
+	}
+	return signature, nil
+}
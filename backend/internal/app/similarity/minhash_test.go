@@ -0,0 +1,95 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// words returns count distinct words starting with prefix.
+func words(prefix string, count int) []string {
+	words := make([]string, count)
+	for i := range words {
+		words[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return words
+}
+
+func TestNewSignature(t *testing.T) {
+	if signature := NewSignature(nil); signature != nil {
+		t.Errorf("NewSignature(nil) = %v, want nil", signature)
+	}
+	terms := strings.Fields("the rain fell on the roofs")
+	signature := NewSignature(terms)
+	if len(signature) != NUM_HASHES {
+		t.Fatalf("the signature has %d values, want %d", len(signature), NUM_HASHES)
+	}
+	if similarity := signature.Similarity(NewSignature(terms)); similarity != 1 {
+		t.Errorf("the signatures of the same terms have a similarity of %v", similarity)
+	}
+	// texts shorter than a shingle are compared as a whole
+	if similarity := NewSignature([]string{"rain"}).Similarity(NewSignature([]string{"rain"})); similarity != 1 {
+		t.Errorf("the signatures of a single word have a similarity of %v", similarity)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     []string
+		b     []string
+		ratio float64
+	}{
+		// 98 shingles each, none shared
+		{"disjoint", words("a", 100), words("b", 100), 0},
+		// 98 shingles each, 48 shared: 48/148
+		{"half", words("a", 100), append(words("a", 100)[50:], words("b", 50)...), 48.0 / 148},
+		// 98 and 148 shingles, 98 shared
+		{"contained", words("a", 100), append(words("a", 100), words("b", 50)...), 98.0 / 148},
+	}
+	// a little more than three standard errors
+	tolerance := 4 / math.Sqrt(NUM_HASHES)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			similarity := NewSignature(test.a).Similarity(NewSignature(test.b))
+			if math.Abs(similarity-test.ratio) > tolerance {
+				t.Errorf("Similarity() = %v, want about %v", similarity, test.ratio)
+			}
+		})
+	}
+}
+
+func TestSimilarityMismatch(t *testing.T) {
+	signature := NewSignature(words("a", 10))
+	tests := []struct {
+		name string
+		a    Signature
+		b    Signature
+	}{
+		{"empty", nil, signature},
+		{"both empty", nil, nil},
+		{"other length", signature, signature[:10]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if similarity := test.a.Similarity(test.b); similarity != 0 {
+				t.Errorf("Similarity() = %v, want 0", similarity)
+			}
+		})
+	}
+}
+
+func TestSignatureEncoding(t *testing.T) {
+	signature := NewSignature(words("a", 10))
+	decoded, err := decodeSignature(signature.encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Similarity(signature) != 1 {
+		t.Error("the decoded signature differs from the encoded one")
+	}
+	if _, err := decodeSignature(make([]byte, 7)); err == nil {
+		t.Error("decodeSignature accepted a truncated signature")
+	}
+}
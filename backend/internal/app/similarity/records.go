@@ -0,0 +1,193 @@
+package similarity
+
+import (
+	"database/sql"
+	"talenest/backend/internal/data"
+)
+
+const signaturesTableName = "tale_signatures"
+const proposalsTableName = "similarity_proposals"
+const linksTableName = "is_similar"
+
+// Pair is two similar tales, FirstTaleId being the lowest id.
+type Pair struct {
+	Id           int
+	FirstTaleId  int
+	SecondTaleId int
+	Score        float64
+}
+
+func newPair(taleId, otherId int, score float64) Pair {
+	if otherId < taleId {
+		taleId, otherId = otherId, taleId
+	}
+	return Pair{FirstTaleId: taleId, SecondTaleId: otherId, Score: score}
+}
+
+// taleSignature is the signature of a tale with the fingerprint of the
+// chapters it was computed from.
+type taleSignature struct {
+	Id          int
+	TaleId      int
+	Fingerprint string
+	Signature   Signature
+}
+
+type signatureMapper struct{}
+
+func getSignatureColumnNames() []string {
+	return []string{
+		"id",
+		"tale_id",
+		"fingerprint",
+		"signature",
+	}
+}
+
+func (signatureMapper) TableName() string {
+	return signaturesTableName
+}
+
+func (signatureMapper) Columns() []string {
+	return getSignatureColumnNames()
+}
+
+func (signatureMapper) Values(signature *taleSignature) []any {
+	return []any{
+		signature.Id,
+		signature.TaleId,
+		signature.Fingerprint,
+		signature.Signature.encode(),
+	}
+}
+
+func (signatureMapper) Scan(scanner data.Scanner) (*taleSignature, error) {
+	signature := taleSignature{}
+	var encoded []byte
+	err := scanner.Scan(
+		&signature.Id,
+		&signature.TaleId,
+		&signature.Fingerprint,
+		&encoded,
+	)
+	if err != nil {
+		return nil, err
+	}
+	signature.Signature, err = decodeSignature(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+func (signatureMapper) GetId(signature *taleSignature) int {
+	return signature.Id
+}
+
+func (signatureMapper) SetId(signature *taleSignature, id int) {
+	signature.Id = id
+}
+
+type proposalMapper struct{}
+
+func getProposalColumnNames() []string {
+	return []string{
+		"id",
+		"first_tale_id",
+		"second_tale_id",
+		"score",
+	}
+}
+
+func (proposalMapper) TableName() string {
+	return proposalsTableName
+}
+
+func (proposalMapper) Columns() []string {
+	return getProposalColumnNames()
+}
+
+func (proposalMapper) Values(pair *Pair) []any {
+	return []any{
+		pair.Id,
+		pair.FirstTaleId,
+		pair.SecondTaleId,
+		pair.Score,
+	}
+}
+
+func (proposalMapper) Scan(scanner data.Scanner) (*Pair, error) {
+	pair := Pair{}
+	err := scanner.Scan(
+		&pair.Id,
+		&pair.FirstTaleId,
+		&pair.SecondTaleId,
+		&pair.Score,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pair, nil
+}
+
+func (proposalMapper) GetId(pair *Pair) int {
+	return pair.Id
+}
+
+func (proposalMapper) SetId(pair *Pair, id int) {
+	pair.Id = id
+}
+
+// linkMapper reads the similar tales of is_similar as pairs, the manual
+// links having no score.
+type linkMapper struct{}
+
+func getLinkColumnNames() []string {
+	return []string{
+		"rowid",
+		"first_tale_id",
+		"second_tale_id",
+		"score",
+	}
+}
+
+func (linkMapper) TableName() string {
+	return linksTableName
+}
+
+func (linkMapper) Columns() []string {
+	return getLinkColumnNames()
+}
+
+func (linkMapper) Values(pair *Pair) []any {
+	return []any{
+		pair.Id,
+		pair.FirstTaleId,
+		pair.SecondTaleId,
+		pair.Score,
+	}
+}
+
+func (linkMapper) Scan(scanner data.Scanner) (*Pair, error) {
+	pair := Pair{}
+	var score sql.NullFloat64
+	err := scanner.Scan(
+		&pair.Id,
+		&pair.FirstTaleId,
+		&pair.SecondTaleId,
+		&score,
+	)
+	if err != nil {
+		return nil, err
+	}
+	pair.Score = score.Float64
+	return &pair, nil
+}
+
+func (linkMapper) GetId(pair *Pair) int {
+	return pair.Id
+}
+
+func (linkMapper) SetId(pair *Pair, id int) {
+	pair.Id = id
+}
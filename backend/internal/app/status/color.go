@@ -0,0 +1,148 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidColor is returned for colors that can't be parsed.
+var ErrInvalidColor = errors.New("invalid color")
+
+var (
+	BLACK = Color{0, 0, 0}
+	WHITE = Color{255, 255, 255}
+)
+
+// Color is an sRGB color.
+type Color struct {
+	R uint8
+	G uint8
+	B uint8
+}
+
+// ParseColor reads a color written as hex (#RGB, #RRGGBB, with or without
+// the #), a CSS color name or hsl(h, s%, l%).
+func ParseColor(value string) (Color, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if color, ok := namedColors[normalized]; ok {
+		return color, nil
+	}
+	if strings.HasPrefix(normalized, "hsl(") && strings.HasSuffix(normalized, ")") {
+		color, err := parseHSL(strings.TrimSuffix(strings.TrimPrefix(normalized, "hsl("), ")"))
+		if err != nil {
+			return Color{}, fmt.Errorf("%w %q: %v", ErrInvalidColor, value, err)
+		}
+		return color, nil
+	}
+	color, err := parseHex(strings.TrimPrefix(normalized, "#"))
+	if err != nil {
+		return Color{}, fmt.Errorf("%w %q: %v", ErrInvalidColor, value, err)
+	}
+	return color, nil
+}
+
+func parseHex(digits string) (Color, error) {
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	if len(digits) != 6 {
+		return Color{}, errors.New("expected #RGB or #RRGGBB")
+	}
+	rgb, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return Color{}, errors.New("expected hex digits")
+	}
+	return Color{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb)}, nil
+}
+
+// parseHSL reads "h, s%, l%", the space separated syntax of CSS being
+// accepted too.
+func parseHSL(arguments string) (Color, error) {
+	fields := strings.FieldsFunc(arguments, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(fields) != 3 {
+		return Color{}, errors.New("expected hsl(h, s%, l%)")
+	}
+	hue, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "deg"), 64)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid hue %q", fields[0])
+	}
+	saturation, err := parsePercentage(fields[1])
+	if err != nil {
+		return Color{}, err
+	}
+	lightness, err := parsePercentage(fields[2])
+	if err != nil {
+		return Color{}, err
+	}
+	return HSL(hue, saturation, lightness), nil
+}
+
+func parsePercentage(field string) (float64, error) {
+	if !strings.HasSuffix(field, "%") {
+		return 0, fmt.Errorf("expected a percentage, got %q", field)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+	if err != nil || value < 0 || value > 100 {
+		return 0, fmt.Errorf("expected a percentage between 0%% and 100%%, got %q", field)
+	}
+	return value / 100, nil
+}
+
+// HSL returns the color of hue in degrees and saturation and lightness
+// between 0 and 1.
+func HSL(hue, saturation, lightness float64) Color {
+	hue = math.Mod(math.Mod(hue, 360)+360, 360) / 360
+	channel := func(n float64) uint8 {
+		k := math.Mod(n+hue*12, 12)
+		a := saturation * math.Min(lightness, 1-lightness)
+		value := lightness - a*math.Max(-1, math.Min(math.Min(k-3, 9-k), 1))
+		return uint8(math.Round(value * 255))
+	}
+	return Color{channel(0), channel(8), channel(4)}
+}
+
+// Hex returns the lower case hex digits of the color, e.g. "1e90ff".
+func (color Color) Hex() string {
+	return fmt.Sprintf("%02x%02x%02x", color.R, color.G, color.B)
+}
+
+// String returns the CSS form of the color.
+func (color Color) String() string {
+	return "#" + color.Hex()
+}
+
+// Luminance is the relative luminance of the color, as defined by WCAG.
+func (color Color) Luminance() float64 {
+	linear := func(channel uint8) float64 {
+		value := float64(channel) / 255
+		if value <= 0.04045 {
+			return value / 12.92
+		}
+		return math.Pow((value+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(color.R) + 0.7152*linear(color.G) + 0.0722*linear(color.B)
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colors, from 1
+// to 21.
+func (color Color) ContrastRatio(other Color) float64 {
+	lighter, darker := color.Luminance(), other.Luminance()
+	if lighter < darker {
+		lighter, darker = darker, lighter
+	}
+	return (lighter + 0.05) / (darker + 0.05)
+}
+
+// TextColor returns black or white, whichever is the most readable on the
+// color.
+func (color Color) TextColor() Color {
+	if color.ContrastRatio(BLACK) >= color.ContrastRatio(WHITE) {
+		return BLACK
+	}
+	return WHITE
+}
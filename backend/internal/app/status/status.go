@@ -1,29 +1,60 @@
 package status
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Status struct {
 	Id    int
 	Name  string
-	color string
+	color Color
+	// colorText is the color as it was set, stored as is.
+	colorText string
+	// Position orders the statuses in the workflow.
+	Position int
+	// Terminal statuses can't be left once reached.
+	Terminal bool
 }
 
 func GetDefault() Status {
 	return Status{
-		Id:    1,
-		Name:  "New",
-		color: "008000",
+		Id:       1,
+		Name:     "New",
+		color:    Color{0x00, 0x80, 0x00},
+		Position: 1,
 	}
 }
 
-func (status Status) GetColor() string {
+func (status Status) GetColor() Color {
 	return status.color
 }
 
-func (status Status) SetColor(color string) {
+// SetColor parses the color, see ParseColor. It's saved by
+// Repository.Update.
+func (status *Status) SetColor(color string) error {
+	parsed, err := ParseColor(color)
+	if err != nil {
+		return err
+	}
+	status.color, status.colorText = parsed, strings.TrimSpace(color)
+	return nil
+}
+
+// storedColor returns the color as it was set, or in hex when it wasn't
+// set by SetColor.
+func (status Status) storedColor() string {
+	if status.colorText == "" {
+		return status.color.String()
+	}
+	return status.colorText
+}
 
+// TextColor returns the color of the text shown on the status color.
+func (status Status) TextColor() Color {
+	return status.color.TextColor()
 }
 
 func (status Status) String() string {
-	return fmt.Sprintf("status: %s, %s", status.Name, status.color)
+	return fmt.Sprintf("status: %s, %s", status.Name, status.color.Hex())
 }
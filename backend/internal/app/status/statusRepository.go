@@ -8,6 +8,9 @@ import (
 )
 
 const tableName = "status"
+const transitionsTableName = "status_transitions"
+const SET_POSITION_STATEMENT = "SET_POSITION"
+const DELETE_BY_STATUSES_STATEMENT = "DELETE_BY_STATUSES"
 
 type Repository interface {
 	Create(s *Status) (int, error)
@@ -15,58 +18,55 @@ type Repository interface {
 	ReadAll() ([]Status, error)
 	Update(s Status) error
 	Delete(id int) error
+	// ReadWorkflow returns the statuses with the transitions between them.
+	ReadWorkflow() (*Workflow, error)
+	AddTransition(fromId, toId int) (int, error)
+	RemoveTransition(fromId, toId int) error
+	// Reorder sets the workflow order of the statuses to the one of ids.
+	Reorder(ids []int) error
 	Close() error
 }
 
 type statusRepository struct {
-	dbConn     *data.DatabaseConnector
-	statements map[string]*sql.Stmt
+	dbConn      *data.DatabaseConnector
+	entities    *data.Repository[Status]
+	transitions *data.Repository[Transition]
 }
 
-func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
-	repo := &statusRepository{
-		dbConn: dbConn,
-	}
+type statusMapper struct{}
 
-	// Prepare statements only once (better perf + safety)
-	createStmt, err := dbConn.PrepareQuery(
-		data.CreateQuery(tableName, getColumnNames()))
-	if err != nil {
-		return nil, err
-	}
-	repo.statements = make(map[string]*sql.Stmt)
-	repo.statements[data.CREATE_STATEMENT] = createStmt
+type transitionMapper struct{}
 
-	readByIdStmt, err := dbConn.PrepareQuery(
-		data.ReadByIdQuery(tableName, getColumnNames()))
+func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
+	entities, err := data.NewRepository[Status](dbConn, statusMapper{})
 	if err != nil {
 		return nil, err
 	}
-	repo.statements[data.READ_STATEMENT] = readByIdStmt
-
-	readAllStmt, err := dbConn.PrepareQuery(
-		data.ReadAllQuery(tableName, getColumnNames()))
+	err = entities.Prepare(SET_POSITION_STATEMENT,
+		data.UpdateColumnsQuery(tableName, []string{"position"}))
 	if err != nil {
+		entities.Close()
 		return nil, err
 	}
-	repo.statements[data.READ_ALL_STATEMENT] = readAllStmt
 
-	updateStmt, err := dbConn.PrepareQuery(
-		data.UpdateQuery(tableName, getColumnNames()))
+	transitions, err := data.NewRepository[Transition](dbConn, transitionMapper{})
 	if err != nil {
+		entities.Close()
 		return nil, err
 	}
-	repo.statements[data.UPDATE_STATEMENT] = updateStmt
-
-	deleteStmt, err := dbConn.PrepareQuery(
-		data.DeleteQuery(tableName))
+	err = transitions.Prepare(DELETE_BY_STATUSES_STATEMENT,
+		data.DeleteByColumnsQuery(transitionsTableName, []string{"from_status_id", "to_status_id"}))
 	if err != nil {
+		entities.Close()
+		transitions.Close()
 		return nil, err
 	}
 
-	repo.statements[data.DELETE_STATEMENT] = deleteStmt
-
-	return repo, nil
+	return &statusRepository{
+		dbConn:      dbConn,
+		entities:    entities,
+		transitions: transitions,
+	}, nil
 }
 
 func getColumnNames() []string {
@@ -74,96 +74,174 @@ func getColumnNames() []string {
 		"id",
 		"name",
 		"color",
+		"position",
+		"terminal",
 	}
 }
 
-func (repo statusRepository) Create(status *Status) (int, error) {
-	result, err := repo.statements[data.CREATE_STATEMENT].Exec(
-		nil,
+func (statusMapper) TableName() string {
+	return tableName
+}
+
+func (statusMapper) Columns() []string {
+	return getColumnNames()
+}
+
+func (statusMapper) Values(status *Status) []any {
+	return []any{
+		status.Id,
 		status.Name,
-		status.color,
+		status.storedColor(),
+		status.Position,
+		status.Terminal,
+	}
+}
+
+func (statusMapper) Scan(scanner data.Scanner) (*Status, error) {
+	status := Status{}
+	var color string
+	err := scanner.Scan(
+		&status.Id,
+		&status.Name,
+		&color,
+		&status.Position,
+		&status.Terminal,
 	)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if err := status.SetColor(color); err != nil {
+		return nil, fmt.Errorf("status %d: %w", status.Id, err)
 	}
+	return &status, nil
+}
 
-	id, err := result.LastInsertId()
-	status.Id = int(id)
-	return status.Id, err
+func (statusMapper) GetId(status *Status) int {
+	return status.Id
 }
 
-func (repo statusRepository) ReadById(id int) (*Status, error) {
-	rows, err := repo.statements[data.READ_STATEMENT].Query(id)
-	defer rows.Close()
+func (statusMapper) SetId(status *Status, id int) {
+	status.Id = id
+}
+
+func (transitionMapper) TableName() string {
+	return transitionsTableName
+}
+
+func (transitionMapper) Columns() []string {
+	return []string{
+		"id",
+		"from_status_id",
+		"to_status_id",
+	}
+}
+
+func (transitionMapper) Values(transition *Transition) []any {
+	return []any{
+		transition.Id,
+		transition.FromStatusId,
+		transition.ToStatusId,
+	}
+}
+
+func (transitionMapper) Scan(scanner data.Scanner) (*Transition, error) {
+	transition := Transition{}
+	err := scanner.Scan(
+		&transition.Id,
+		&transition.FromStatusId,
+		&transition.ToStatusId,
+	)
 	if err != nil {
-		return &Status{}, err
-	}
-	if rows.Next() {
-		status := Status{}
-		err := rows.Scan(
-			&status.Id,
-			&status.Name,
-			&status.color,
-		)
-		if err != nil {
-			return &Status{}, err
-		}
-		return &status, nil
+		return nil, err
 	}
-	return &Status{}, errors.New(fmt.Sprintf("Error on retrieving the row: %v", err))
+	return &transition, nil
+}
+
+func (transitionMapper) GetId(transition *Transition) int {
+	return transition.Id
+}
+
+func (transitionMapper) SetId(transition *Transition, id int) {
+	transition.Id = id
+}
+
+func (repo statusRepository) Create(status *Status) (int, error) {
+	return repo.entities.Create(status)
+}
+
+func (repo statusRepository) ReadById(id int) (*Status, error) {
+	return repo.entities.ReadById(id)
 }
 
 func (repo statusRepository) ReadAll() ([]Status, error) {
-	rows, err := repo.statements[data.READ_ALL_STATEMENT].Query()
-	defer rows.Close()
+	collection, err := repo.entities.ReadAll()
 	if err != nil {
 		return []Status{}, err
 	}
-	var statusCollection []Status
-	for rows.Next() {
-		status := Status{}
-		err := rows.Scan(
-			&status.Id,
-			&status.Name,
-			&status.color,
-		)
-		if err != nil {
-			return []Status{}, err
-		}
-		statusCollection = append(statusCollection, status)
+	statusCollection := make([]Status, 0, len(collection))
+	for _, status := range collection {
+		statusCollection = append(statusCollection, *status)
 	}
 	return statusCollection, nil
 }
 
 func (repo statusRepository) Update(status Status) error {
-	result, err := repo.statements[data.UPDATE_STATEMENT].Exec(
-		status.Id,
-		status.Name,
-		status.color,
-		status.Id,
-	)
+	return repo.entities.Update(&status)
+}
+
+func (repo statusRepository) Delete(id int) error {
+	return repo.entities.Delete(id)
+}
+
+func (repo statusRepository) ReadWorkflow() (*Workflow, error) {
+	statuses, err := repo.ReadAll()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	collection, err := repo.transitions.ReadAll()
+	if err != nil {
+		return nil, err
 	}
-	if nRows, err := result.RowsAffected(); nRows != 1 || err != nil {
-		return errors.New("The rows affected are different than 1")
+	transitions := make([]Transition, 0, len(collection))
+	for _, transition := range collection {
+		transitions = append(transitions, *transition)
 	}
-	return nil
+	return NewWorkflow(statuses, transitions), nil
 }
 
-func (repo statusRepository) Delete(id int) error {
-	_, err := repo.statements[data.DELETE_STATEMENT].Exec(id)
-	return err
+func (repo statusRepository) AddTransition(fromId, toId int) (int, error) {
+	if fromId == toId {
+		return 0, fmt.Errorf("%w: a status can't move to itself", ErrTransitionNotAllowed)
+	}
+	from, err := repo.ReadById(fromId)
+	if err != nil {
+		return 0, err
+	}
+	if from.Terminal {
+		return 0, fmt.Errorf("%w: %s can't be left", ErrTerminalStatus, from.Name)
+	}
+	return repo.transitions.Create(&Transition{
+		FromStatusId: fromId,
+		ToStatusId:   toId,
+	})
 }
 
-func (repo statusRepository) Close() error {
-	var errs error
-	for _, statement := range repo.statements {
-		if statement != nil {
-			if currentErr := statement.Close(); currentErr != nil {
-				errs = errors.Join(currentErr)
+func (repo statusRepository) RemoveTransition(fromId, toId int) error {
+	return repo.transitions.Exec(DELETE_BY_STATUSES_STATEMENT, fromId, toId)
+}
+
+func (repo statusRepository) Reorder(ids []int) error {
+	return repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		entities := repo.entities.WithTx(tx)
+		for i, id := range ids {
+			if err := entities.Exec(SET_POSITION_STATEMENT, i+1, id); err != nil {
+				return err
 			}
 		}
-	}
-	return errs
+		return nil
+	})
+}
+
+func (repo statusRepository) Close() error {
+	return errors.Join(repo.entities.Close(), repo.transitions.Close())
 }
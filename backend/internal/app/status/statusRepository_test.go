@@ -0,0 +1,55 @@
+package status
+
+import (
+	"errors"
+	"talenest/backend/internal/data/datatest"
+	"testing"
+)
+
+func TestStatusColorIsStoredAsSet(t *testing.T) {
+	dbConn := datatest.Open(t)
+	repo, err := NewRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+
+	status := &Status{Name: "Drafting", Position: 9}
+	if err := status.SetColor(" DodgerBlue "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Create(status); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := dbConn.Query("SELECT color FROM status WHERE id = ?;", []any{status.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored string
+	for rows.Next() {
+		rows.Scan(&stored)
+	}
+	rows.Close()
+	if stored != "DodgerBlue" {
+		t.Errorf("the color is stored as %q, want it as set", stored)
+	}
+	read, err := repo.ReadById(status.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.GetColor() != (Color{0x1e, 0x90, 0xff}) {
+		t.Errorf("the color is read as %v", read.GetColor())
+	}
+
+	// the built-in statuses were normalized by the migrations
+	if _, err := repo.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dbConn.ExecuteQuery("UPDATE status SET color = 'plaid' WHERE id = ?;", []any{status.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ReadById(status.Id); !errors.Is(err, ErrInvalidColor) {
+		t.Errorf("reading an invalid color = %v, want ErrInvalidColor", err)
+	}
+}
@@ -0,0 +1,96 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrTransitionNotAllowed is returned when a tale is moved between two
+	// statuses not linked by a transition.
+	ErrTransitionNotAllowed = errors.New("status transition not allowed")
+	// ErrTerminalStatus is returned when a tale is moved out of a terminal
+	// status.
+	ErrTerminalStatus = errors.New("terminal status")
+	// ErrUnknownStatus is returned for statuses missing from the workflow.
+	ErrUnknownStatus = errors.New("unknown status")
+)
+
+// Transition allows tales to move from a status to another.
+type Transition struct {
+	Id           int
+	FromStatusId int
+	ToStatusId   int
+}
+
+// Workflow holds the statuses and the transitions allowed between them.
+type Workflow struct {
+	statuses    []Status
+	transitions map[int][]int
+}
+
+func NewWorkflow(statuses []Status, transitions []Transition) *Workflow {
+	workflow := &Workflow{
+		statuses:    slices.Clone(statuses),
+		transitions: map[int][]int{},
+	}
+	slices.SortStableFunc(workflow.statuses, func(a, b Status) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+		return a.Id - b.Id
+	})
+	for _, transition := range transitions {
+		workflow.transitions[transition.FromStatusId] = append(
+			workflow.transitions[transition.FromStatusId], transition.ToStatusId)
+	}
+	return workflow
+}
+
+// Statuses returns the statuses in workflow order.
+func (workflow *Workflow) Statuses() []Status {
+	return slices.Clone(workflow.statuses)
+}
+
+func (workflow *Workflow) Status(id int) (Status, error) {
+	index := slices.IndexFunc(workflow.statuses, func(status Status) bool {
+		return status.Id == id
+	})
+	if index < 0 {
+		return Status{}, fmt.Errorf("%w %d", ErrUnknownStatus, id)
+	}
+	return workflow.statuses[index], nil
+}
+
+// Next returns the statuses a tale can move to from the status fromId, in
+// workflow order.
+func (workflow *Workflow) Next(fromId int) []Status {
+	next := []Status{}
+	for _, status := range workflow.statuses {
+		if slices.Contains(workflow.transitions[fromId], status.Id) {
+			next = append(next, status)
+		}
+	}
+	return next
+}
+
+// CheckTransition returns an error if a tale can't move from the status
+// fromId to the status toId.
+func (workflow *Workflow) CheckTransition(fromId, toId int) error {
+	from, err := workflow.Status(fromId)
+	if err != nil {
+		return err
+	}
+	to, err := workflow.Status(toId)
+	if err != nil {
+		return err
+	}
+	if from.Terminal {
+		return fmt.Errorf("%w: %s can't be left", ErrTerminalStatus, from.Name)
+	}
+	if !slices.Contains(workflow.transitions[fromId], toId) {
+		return fmt.Errorf("%w: %s to %s", ErrTransitionNotAllowed, from.Name, to.Name)
+	}
+	return nil
+}
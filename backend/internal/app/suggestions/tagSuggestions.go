@@ -0,0 +1,132 @@
+package suggestions
+
+import (
+	"sort"
+	"talenest/backend/internal/app/chapter"
+	"talenest/backend/internal/app/tags"
+	"talenest/backend/internal/text"
+)
+
+// TagSuggestion is a tag whose name, or one of its aliases, is a keyword of
+// a tale.
+type TagSuggestion struct {
+	Tag tags.Tag
+	// Score is the mean TF-IDF weight of the words of the matched name.
+	Score float64
+	// Match is the tag name or alias found in the tale.
+	Match    string
+	Keywords []string
+}
+
+// Suggester suggests tags from the content of the chapters, the whole
+// library being the corpus the keywords are weighed against.
+type Suggester struct {
+	chapters chapter.Repository
+	tags     tags.Repository
+}
+
+func NewSuggester(chapters chapter.Repository, tags tags.Repository) *Suggester {
+	return &Suggester{
+		chapters: chapters,
+		tags:     tags,
+	}
+}
+
+// SuggestTags returns at most limit tags for the tale, the best first,
+// leaving out the ones it already has.
+func (suggester *Suggester) SuggestTags(taleId int, limit int) ([]TagSuggestion, error) {
+	corpus, err := suggester.corpus()
+	if err != nil {
+		return []TagSuggestion{}, err
+	}
+	weights := corpus.Weights(taleId)
+	if len(weights) == 0 {
+		return []TagSuggestion{}, nil
+	}
+
+	tree, err := suggester.tags.ReadTree()
+	if err != nil {
+		return []TagSuggestion{}, err
+	}
+	aliases, err := suggester.tags.ReadAllAliases()
+	if err != nil {
+		return []TagSuggestion{}, err
+	}
+	linked, err := suggester.tags.ReadByTale(taleId)
+	if err != nil {
+		return []TagSuggestion{}, err
+	}
+	names := map[int][]string{}
+	for _, tag := range tree.Tags() {
+		names[tag.Id] = []string{tag.Name}
+	}
+	for _, alias := range aliases {
+		names[alias.TagId] = append(names[alias.TagId], alias.Name)
+	}
+	for _, tag := range linked {
+		delete(names, tag.Id)
+	}
+
+	suggestions := []TagSuggestion{}
+	for tagId, tagNames := range names {
+		best := TagSuggestion{}
+		for _, name := range tagNames {
+			if score, keywords := match(weights, name); score > best.Score {
+				best = TagSuggestion{Score: score, Match: name, Keywords: keywords}
+			}
+		}
+		if best.Score > 0 {
+			best.Tag, _ = tree.Tag(tagId)
+			suggestions = append(suggestions, best)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Tag.Path < suggestions[j].Tag.Path
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// Accept tags the tale with a suggested tag.
+func (suggester *Suggester) Accept(taleId, tagId int) error {
+	return suggester.tags.Link(taleId, tagId)
+}
+
+// corpus indexes the chapters of each tale as one document.
+func (suggester *Suggester) corpus() (*text.Corpus, error) {
+	chapters, err := suggester.chapters.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	terms := map[int][]string{}
+	for chapter := range chapters.ChaptersStream() {
+		terms[chapter.TaleId] = append(terms[chapter.TaleId], text.Terms(chapter.Content)...)
+	}
+	corpus := text.NewCorpus()
+	for taleId, taleTerms := range terms {
+		corpus.Add(taleId, taleTerms)
+	}
+	return corpus, nil
+}
+
+// match scores a name whose words are all terms of the tale.
+func match(weights map[string]float64, name string) (float64, []string) {
+	terms := text.Terms(name)
+	if len(terms) == 0 {
+		return 0, nil
+	}
+	score := 0.0
+	for _, term := range terms {
+		weight, ok := weights[term]
+		if !ok {
+			return 0, nil
+		}
+		score += weight
+	}
+	return score / float64(len(terms)), terms
+}
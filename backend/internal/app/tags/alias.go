@@ -0,0 +1,59 @@
+package tags
+
+import "talenest/backend/internal/data"
+
+const aliasTableName = "tag_alias"
+
+// Alias is another name resolving to a tag, compared ignoring case.
+type Alias struct {
+	Id    int
+	TagId int
+	Name  string
+}
+
+type aliasMapper struct{}
+
+func getAliasColumnNames() []string {
+	return []string{
+		"id",
+		"tag_id",
+		"alias",
+	}
+}
+
+func (aliasMapper) TableName() string {
+	return aliasTableName
+}
+
+func (aliasMapper) Columns() []string {
+	return getAliasColumnNames()
+}
+
+func (aliasMapper) Values(alias *Alias) []any {
+	return []any{
+		alias.Id,
+		alias.TagId,
+		alias.Name,
+	}
+}
+
+func (aliasMapper) Scan(scanner data.Scanner) (*Alias, error) {
+	alias := Alias{}
+	err := scanner.Scan(
+		&alias.Id,
+		&alias.TagId,
+		&alias.Name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+func (aliasMapper) GetId(alias *Alias) int {
+	return alias.Id
+}
+
+func (aliasMapper) SetId(alias *Alias, id int) {
+	alias.Id = id
+}
@@ -1,12 +1,54 @@
 package tags
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PATH_SEPARATOR separates the names of nested tags, e.g. genre/sci-fi.
+const PATH_SEPARATOR = "/"
+
+var (
+	// ErrInvalidName is returned for empty tag names or names holding the
+	// path separator.
+	ErrInvalidName = errors.New("invalid tag name")
+	// ErrTagCycle is returned when a tag would end up nested in itself.
+	ErrTagCycle = errors.New("a tag can't be nested in itself")
+)
 
 type Tag struct {
 	Id   int
 	Name string
+	// ParentId is 0 for top level tags.
+	ParentId int
+	// Path is the name of the tag prefixed by the ones of its parents. It's
+	// set when the tag is read.
+	Path string
 }
 
 func (tag Tag) String() string {
-	return fmt.Sprintf("Tag %d: %s", tag.Id, tag.Name)
+	return fmt.Sprintf("Tag %d: %s", tag.Id, tag.Path)
+}
+
+func checkName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: the name is empty", ErrInvalidName)
+	}
+	if strings.Contains(name, PATH_SEPARATOR) {
+		return fmt.Errorf("%w %q: %s separates nested tags", ErrInvalidName, name, PATH_SEPARATOR)
+	}
+	return nil
+}
+
+// SplitPath returns the names of a path, trimmed.
+func SplitPath(path string) ([]string, error) {
+	names := strings.Split(path, PATH_SEPARATOR)
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		if names[i] == "" {
+			return nil, fmt.Errorf("%w %q: empty name in the path", ErrInvalidName, path)
+		}
+	}
+	return names, nil
 }
@@ -4,161 +4,486 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"talenest/backend/internal/data"
 )
 
 const tableName = "tag"
+const taleTagTableName = "tale_tag"
+
+const (
+	READ_BY_ALIAS_STATEMENT     = "READ_BY_ALIAS"
+	READ_BY_TAG_STATEMENT       = "READ_BY_TAG"
+	DELETE_BY_ALIAS_STATEMENT   = "DELETE_BY_ALIAS"
+	RELINK_TALES_STATEMENT      = "RELINK_TALES"
+	UNLINK_TALES_STATEMENT      = "UNLINK_TALES"
+	SET_PARENT_STATEMENT        = "SET_PARENT"
+	MOVE_ALIASES_STATEMENT      = "MOVE_ALIASES"
+	ADD_ALIAS_IF_FREE_STATEMENT = "ADD_ALIAS_IF_FREE"
+	READ_BY_TALE_STATEMENT      = "READ_BY_TALE"
+	LINK_STATEMENT              = "LINK"
+	UNLINK_STATEMENT            = "UNLINK"
+)
 
 type Repository interface {
 	Create(tag *Tag) (int, error)
+	// CreatePath returns the tag of path, creating it and its missing
+	// parents.
+	CreatePath(path string) (*Tag, error)
 	ReadById(id int) (*Tag, error)
+	// ReadAll returns the tags, each parent before its children.
 	ReadAll() ([]Tag, error)
+	ReadTree() (*Tree, error)
+	// Resolve returns the tag of a path or of an alias.
+	Resolve(name string) (*Tag, error)
 	Update(tag Tag) error
 	Delete(id int) error
+	AddAlias(tagId int, alias string) (int, error)
+	RemoveAlias(alias string) error
+	ReadAliases(tagId int) ([]Alias, error)
+	ReadAllAliases() ([]Alias, error)
+	// ReadByTale returns the tags of a tale.
+	ReadByTale(taleId int) ([]Tag, error)
+	// Link tags a tale, linking it twice isn't an error.
+	Link(taleId, tagId int) error
+	Unlink(taleId, tagId int) error
+	// Merge moves the tales, aliases and children of the sources to the
+	// target and deletes the sources, whose paths become aliases of the
+	// target. Children named like a child of the target are merged into it.
+	// Nothing changes if any of it fails.
+	Merge(sourceIds []int, targetId int) error
+	// ReadUsage returns the tags with the number of tales using them, the
+	// most used first.
+	ReadUsage() ([]TagUsage, error)
+	// ReadUnused returns the tags no tale uses, directly or through their
+	// children.
+	ReadUnused() ([]Tag, error)
+	// PruneUnused deletes the tags returned by ReadUnused.
+	PruneUnused() ([]Tag, error)
+	ReadGraph() (*Graph, error)
 	Close() error
 }
 
 type tagRepository struct {
-	dbConn     *data.DatabaseConnector
-	statements map[string]*sql.Stmt
+	dbConn   *data.DatabaseConnector
+	entities *data.Repository[Tag]
+	aliases  *data.Repository[Alias]
 }
 
+type tagMapper struct{}
+
 func getColumnNames() []string {
 	return []string{
 		"id",
 		"name",
+		"parent_id",
 	}
 }
 
 func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
+	entities, err := data.NewRepository[Tag](dbConn, tagMapper{})
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := data.NewRepository[Alias](dbConn, aliasMapper{})
+	if err != nil {
+		entities.Close()
+		return nil, err
+	}
 	repo := &tagRepository{
-		dbConn: dbConn,
+		dbConn:   dbConn,
+		entities: entities,
+		aliases:  aliases,
 	}
 
-	var err error
+	tagQueries := map[string]string{
+		RELINK_TALES_STATEMENT: relinkTalesQuery(),
+		UNLINK_TALES_STATEMENT: data.DeleteByColumnsQuery(taleTagTableName, []string{"tag_id"}),
+		SET_PARENT_STATEMENT:   data.UpdateColumnsQuery(tableName, []string{"parent_id"}),
+		READ_BY_TALE_STATEMENT: readByTaleQuery(),
+		LINK_STATEMENT:         data.CreateIgnoreQuery(taleTagTableName, []string{"tale_id", "tag_id"}),
+		UNLINK_STATEMENT:       data.DeleteByColumnsQuery(taleTagTableName, []string{"tale_id", "tag_id"}),
+	}
+	for name, query := range tagQueries {
+		if err := entities.Prepare(name, query); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+	aliasQueries := map[string]string{
+		MOVE_ALIASES_STATEMENT:      data.UpdateByColumnQuery(aliasTableName, []string{"tag_id"}, "tag_id"),
+		ADD_ALIAS_IF_FREE_STATEMENT: data.CreateIgnoreQuery(aliasTableName, []string{"tag_id", "alias"}),
+		READ_BY_ALIAS_STATEMENT:     data.ReadByColumnQuery(aliasTableName, getAliasColumnNames(), "alias"),
+		READ_BY_TAG_STATEMENT:       data.ReadByColumnQuery(aliasTableName, getAliasColumnNames(), "tag_id"),
+		DELETE_BY_ALIAS_STATEMENT:   data.DeleteByColumnsQuery(aliasTableName, []string{"alias"}),
+	}
+	for name, query := range aliasQueries {
+		if err := aliases.Prepare(name, query); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+// relinkTalesQuery links the tag of the first placeholder to the tales of
+// the tag of the second one, the tales having both keeping a single link.
+func relinkTalesQuery() string {
+	taleId, _ := data.NewColumn("tale_id", "")
+	tagId, _ := data.NewColumn("tag_id", "")
+	newTagId, _ := data.NewColumn("?", "")
+	selection := data.NewSelectQueryBuilder(taleTagTableName)
+	selection.SetColumns([]data.Column{*taleId, *newTagId})
+	selection.SetWhere(taleTagTableName, *tagId, "=", data.NewTokenValue("?"), "")
+	builder := data.NewInsertQueryBuilder(taleTagTableName)
+	builder.SetIgnore()
+	builder.SetColumns([]data.Column{*taleId, *tagId})
+	builder.SetSelect(selection)
+	query, _, _ := builder.Build()
+	return query
+}
 
-	createStmt, err := dbConn.PrepareQuery(
-		data.CreateQuery(tableName, getColumnNames()))
+// readByTaleQuery reads the tags linked to a tale.
+func readByTaleQuery() string {
+	id, _ := data.NewColumn("id", "")
+	taleId, _ := data.NewColumn("tale_id", "")
+	tagId, _ := data.NewColumn("tag_id", "")
+	columns := []data.Column{}
+	for _, name := range getColumnNames() {
+		column, _ := data.NewColumn(tableName+"."+name, "")
+		columns = append(columns, *column)
+	}
+	builder := data.NewSelectQueryBuilder(tableName)
+	builder.SetColumns(columns)
+	builder.SetJoin(tableName, *id, taleTagTableName, *tagId, "")
+	builder.SetWhere(taleTagTableName, *taleId, "=", data.NewTokenValue("?"), "")
+	query, _ := builder.Build()
+	return query
+}
+
+func (tagMapper) TableName() string {
+	return tableName
+}
+
+func (tagMapper) Columns() []string {
+	return getColumnNames()
+}
+
+func (tagMapper) Values(tag *Tag) []any {
+	var parentId any
+	if tag.ParentId != 0 {
+		parentId = tag.ParentId
+	}
+	return []any{
+		tag.Id,
+		tag.Name,
+		parentId,
+	}
+}
+
+func (tagMapper) Scan(scanner data.Scanner) (*Tag, error) {
+	tag := Tag{}
+	var parentId sql.NullInt64
+	err := scanner.Scan(
+		&tag.Id,
+		&tag.Name,
+		&parentId,
+	)
 	if err != nil {
 		return nil, err
 	}
-	repo.statements = make(map[string]*sql.Stmt)
-	repo.statements[data.CREATE_STATEMENT] = createStmt
+	tag.ParentId = int(parentId.Int64)
+	tag.Path = tag.Name
+	return &tag, nil
+}
+
+func (tagMapper) GetId(tag *Tag) int {
+	return tag.Id
+}
+
+func (tagMapper) SetId(tag *Tag, id int) {
+	tag.Id = id
+}
+
+func (repo tagRepository) Create(tag *Tag) (int, error) {
+	tag.Name = strings.TrimSpace(tag.Name)
+	if err := checkName(tag.Name); err != nil {
+		return 0, err
+	}
+	id, err := repo.entities.Create(tag)
+	if err != nil {
+		return 0, err
+	}
+	tag.Path = tag.Name
+	if tag.ParentId != 0 {
+		parent, err := repo.ReadById(tag.ParentId)
+		if err != nil {
+			return 0, err
+		}
+		tag.Path = parent.Path + PATH_SEPARATOR + tag.Name
+	}
+	return id, nil
+}
 
-	readByIdStmt, err := dbConn.PrepareQuery(
-		data.ReadByIdQuery(tableName, getColumnNames()))
+func (repo tagRepository) CreatePath(path string) (*Tag, error) {
+	names, err := SplitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := repo.ReadTree()
 	if err != nil {
 		return nil, err
 	}
-	repo.statements[data.READ_STATEMENT] = readByIdStmt
 
-	readAllStmt, err := dbConn.PrepareQuery(
-		data.ReadAllQuery(tableName, getColumnNames()))
+	tag := Tag{}
+	created := []Tag{}
+	err = repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		for _, name := range names {
+			if child, ok := tree.Child(tag.Id, name); ok && len(created) == 0 {
+				tag = child
+				continue
+			}
+			child := Tag{Name: name, ParentId: tag.Id, Path: name}
+			if tag.Id != 0 {
+				child.Path = tag.Path + PATH_SEPARATOR + name
+			}
+			if _, err := repo.entities.WithTx(tx).Create(&child); err != nil {
+				return err
+			}
+			created = append(created, child)
+			tag = child
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	repo.statements[data.READ_ALL_STATEMENT] = readAllStmt
+	return &tag, nil
+}
 
-	updateStmt, err := dbConn.PrepareQuery(
-		data.UpdateQuery(tableName, getColumnNames()))
+func (repo tagRepository) ReadById(id int) (*Tag, error) {
+	tree, err := repo.ReadTree()
 	if err != nil {
 		return nil, err
 	}
-	repo.statements[data.UPDATE_STATEMENT] = updateStmt
+	tag, ok := tree.Tag(id)
+	if !ok {
+		return nil, &data.OpError{Op: fmt.Sprintf("read %d from", id), Table: tableName, Err: data.ErrNotFound}
+	}
+	return &tag, nil
+}
+
+func (repo tagRepository) ReadAll() ([]Tag, error) {
+	tree, err := repo.ReadTree()
+	if err != nil {
+		return []Tag{}, err
+	}
+	return tree.Tags(), nil
+}
 
-	deleteStmt, err := dbConn.PrepareQuery(
-		data.DeleteQuery(tableName))
+func (repo tagRepository) ReadTree() (*Tree, error) {
+	collection, err := repo.entities.ReadAll()
 	if err != nil {
 		return nil, err
 	}
+	return newTree(collection), nil
+}
 
-	repo.statements[data.DELETE_STATEMENT] = deleteStmt
+func (repo tagRepository) Resolve(name string) (*Tag, error) {
+	tree, err := repo.ReadTree()
+	if err != nil {
+		return nil, err
+	}
+	tag, err := tree.Find(name)
+	if err == nil || !errors.Is(err, data.ErrNotFound) {
+		return &tag, err
+	}
 
-	return repo, nil
+	aliases, err := repo.aliases.ReadMany(READ_BY_ALIAS_STATEMENT, strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(aliases) == 0 {
+		return nil, fmt.Errorf("tag %q: %w", name, data.ErrNotFound)
+	}
+	tag, ok := tree.Tag(aliases[0].TagId)
+	if !ok {
+		return nil, fmt.Errorf("tag of the alias %q: %w", name, data.ErrNotFound)
+	}
+	return &tag, nil
 }
 
-func (repo tagRepository) Create(tag *Tag) (int, error) {
-	result, err := repo.statements[data.CREATE_STATEMENT].Exec(
-		nil,
-		tag.Name,
-	)
+func (repo tagRepository) Update(tag Tag) error {
+	tag.Name = strings.TrimSpace(tag.Name)
+	if err := checkName(tag.Name); err != nil {
+		return err
+	}
+	if tag.ParentId != 0 {
+		tree, err := repo.ReadTree()
+		if err != nil {
+			return err
+		}
+		if tree.IsDescendant(tag.ParentId, tag.Id) {
+			return fmt.Errorf("%w: moving %d under %d", ErrTagCycle, tag.Id, tag.ParentId)
+		}
+	}
+	return repo.entities.Update(&tag)
+}
+
+// Delete deletes the tag with its children.
+func (repo tagRepository) Delete(id int) error {
+	return repo.entities.Delete(id)
+}
+
+func (repo tagRepository) AddAlias(tagId int, alias string) (int, error) {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return 0, fmt.Errorf("%w: the alias is empty", ErrInvalidName)
+	}
+	tree, err := repo.ReadTree()
 	if err != nil {
 		return 0, err
 	}
-	id, err := result.LastInsertId()
-	tag.Id = int(id)
-	return tag.Id, err
+	if tag, err := tree.Find(alias); err == nil {
+		return 0, fmt.Errorf("the alias %q is the path of %s: %w", alias, tag, data.ErrConflict)
+	}
+	return repo.aliases.Create(&Alias{TagId: tagId, Name: alias})
 }
 
-func (repo tagRepository) ReadById(id int) (*Tag, error) {
-	rows, err := repo.statements[data.READ_ALL_STATEMENT].Query(id)
-	defer rows.Close()
+func (repo tagRepository) RemoveAlias(alias string) error {
+	return repo.aliases.Exec(DELETE_BY_ALIAS_STATEMENT, strings.TrimSpace(alias))
+}
+
+func (repo tagRepository) ReadAliases(tagId int) ([]Alias, error) {
+	collection, err := repo.aliases.ReadMany(READ_BY_TAG_STATEMENT, tagId)
 	if err != nil {
-		return &Tag{}, err
+		return []Alias{}, err
 	}
-	if rows.Next() {
-		tag := Tag{}
-		err := rows.Scan(
-			&tag.Id,
-			&tag.Name,
-		)
-		if err != nil {
-			return &Tag{}, err
-		}
-		return &tag, nil
+	aliases := make([]Alias, 0, len(collection))
+	for _, alias := range collection {
+		aliases = append(aliases, *alias)
 	}
-	return &Tag{}, errors.New(fmt.Sprintf("Error on retrieving the row: %v", err))
+	return aliases, nil
 }
 
-func (repo tagRepository) ReadAll() ([]Tag, error) {
-	rows, err := repo.statements[data.READ_ALL_STATEMENT].Query()
-	defer rows.Close()
+func (repo tagRepository) ReadAllAliases() ([]Alias, error) {
+	collection, err := repo.aliases.ReadAll()
+	if err != nil {
+		return []Alias{}, err
+	}
+	aliases := make([]Alias, 0, len(collection))
+	for _, alias := range collection {
+		aliases = append(aliases, *alias)
+	}
+	return aliases, nil
+}
+
+func (repo tagRepository) ReadByTale(taleId int) ([]Tag, error) {
+	collection, err := repo.entities.ReadMany(READ_BY_TALE_STATEMENT, taleId)
 	if err != nil {
 		return []Tag{}, err
 	}
-	tags := []Tag{}
-	for rows.Next() {
-		tag := Tag{}
-		err := rows.Scan(
-			&tag.Id,
-			&tag.Name,
-		)
-		if err != nil {
-			return []Tag{}, err
-		}
-		tags = append(tags, tag)
+	tree, err := repo.ReadTree()
+	if err != nil {
+		return []Tag{}, err
+	}
+	tags := make([]Tag, 0, len(collection))
+	for _, tag := range collection {
+		tag.Path = tree.path(tag.Id)
+		tags = append(tags, *tag)
 	}
 	return tags, nil
 }
 
-func (repo tagRepository) Update(tag Tag) error {
-	result, err := repo.statements[data.UPDATE_STATEMENT].Exec(
-		tag.Id,
-		tag.Name,
-		tag.Id,
-	)
+func (repo tagRepository) Link(taleId, tagId int) error {
+	_, err := repo.entities.ExecMany(LINK_STATEMENT, taleId, tagId)
+	return err
+}
+
+func (repo tagRepository) Unlink(taleId, tagId int) error {
+	return repo.entities.Exec(UNLINK_STATEMENT, taleId, tagId)
+}
+
+func (repo tagRepository) Merge(sourceIds []int, targetId int) error {
+	tree, err := repo.ReadTree()
 	if err != nil {
 		return err
 	}
-	if nRows, err := result.RowsAffected(); nRows != 1 || err != nil {
-		return errors.New("The rows affected are different than 1")
+	target, ok := tree.Tag(targetId)
+	if !ok {
+		return fmt.Errorf("merge target %d: %w", targetId, data.ErrNotFound)
+	}
+	sources := []Tag{}
+	for _, sourceId := range sourceIds {
+		source, ok := tree.Tag(sourceId)
+		if !ok {
+			return fmt.Errorf("merge source %d: %w", sourceId, data.ErrNotFound)
+		}
+		if sourceId == targetId {
+			continue
+		}
+		if tree.IsDescendant(targetId, sourceId) {
+			return fmt.Errorf("%w: %s can't be merged into %s", ErrTagCycle, source.Path, target.Path)
+		}
+		sources = append(sources, source)
 	}
-	return nil
-}
 
-func (repo tagRepository) Delete(id int) error {
-	_, err := repo.statements[data.DELETE_STATEMENT].Exec(id)
-	return err
+	return repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		entities := repo.entities.WithTx(tx)
+		aliases := repo.aliases.WithTx(tx)
+		for _, source := range sources {
+			// a source nested in another one may be merged with it already
+			if _, ok := tree.Tag(source.Id); !ok {
+				continue
+			}
+			if err := merge(entities, aliases, tree, source, target); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (repo tagRepository) Close() error {
-	var errs error
-	for _, statement := range repo.statements {
-		if statement != nil {
-			if currentErr := statement.Close(); currentErr != nil {
-				errs = errors.Join(currentErr)
+// merge moves the tales, aliases and children of source to target and
+// deletes source. A child named like a child of target is merged into it
+// the same way, as the names of siblings are unique.
+func merge(entities *data.Repository[Tag], aliases *data.Repository[Alias], tree *Tree, source, target Tag) error {
+	// the children are read again as merging one into another can nest
+	// more of them in source
+	for children := tree.Children(source.Id); len(children) > 0; children = tree.Children(source.Id) {
+		child := children[0]
+		if existing, ok := tree.Child(target.Id, child.Name); ok && existing.Name == child.Name {
+			if err := merge(entities, aliases, tree, child, existing); err != nil {
+				return err
 			}
+			continue
+		}
+		if err := entities.Exec(SET_PARENT_STATEMENT, target.Id, child.Id); err != nil {
+			return fmt.Errorf("moving %s to %s: %w", child.Path, target.Path, err)
+		}
+		tree.move(child.Id, target.Id)
+	}
+	if _, err := entities.ExecMany(RELINK_TALES_STATEMENT, target.Id, source.Id); err != nil {
+		return err
+	}
+	if _, err := entities.ExecMany(UNLINK_TALES_STATEMENT, source.Id); err != nil {
+		return err
+	}
+	if _, err := aliases.ExecMany(MOVE_ALIASES_STATEMENT, target.Id, source.Id); err != nil {
+		return err
+	}
+	if !strings.EqualFold(source.Path, target.Path) {
+		if _, err := aliases.ExecMany(ADD_ALIAS_IF_FREE_STATEMENT, target.Id, source.Path); err != nil {
+			return err
 		}
 	}
-	return errs
+	if err := entities.Delete(source.Id); err != nil {
+		return err
+	}
+	tree.remove(source.Id)
+	return nil
+}
+
+func (repo tagRepository) Close() error {
+	return errors.Join(repo.entities.Close(), repo.aliases.Close())
 }
@@ -0,0 +1,126 @@
+package tags
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func aliasNames(t *testing.T, repo Repository, tagId int) []string {
+	t.Helper()
+	aliases, err := repo.ReadAliases(tagId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, alias := range aliases {
+		names = append(names, alias.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func taleTagPaths(t *testing.T, repo Repository, taleId int) []string {
+	t.Helper()
+	tags, err := repo.ReadByTale(taleId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{}
+	for _, tag := range tags {
+		paths = append(paths, tag.Path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func TestMerge(t *testing.T) {
+	repo, _ := newTestRepository(t, 2)
+	tags := createPaths(t, repo, "scary", "scary/ghosts", "scary/ghosts/haunted houses", "horror", "horror/ghosts", "horror/zombies")
+	scary, scaryGhosts, hauntedHouses, horror, horrorGhosts := tags[0], tags[1], tags[2], tags[3], tags[4]
+	if _, err := repo.AddAlias(scary.Id, "spooky"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AddAlias(scaryGhosts.Id, "spirits"); err != nil {
+		t.Fatal(err)
+	}
+	// tale 2 has both tags, tale 3 only the merged one
+	link(t, repo, 2, scary, horror, scaryGhosts, horrorGhosts)
+	link(t, repo, 3, scary, scaryGhosts)
+
+	if err := repo.Merge([]int{scary.Id}, horror.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{}
+	all, err := repo.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range all {
+		paths = append(paths, tag.Path)
+	}
+	want := []string{"horror", "horror/ghosts", "horror/ghosts/haunted houses", "horror/zombies"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("the tags are %v after the merge, want %v", paths, want)
+	}
+	if merged, err := repo.ReadById(hauntedHouses.Id); err != nil || merged.ParentId != horrorGhosts.Id {
+		t.Errorf("the grandchild is %+v, %v, want it under %s", merged, err, horrorGhosts.Path)
+	}
+
+	// the aliases of the sources and their paths carry over
+	if aliases := aliasNames(t, repo, horror.Id); !slices.Equal(aliases, []string{"scary", "spooky"}) {
+		t.Errorf("the aliases of horror are %v", aliases)
+	}
+	if aliases := aliasNames(t, repo, horrorGhosts.Id); !slices.Equal(aliases, []string{"scary/ghosts", "spirits"}) {
+		t.Errorf("the aliases of horror/ghosts are %v", aliases)
+	}
+	if resolved, err := repo.Resolve("spirits"); err != nil || resolved.Id != horrorGhosts.Id {
+		t.Errorf("Resolve(spirits) = %+v, %v, want horror/ghosts", resolved, err)
+	}
+
+	// the tales are linked once to the target
+	for taleId, want := range map[int][]string{2: {"horror", "horror/ghosts"}, 3: {"horror", "horror/ghosts"}} {
+		if paths := taleTagPaths(t, repo, taleId); !slices.Equal(paths, want) {
+			t.Errorf("tale %d has the tags %v, want %v", taleId, paths, want)
+		}
+	}
+	usage, err := repo.ReadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tagUsage := range usage {
+		if tagUsage.Tag.Id == horror.Id && tagUsage.Count != 2 {
+			t.Errorf("horror is used %d times, want 2", tagUsage.Count)
+		}
+	}
+}
+
+func TestMergeIntoDescendant(t *testing.T) {
+	repo, _ := newTestRepository(t, 1)
+	tags := createPaths(t, repo, "genre/fantasy/epic")
+	genre, err := repo.Resolve("genre")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link(t, repo, 2, genre)
+
+	if err := repo.Merge([]int{genre.Id}, tags[0].Id); !errors.Is(err, ErrTagCycle) {
+		t.Fatalf("merging a tag into its descendant = %v, want ErrTagCycle", err)
+	}
+	all, err := repo.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("the tags are %v after the failed merge, want the 3 of the path", all)
+	}
+	if paths := taleTagPaths(t, repo, 2); !slices.Equal(paths, []string{"genre"}) {
+		t.Errorf("the tale has the tags %v after the failed merge", paths)
+	}
+
+	// a tag merged into itself is left alone
+	if err := repo.Merge([]int{genre.Id}, genre.Id); err != nil {
+		t.Errorf("merging a tag into itself = %v", err)
+	}
+}
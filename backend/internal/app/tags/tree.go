@@ -0,0 +1,153 @@
+package tags
+
+import (
+	"fmt"
+	"strings"
+	"talenest/backend/internal/data"
+)
+
+// Tree indexes the tags by parent to resolve their paths.
+type Tree struct {
+	tags     map[int]*Tag
+	children map[int][]*Tag
+}
+
+func newTree(collection []*Tag) *Tree {
+	tree := &Tree{
+		tags:     map[int]*Tag{},
+		children: map[int][]*Tag{},
+	}
+	for _, tag := range collection {
+		tree.tags[tag.Id] = tag
+		tree.children[tag.ParentId] = append(tree.children[tag.ParentId], tag)
+	}
+	for _, tag := range collection {
+		tag.Path = tree.path(tag.Id)
+	}
+	return tree
+}
+
+func (tree *Tree) path(id int) string {
+	names := []string{}
+	// the depth is bounded in case the table holds a cycle
+	for tag, ok := tree.tags[id]; ok && len(names) <= len(tree.tags); tag, ok = tree.tags[tag.ParentId] {
+		names = append([]string{tag.Name}, names...)
+	}
+	return strings.Join(names, PATH_SEPARATOR)
+}
+
+// Tags returns the tags, each parent before its children.
+func (tree *Tree) Tags() []Tag {
+	tags := []Tag{}
+	var walk func(parentId int)
+	walk = func(parentId int) {
+		for _, tag := range tree.children[parentId] {
+			tags = append(tags, *tag)
+			walk(tag.Id)
+		}
+	}
+	walk(0)
+	return tags
+}
+
+func (tree *Tree) Tag(id int) (Tag, bool) {
+	tag, ok := tree.tags[id]
+	if !ok {
+		return Tag{}, false
+	}
+	return *tag, true
+}
+
+// Children returns the tags nested directly in the tag id, 0 for the top
+// level ones.
+func (tree *Tree) Children(id int) []Tag {
+	children := []Tag{}
+	for _, tag := range tree.children[id] {
+		children = append(children, *tag)
+	}
+	return children
+}
+
+// Child returns the child of parentId named name, an exact match being
+// preferred to one ignoring case.
+func (tree *Tree) Child(parentId int, name string) (Tag, bool) {
+	var folded *Tag
+	for _, tag := range tree.children[parentId] {
+		if tag.Name == name {
+			return *tag, true
+		}
+		if folded == nil && strings.EqualFold(tag.Name, name) {
+			folded = tag
+		}
+	}
+	if folded == nil {
+		return Tag{}, false
+	}
+	return *folded, true
+}
+
+// move nests the tag id in parentId, keeping the tree in step with the
+// table during a merge.
+func (tree *Tree) move(id, parentId int) {
+	tag, ok := tree.tags[id]
+	if !ok {
+		return
+	}
+	tree.detach(tag)
+	tag.ParentId = parentId
+	tree.children[parentId] = append(tree.children[parentId], tag)
+	for _, tag := range tree.tags {
+		tag.Path = tree.path(tag.Id)
+	}
+}
+
+// remove drops the tag id, which has no children left.
+func (tree *Tree) remove(id int) {
+	if tag, ok := tree.tags[id]; ok {
+		tree.detach(tag)
+		delete(tree.tags, id)
+	}
+}
+
+func (tree *Tree) detach(tag *Tag) {
+	siblings := tree.children[tag.ParentId]
+	for i, sibling := range siblings {
+		if sibling.Id == tag.Id {
+			tree.children[tag.ParentId] = append(siblings[:i:i], siblings[i+1:]...)
+			return
+		}
+	}
+}
+
+// Find returns the tag of a path.
+func (tree *Tree) Find(path string) (Tag, error) {
+	names, err := SplitPath(path)
+	if err != nil {
+		return Tag{}, err
+	}
+	tag := Tag{}
+	for _, name := range names {
+		var ok bool
+		tag, ok = tree.Child(tag.Id, name)
+		if !ok {
+			return Tag{}, fmt.Errorf("tag %q: %w", path, data.ErrNotFound)
+		}
+	}
+	return tag, nil
+}
+
+// IsDescendant reports if the tag id is nested, at any depth, in the tag
+// ancestorId, or is that tag.
+func (tree *Tree) IsDescendant(id, ancestorId int) bool {
+	for depth := 0; depth <= len(tree.tags); depth++ {
+		if id == ancestorId {
+			return true
+		}
+		tag, ok := tree.tags[id]
+		if !ok {
+			return false
+		}
+		id = tag.ParentId
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package tags
+
+import (
+	"database/sql"
+	"slices"
+	"talenest/backend/internal/data"
+)
+
+// TagUsage counts the tales tagged with a tag.
+type TagUsage struct {
+	Tag   Tag
+	Count int
+}
+
+// CoOccurrence counts the tales tagged with both tags, FirstTagId being
+// the lowest id.
+type CoOccurrence struct {
+	FirstTagId  int
+	SecondTagId int
+	Count       int
+}
+
+// Graph links the tags used on the same tales, to be drawn as a network.
+type Graph struct {
+	Nodes []TagUsage
+	Edges []CoOccurrence
+}
+
+// Matrix returns the co-occurrences indexed by the position of the tags in
+// Nodes, the diagonal holding the usage of each tag.
+func (graph Graph) Matrix() [][]int {
+	index := map[int]int{}
+	matrix := make([][]int, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		index[node.Tag.Id] = i
+		matrix[i] = make([]int, len(graph.Nodes))
+		matrix[i][i] = node.Count
+	}
+	for _, edge := range graph.Edges {
+		first, ok := index[edge.FirstTagId]
+		second, found := index[edge.SecondTagId]
+		if ok && found {
+			matrix[first][second] = edge.Count
+			matrix[second][first] = edge.Count
+		}
+	}
+	return matrix
+}
+
+func (repo tagRepository) ReadUsage() ([]TagUsage, error) {
+	tree, err := repo.ReadTree()
+	if err != nil {
+		return []TagUsage{}, err
+	}
+	counts, err := repo.counts()
+	if err != nil {
+		return []TagUsage{}, err
+	}
+	usage := []TagUsage{}
+	for _, tag := range tree.Tags() {
+		usage = append(usage, TagUsage{Tag: tag, Count: counts[tag.Id]})
+	}
+	slices.SortStableFunc(usage, func(a, b TagUsage) int {
+		return b.Count - a.Count
+	})
+	return usage, nil
+}
+
+// usageQuery counts the tales linked to each tag.
+func usageQuery() string {
+	tagId, _ := data.NewColumn("tag_id", "")
+	count, _ := data.NewAggregateColumn("count", "*", "")
+	builder := data.NewSelectQueryBuilder(taleTagTableName)
+	builder.SetColumns([]data.Column{*tagId, *count})
+	builder.SetWhereNotNull(taleTagTableName, *tagId, "")
+	builder.GroupBy([]data.Column{*tagId})
+	query, _ := builder.Build()
+	return query
+}
+
+// coOccurrenceQuery counts the tales linked to each pair of tags, the
+// lowest tag id first.
+func coOccurrenceQuery() string {
+	firstTagId, _ := data.NewColumn("first.tag_id", "")
+	secondTagId, _ := data.NewColumn("second.tag_id", "")
+	taleId, _ := data.NewColumn("tale_id", "")
+	tagId, _ := data.NewColumn("tag_id", "")
+	count, _ := data.NewAggregateColumn("count", "*", "")
+	builder := data.NewSelectQueryBuilder(taleTagTableName)
+	builder.SetTableAlias("first")
+	builder.SetColumns([]data.Column{*firstTagId, *secondTagId, *count})
+	builder.SetJoinAlias("first", *taleId, taleTagTableName, "second", *taleId, "")
+	builder.SetWhere("second", *tagId, ">", data.NewTokenValue("first.tag_id"), "")
+	builder.GroupBy([]data.Column{*firstTagId, *secondTagId})
+	query, _ := builder.Build()
+	return query
+}
+
+func (repo tagRepository) counts() (map[int]int, error) {
+	rows, err := repo.dbConn.Query(usageQuery(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return scanCounts(rows)
+}
+
+func scanCounts(rows *sql.Rows) (map[int]int, error) {
+	defer rows.Close()
+	counts := map[int]int{}
+	for rows.Next() {
+		var tagId, count int
+		if err := rows.Scan(&tagId, &count); err != nil {
+			return nil, err
+		}
+		counts[tagId] = count
+	}
+	return counts, rows.Err()
+}
+
+func (repo tagRepository) ReadUnused() ([]Tag, error) {
+	tree, err := repo.ReadTree()
+	if err != nil {
+		return []Tag{}, err
+	}
+	counts, err := repo.counts()
+	if err != nil {
+		return []Tag{}, err
+	}
+	return unused(tree, counts), nil
+}
+
+// unused returns the tags linked to no tale, neither directly nor through
+// their children, parents first.
+func unused(tree *Tree, counts map[int]int) []Tag {
+	used := map[int]bool{}
+	for tagId, count := range counts {
+		// the parents are used through their children
+		for tag, ok := tree.Tag(tagId); ok && count > 0 && !used[tag.Id]; tag, ok = tree.Tag(tag.ParentId) {
+			used[tag.Id] = true
+		}
+	}
+	tags := []Tag{}
+	for _, tag := range tree.Tags() {
+		if !used[tag.Id] {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (repo tagRepository) PruneUnused() ([]Tag, error) {
+	pruned := []Tag{}
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		entities := repo.entities.WithTx(tx)
+		collection, err := entities.ReadAll()
+		if err != nil {
+			return err
+		}
+		rows, err := tx.Query(usageQuery())
+		if err != nil {
+			return err
+		}
+		counts, err := scanCounts(rows)
+		if err != nil {
+			return err
+		}
+		pruned = unused(newTree(collection), counts)
+		// children first, their parents would delete them
+		for i := len(pruned) - 1; i >= 0; i-- {
+			if err := entities.Delete(pruned[i].Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return []Tag{}, err
+	}
+	return pruned, nil
+}
+
+func (repo tagRepository) ReadGraph() (*Graph, error) {
+	usage, err := repo.ReadUsage()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := repo.dbConn.Query(coOccurrenceQuery(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	graph := &Graph{Nodes: usage, Edges: []CoOccurrence{}}
+	for rows.Next() {
+		edge := CoOccurrence{}
+		if err := rows.Scan(&edge.FirstTagId, &edge.SecondTagId, &edge.Count); err != nil {
+			return nil, err
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+	return graph, rows.Err()
+}
@@ -0,0 +1,114 @@
+package tags
+
+import (
+	"reflect"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/data/datatest"
+	"testing"
+)
+
+// newTestRepository opens a tag repository on a new library holding tales
+// tales besides the root one, numbered from 2.
+func newTestRepository(t *testing.T, tales int) (Repository, *data.DatabaseConnector) {
+	t.Helper()
+	dbConn := datatest.Open(t)
+	for range tales {
+		_, err := dbConn.ExecuteQuery("INSERT INTO tales (name, created_at, updated_at) VALUES ('tale', '', '');", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := NewRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	return repo, dbConn
+}
+
+func createPaths(t *testing.T, repo Repository, paths ...string) []*Tag {
+	t.Helper()
+	tags := []*Tag{}
+	for _, path := range paths {
+		tag, err := repo.CreatePath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func link(t *testing.T, repo Repository, taleId int, tags ...*Tag) {
+	t.Helper()
+	for _, tag := range tags {
+		if err := repo.Link(taleId, tag.Id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadUsageAndGraph(t *testing.T) {
+	repo, _ := newTestRepository(t, 3)
+	tags := createPaths(t, repo, "genre/fantasy", "genre/horror", "mood")
+	fantasy, horror, mood := tags[0], tags[1], tags[2]
+	link(t, repo, 2, fantasy, mood)
+	link(t, repo, 3, fantasy, horror, mood)
+	link(t, repo, 4, fantasy)
+
+	usage, err := repo.ReadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, tagUsage := range usage {
+		counts[tagUsage.Tag.Path] = tagUsage.Count
+	}
+	want := map[string]int{"genre": 0, "genre/fantasy": 3, "genre/horror": 1, "mood": 2}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("ReadUsage counts %v, want %v", counts, want)
+	}
+	if usage[0].Tag.Id != fantasy.Id {
+		t.Errorf("the most used tag is %s, want %s", usage[0].Tag.Path, fantasy.Path)
+	}
+
+	graph, err := repo.ReadGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges := map[[2]int]int{}
+	for _, edge := range graph.Edges {
+		if edge.FirstTagId >= edge.SecondTagId {
+			t.Errorf("the edge %+v isn't ordered by tag id", edge)
+		}
+		edges[[2]int{edge.FirstTagId, edge.SecondTagId}] = edge.Count
+	}
+	wantEdges := map[[2]int]int{
+		{fantasy.Id, horror.Id}: 1,
+		{fantasy.Id, mood.Id}:   2,
+		{horror.Id, mood.Id}:    1,
+	}
+	if !reflect.DeepEqual(edges, wantEdges) {
+		t.Errorf("ReadGraph edges %v, want %v", edges, wantEdges)
+	}
+
+	unused, err := repo.ReadUnused()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unused) != 0 {
+		t.Errorf("ReadUnused = %v, genre is used through its children", unused)
+	}
+	if err := repo.Unlink(3, horror.Id); err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := repo.PruneUnused()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 || pruned[0].Id != horror.Id {
+		t.Errorf("PruneUnused = %v, want %s", pruned, horror.Path)
+	}
+}
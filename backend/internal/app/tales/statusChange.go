@@ -0,0 +1,110 @@
+package tales
+
+import (
+	"database/sql"
+	"errors"
+	"talenest/backend/internal/data"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+const changesTableName = "tale_status_changes"
+const READ_BY_TALE_STATEMENT = "READ_BY_TALE"
+const READ_CHANGES_UNTIL_STATEMENT = "READ_CHANGES_UNTIL"
+
+// ErrStatusChange is returned when the status of a tale is changed by
+// Update instead of TransitionStatus.
+var ErrStatusChange = errors.New("the status of a tale can only change through a transition")
+
+// StatusChange records a tale moving from a status to another. The statuses
+// are 0 once deleted.
+type StatusChange struct {
+	Id           int
+	TaleId       int
+	FromStatusId int
+	ToStatusId   int
+	ChangedBy    string
+	ChangedAt    time.Time
+}
+
+type changeMapper struct{}
+
+func (changeMapper) TableName() string {
+	return changesTableName
+}
+
+func (changeMapper) Columns() []string {
+	return getChangeColumnNames()
+}
+
+func getChangeColumnNames() []string {
+	return []string{
+		"id",
+		"tale_id",
+		"from_status_id",
+		"to_status_id",
+		"changed_by",
+		"changed_at",
+	}
+}
+
+func (changeMapper) Values(change *StatusChange) []any {
+	return []any{
+		change.Id,
+		change.TaleId,
+		nullableId(change.FromStatusId),
+		nullableId(change.ToStatusId),
+		change.ChangedBy,
+		utils.CleanTime(change.ChangedAt),
+	}
+}
+
+func (changeMapper) Scan(scanner data.Scanner) (*StatusChange, error) {
+	change := StatusChange{}
+	var fromStatusId, toStatusId sql.NullInt64
+	var changedString string
+	err := scanner.Scan(
+		&change.Id,
+		&change.TaleId,
+		&fromStatusId,
+		&toStatusId,
+		&change.ChangedBy,
+		&changedString,
+	)
+	if err != nil {
+		return nil, err
+	}
+	change.FromStatusId = int(fromStatusId.Int64)
+	change.ToStatusId = int(toStatusId.Int64)
+	change.ChangedAt, err = time.Parse(utils.DATETIME_FORMAT, changedString)
+	if err != nil {
+		return nil, errors.New("Failed to parse time value")
+	}
+	return &change, nil
+}
+
+func (changeMapper) GetId(change *StatusChange) int {
+	return change.Id
+}
+
+func (changeMapper) SetId(change *StatusChange, id int) {
+	change.Id = id
+}
+
+func nullableId(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// readChangesUntilQuery selects the changes made before a date, oldest first.
+func readChangesUntilQuery() string {
+	builder := data.NewSelectQueryBuilder(changesTableName)
+	builder.SetColumns(data.ConvertToColumns(getChangeColumnNames()))
+	changedAt, _ := data.NewColumn("changed_at", "")
+	builder.SetWhere(changesTableName, *changedAt, "<", data.NewTokenValue("?"), "")
+	builder.OrderBy(data.ConvertToColumns([]string{"changed_at", "id"}), "ASC")
+	query, _ := builder.Build()
+	return query
+}
@@ -0,0 +1,79 @@
+package tales
+
+import (
+	"slices"
+	"talenest/backend/internal/utils"
+	"time"
+)
+
+// StatusDuration is the time a tale spent in a status, summed over every
+// time it was in it.
+type StatusDuration struct {
+	StatusId int
+	Duration time.Duration
+}
+
+// FlowPoint counts the tales in each status, by status id, at the end of
+// a day.
+type FlowPoint struct {
+	Date   time.Time
+	Counts map[int]int
+}
+
+// timeInStatus sums the time between the changes of a tale, the current
+// status counting until now. The statuses are in order of first entry.
+func timeInStatus(changes []StatusChange, now time.Time) []StatusDuration {
+	durations := []StatusDuration{}
+	for i, change := range changes {
+		if change.ToStatusId == 0 {
+			// the status was deleted
+			continue
+		}
+		end := now
+		if i+1 < len(changes) {
+			end = changes[i+1].ChangedAt
+		}
+		index := slices.IndexFunc(durations, func(duration StatusDuration) bool {
+			return duration.StatusId == change.ToStatusId
+		})
+		if index < 0 {
+			durations = append(durations, StatusDuration{StatusId: change.ToStatusId})
+			index = len(durations) - 1
+		}
+		durations[index].Duration += end.Sub(change.ChangedAt)
+	}
+	return durations
+}
+
+// cumulativeFlow replays the changes of every tale, sorted by date, and
+// counts the tales in each status at the end of every day from from to to.
+func cumulativeFlow(changes []StatusChange, from, to time.Time) []FlowPoint {
+	points := []FlowPoint{}
+	current := map[int]int{}
+	next := 0
+	for day := startOfDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		for ; next < len(changes) && changes[next].ChangedAt.Before(end); next++ {
+			current[changes[next].TaleId] = changes[next].ToStatusId
+		}
+		counts := map[int]int{}
+		for _, statusId := range current {
+			if statusId != 0 {
+				counts[statusId]++
+			}
+		}
+		points = append(points, FlowPoint{Date: day, Counts: counts})
+	}
+	return points
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// storedTime returns t as it reads once stored, the dates being stored
+// without their zone.
+func storedTime(t time.Time) time.Time {
+	stored, _ := time.Parse(utils.DATETIME_FORMAT, utils.CleanTime(t))
+	return stored
+}
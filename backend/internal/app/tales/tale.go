@@ -16,6 +16,9 @@ type Tale struct {
 	ParentId int
 	Status   status.Status
 	Tags     []tags.Tag
+	Version  int
+	// statusId is the stored status, see TransitionStatus
+	statusId int
 	created  time.Time
 	updated  time.Time
 	deleted  time.Time
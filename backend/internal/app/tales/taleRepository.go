@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"talenest/backend/internal/app/status"
 	"talenest/backend/internal/data"
 	"talenest/backend/internal/utils"
 	"time"
@@ -17,67 +18,69 @@ type Repository interface {
 	ReadById(id int) (*Tale, error)
 	ReadByParentId(parentId int) (*Tales, error)
 	ReadAll() (*Tales, error)
-	Update(tale Tale) error
+	// Update fails with a *data.StaleError[Tale] if the tale changed
+	// since it was read, and with ErrStatusChange if its status changed.
+	Update(tale *Tale) error
+	// TransitionStatus moves the tale to the status toStatusId if the
+	// workflow allows it and records who changed it.
+	TransitionStatus(tale *Tale, workflow *status.Workflow, toStatusId int, changedBy string) (*StatusChange, error)
+	// ReadStatusChanges returns the status changes of a tale, oldest first.
+	ReadStatusChanges(taleId int) ([]StatusChange, error)
+	// TimeInStatus returns the time the tale spent in each status until now.
+	TimeInStatus(taleId int) ([]StatusDuration, error)
+	// CumulativeFlow counts the tales of the library in each status for
+	// every day between from and to.
+	CumulativeFlow(from, to time.Time) ([]FlowPoint, error)
 	Delete(id int) error
 	Close() error
 }
 
 type taleRepository struct {
-	dbConn     *data.DatabaseConnector
-	statements map[string]*sql.Stmt
+	dbConn   *data.DatabaseConnector
+	entities *data.Repository[Tale]
+	changes  *data.Repository[StatusChange]
 }
 
-func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
-	repo := &taleRepository{
-		dbConn: dbConn,
-	}
-	var err error
-
-	// Prepare statements only once (better perf + safety)
-	createStmt, err := dbConn.PrepareQuery(
-		data.CreateQuery(tableName, getColumnNames()))
-	if err != nil {
-		return nil, err
-	}
-	repo.statements = make(map[string]*sql.Stmt)
-	repo.statements[data.CREATE_STATEMENT] = createStmt
+type taleMapper struct{}
 
-	readByIdStmt, err := dbConn.PrepareQuery(
-		data.ReadByIdQuery(tableName, getColumnNames()))
+func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
+	entities, err := data.NewRepository[Tale](dbConn, taleMapper{})
 	if err != nil {
 		return nil, err
 	}
-	repo.statements[data.READ_STATEMENT] = readByIdStmt
 
-	readByParentIdStmt, err := dbConn.PrepareQuery(readByParentIdQuery())
+	err = entities.Prepare(READ_BY_PARENT_STATEMENT,
+		data.ReadByColumnQuery(tableName, getColumnNames(), "parent_id"))
 	if err != nil {
+		entities.Close()
 		return nil, err
 	}
-	repo.statements[READ_BY_PARENT_STATEMENT] = readByParentIdStmt
 
-	readAllStmt, err := dbConn.PrepareQuery(
-		data.ReadAllQuery(tableName, getColumnNames()))
+	changes, err := data.NewRepository[StatusChange](dbConn, changeMapper{})
 	if err != nil {
+		entities.Close()
 		return nil, err
 	}
-	repo.statements[data.READ_ALL_STATEMENT] = readAllStmt
-
-	updateStmt, err := dbConn.PrepareQuery(
-		data.UpdateQuery(tableName, getColumnNames()))
+	err = changes.Prepare(READ_BY_TALE_STATEMENT,
+		data.ReadByColumnOrderedQuery(changesTableName, getChangeColumnNames(),
+			"tale_id", []string{"changed_at", "id"}))
 	if err != nil {
+		entities.Close()
+		changes.Close()
 		return nil, err
 	}
-	repo.statements[data.UPDATE_STATEMENT] = updateStmt
-
-	deleteStmt, err := dbConn.PrepareQuery(
-		data.DeleteQuery(tableName))
+	err = changes.Prepare(READ_CHANGES_UNTIL_STATEMENT, readChangesUntilQuery())
 	if err != nil {
+		entities.Close()
+		changes.Close()
 		return nil, err
 	}
 
-	repo.statements[data.DELETE_STATEMENT] = deleteStmt
-
-	return repo, nil
+	return &taleRepository{
+		dbConn:   dbConn,
+		entities: entities,
+		changes:  changes,
+	}, nil
 }
 
 func getColumnNames() []string {
@@ -90,172 +93,234 @@ func getColumnNames() []string {
 		"created_at",
 		"updated_at",
 		"deleted_at",
+		"version",
 	}
 }
 
-func (repo taleRepository) Create(tale *Tale) (int, error) {
-	result, err := repo.statements[data.CREATE_STATEMENT].Exec(
-		nil,
+func (taleMapper) TableName() string {
+	return tableName
+}
+
+func (taleMapper) Columns() []string {
+	return getColumnNames()
+}
+
+func (taleMapper) Values(tale *Tale) []any {
+	var deleted any
+	if !tale.deleted.IsZero() {
+		deleted = utils.CleanTime(tale.deleted)
+	}
+	// only the root tale has no parent
+	var parentId any
+	if tale.ParentId != 0 {
+		parentId = tale.ParentId
+	}
+	return []any{
+		tale.Id,
 		tale.Name,
 		tale.Summary,
-		tale.ParentId,
+		parentId,
 		tale.Status.Id,
 		utils.CleanTime(tale.created),
 		utils.CleanTime(tale.updated),
-		nil,
-	)
-	if err != nil {
-		return 0, err
+		deleted,
+		tale.Version,
 	}
-	id, err := result.LastInsertId()
-	tale.Id = int(id)
-	return tale.Id, err
 }
 
-func (repo taleRepository) ReadById(id int) (*Tale, error) {
-	rows, err := repo.statements[data.READ_STATEMENT].Query(id)
-	defer rows.Close()
+func (taleMapper) Scan(scanner data.Scanner) (*Tale, error) {
+	tale := Tale{}
+	var createdString, updatedString string
+	var deletedString sql.NullString
+	var parentId sql.NullInt64
+	err := scanner.Scan(
+		&tale.Id,
+		&tale.Name,
+		&tale.Summary,
+		&parentId,
+		&tale.Status.Id, // TODO: Get status from its repository
+		&createdString,
+		&updatedString,
+		&deletedString,
+		&tale.Version,
+	)
 	if err != nil {
-		return &Tale{}, err
+		return nil, err
 	}
-	if rows.Next() {
-		tale := Tale{}
-		var statusId int
-		var createdString, updatedString string
-		var deletedString sql.NullString
-		err := rows.Scan(
-			&tale.Id,
-			&tale.Name,
-			&tale.Summary,
-			&tale.ParentId,
-			&statusId, // TODO: Get status from its repository
-			&createdString,
-			&updatedString,
-			&deletedString,
-		)
+	tale.ParentId = int(parentId.Int64)
+	tale.statusId = tale.Status.Id
 
-		if err != nil {
-			return &Tale{}, err
-		}
-		tale.setCreated(createdString)
-		tale.setUpdated(updatedString)
-		if deletedString.Valid {
-			tale.setDeleted(deletedString.String)
+	if err := tale.setCreated(createdString); err != nil {
+		return nil, err
+	}
+	if err := tale.setUpdated(updatedString); err != nil {
+		return nil, err
+	}
+	if deletedString.Valid {
+		if err := tale.setDeleted(deletedString.String); err != nil {
+			return nil, err
 		}
-
-		return &tale, nil
 	}
-	return &Tale{}, errors.New(fmt.Sprintf("Error on retrieving the row: %v", err))
+	return &tale, nil
+}
+
+func (taleMapper) GetId(tale *Tale) int {
+	return tale.Id
+}
+
+func (taleMapper) SetId(tale *Tale, id int) {
+	tale.Id = id
+}
+
+func (taleMapper) VersionColumn() string {
+	return "version"
+}
+
+func (taleMapper) GetVersion(tale *Tale) int {
+	return tale.Version
+}
+
+func (taleMapper) SetVersion(tale *Tale, version int) {
+	tale.Version = version
 }
 
-func (repo taleRepository) readTales(rows *sql.Rows) (*Tales, error) {
-	taleCollection := &Tales{}
-	for rows.Next() {
-		tale := Tale{}
-		var statusId int
-		var createdString, updatedString string
-		var deletedString sql.NullString
-		err := rows.Scan(
-			&tale.Id,
-			&tale.Name,
-			&tale.Summary,
-			&tale.ParentId,
-			&statusId, // TODO: Get status from its repository
-			&createdString,
-			&updatedString,
-			&deletedString,
-		)
+func newTales(collection []*Tale) *Tales {
+	return &Tales{
+		collection: collection,
+	}
+}
 
+// Create records the status of the tale as its first status change.
+func (repo taleRepository) Create(tale *Tale) (int, error) {
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		id, err := repo.entities.WithTx(tx).Create(tale)
 		if err != nil {
-			return &Tales{}, err
+			return err
 		}
-		tale.setCreated(createdString)
-		tale.setUpdated(updatedString)
-		if deletedString.Valid {
-			tale.setDeleted(deletedString.String)
-		}
-		taleCollection.Add(&tale)
+		_, err = repo.changes.WithTx(tx).Create(&StatusChange{
+			TaleId:     id,
+			ToStatusId: tale.Status.Id,
+			ChangedAt:  tale.created,
+		})
+		return err
+	})
+	if err != nil {
+		tale.Id = 0
+		return 0, err
 	}
-	return taleCollection, nil
+	tale.statusId = tale.Status.Id
+	return tale.Id, nil
 }
 
-func readByParentIdQuery() string {
-	builder := data.NewSelectQueryBuilder(tableName)
-	builder.SetColumns(data.ConvertToColumns(getColumnNames()))
-	parentIdColumn, _ := data.NewColumn("parent_id", "")
-	builder.SetWhere(tableName, *parentIdColumn, "=", data.NewTokenValue("?"), "")
-	return builder.Build()
+func (repo taleRepository) ReadById(id int) (*Tale, error) {
+	return repo.entities.ReadById(id)
 }
 
 func (repo taleRepository) ReadByParentId(parentId int) (*Tales, error) {
-	rows, err := repo.statements[READ_BY_PARENT_STATEMENT].Query(parentId)
-	defer rows.Close()
+	collection, err := repo.entities.ReadMany(READ_BY_PARENT_STATEMENT, parentId)
 	if err != nil {
 		return &Tales{}, err
 	}
-	return repo.readTales(rows)
+	return newTales(collection), nil
 }
 
 func (repo taleRepository) ReadAll() (*Tales, error) {
-	rows, err := repo.statements[data.READ_ALL_STATEMENT].Query()
-	defer rows.Close()
+	collection, err := repo.entities.ReadAll()
 	if err != nil {
 		return &Tales{}, err
 	}
-	return repo.readTales(rows)
+	return newTales(collection), nil
 }
 
-func (repo taleRepository) Update(tale Tale) error {
-	var result sql.Result
-	var err error
-	if !tale.deleted.IsZero() {
-		result, err = repo.statements[data.UPDATE_STATEMENT].Exec(
-			tale.Id,
-			tale.Name,
-			tale.Summary,
-			tale.ParentId,
-			tale.Status.Id,
-			utils.CleanTime(tale.created),
-			utils.CleanTime(time.Now()),
-			utils.CleanTime(tale.deleted),
-			tale.Id,
-		)
-	} else {
-		result, err = repo.statements[data.UPDATE_STATEMENT].Exec(
-			tale.Id,
-			tale.Name,
-			tale.Summary,
-			tale.ParentId,
-			tale.Status.Id,
-			utils.CleanTime(tale.created),
-			utils.CleanTime(time.Now()),
-			nil,
-			tale.Id,
-		)
+func (repo taleRepository) Update(tale *Tale) error {
+	if tale.Status.Id != tale.statusId {
+		return fmt.Errorf("%w: tale %d", ErrStatusChange, tale.Id)
 	}
+	return repo.update(repo.entities, tale)
+}
+
+func (repo taleRepository) update(entities *data.Repository[Tale], tale *Tale) error {
+	updated := tale.updated
+	tale.updated = time.Now()
+	if err := entities.Update(tale); err != nil {
+		tale.updated = updated
+		return err
+	}
+	return nil
+}
+
+func (repo taleRepository) TransitionStatus(tale *Tale, workflow *status.Workflow, toStatusId int, changedBy string) (*StatusChange, error) {
+	if err := workflow.CheckTransition(tale.statusId, toStatusId); err != nil {
+		return nil, err
+	}
+	to, err := workflow.Status(toStatusId)
 	if err != nil {
+		return nil, err
+	}
+
+	change := &StatusChange{
+		TaleId:       tale.Id,
+		FromStatusId: tale.statusId,
+		ToStatusId:   toStatusId,
+		ChangedBy:    changedBy,
+		ChangedAt:    time.Now(),
+	}
+	previous, version := tale.Status, tale.Version
+	tale.Status = to
+	err = repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		if err := repo.update(repo.entities.WithTx(tx), tale); err != nil {
+			return err
+		}
+		_, err := repo.changes.WithTx(tx).Create(change)
 		return err
+	})
+	if err != nil {
+		tale.Status, tale.Version = previous, version
+		return nil, err
+	}
+	tale.statusId = toStatusId
+	return change, nil
+}
+
+func (repo taleRepository) ReadStatusChanges(taleId int) ([]StatusChange, error) {
+	collection, err := repo.changes.ReadMany(READ_BY_TALE_STATEMENT, taleId)
+	if err != nil {
+		return []StatusChange{}, err
 	}
-	if nRows, err := result.RowsAffected(); nRows != 1 || err != nil {
-		return errors.New("The rows affected are different than 1")
+	changes := make([]StatusChange, 0, len(collection))
+	for _, change := range collection {
+		changes = append(changes, *change)
 	}
-	return nil
+	return changes, nil
+}
+
+func (repo taleRepository) TimeInStatus(taleId int) ([]StatusDuration, error) {
+	changes, err := repo.ReadStatusChanges(taleId)
+	if err != nil {
+		return []StatusDuration{}, err
+	}
+	return timeInStatus(changes, storedTime(time.Now())), nil
+}
+
+func (repo taleRepository) CumulativeFlow(from, to time.Time) ([]FlowPoint, error) {
+	from, to = storedTime(from), storedTime(to)
+	until := startOfDay(to).AddDate(0, 0, 1)
+	collection, err := repo.changes.ReadMany(READ_CHANGES_UNTIL_STATEMENT, utils.CleanTime(until))
+	if err != nil {
+		return []FlowPoint{}, err
+	}
+	changes := make([]StatusChange, 0, len(collection))
+	for _, change := range collection {
+		changes = append(changes, *change)
+	}
+	return cumulativeFlow(changes, from, to), nil
 }
 
 func (repo taleRepository) Delete(id int) error {
 	// permanent delete
-	_, err := repo.statements[data.DELETE_STATEMENT].Exec(id)
-	return err
+	return repo.entities.Delete(id)
 }
 
 func (repo taleRepository) Close() error {
-	var errs error
-	for _, statement := range repo.statements {
-		if statement != nil {
-			if currentErr := statement.Close(); currentErr != nil {
-				errs = errors.Join(currentErr)
-			}
-		}
-	}
-	return errs
+	return errors.Join(repo.entities.Close(), repo.changes.Close())
 }
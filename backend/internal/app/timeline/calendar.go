@@ -0,0 +1,239 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"talenest/backend/internal/data"
+)
+
+const (
+	calendarsTableName = "calendars"
+	monthsTableName    = "calendar_months"
+)
+
+var (
+	ErrInvalidCalendar = errors.New("invalid calendar")
+	ErrInvalidDate     = errors.New("invalid date")
+	// ErrCalendarInUse is returned when deleting a calendar events are
+	// dated in.
+	ErrCalendarInUse = errors.New("calendar in use")
+)
+
+// Calendar is an in-world calendar of months of a fixed number of days.
+// Dates are converted to days counted from a common origin, the epoch
+// being the day year 0 of the calendar starts on, so that several
+// calendars can be used in the same timeline.
+type Calendar struct {
+	Id     int
+	Name   string
+	Era    string
+	Epoch  int64
+	Months []Month
+}
+
+type Month struct {
+	Name string
+	Days int
+}
+
+// Date is a date of a calendar, Month and Day counted from 1. Years before
+// year 0 are negative.
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// monthRecord is a month as stored, with its calendar and position.
+type monthRecord struct {
+	Id         int
+	CalendarId int
+	Month      Month
+	Position   int
+}
+
+type calendarMapper struct{}
+
+type monthMapper struct{}
+
+func (calendar *Calendar) Validate() error {
+	errs := []error{}
+	if strings.TrimSpace(calendar.Name) == "" {
+		errs = append(errs, errors.New("the name is empty"))
+	}
+	if len(calendar.Months) == 0 {
+		errs = append(errs, errors.New("a calendar needs a month"))
+	}
+	for i, month := range calendar.Months {
+		if strings.TrimSpace(month.Name) == "" {
+			errs = append(errs, fmt.Errorf("month %d has no name", i+1))
+		}
+		if month.Days <= 0 {
+			errs = append(errs, fmt.Errorf("month %d has no days", i+1))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidCalendar, errors.Join(errs...))
+	}
+	return nil
+}
+
+// YearLength returns the number of days in a year.
+func (calendar *Calendar) YearLength() int {
+	days := 0
+	for _, month := range calendar.Months {
+		days += month.Days
+	}
+	return days
+}
+
+func (calendar *Calendar) CheckDate(date Date) error {
+	if date.Month < 1 || date.Month > len(calendar.Months) {
+		return fmt.Errorf("%w: %s has no month %d", ErrInvalidDate, calendar.Name, date.Month)
+	}
+	month := calendar.Months[date.Month-1]
+	if date.Day < 1 || date.Day > month.Days {
+		return fmt.Errorf("%w: %s has no day %d", ErrInvalidDate, month.Name, date.Day)
+	}
+	return nil
+}
+
+// Moment returns the day of a date counted from the common origin.
+func (calendar *Calendar) Moment(date Date) (int64, error) {
+	if err := calendar.CheckDate(date); err != nil {
+		return 0, err
+	}
+	days := int64(date.Year) * int64(calendar.YearLength())
+	for _, month := range calendar.Months[:date.Month-1] {
+		days += int64(month.Days)
+	}
+	return calendar.Epoch + days + int64(date.Day-1), nil
+}
+
+// Date returns the date of a day counted from the common origin.
+func (calendar *Calendar) Date(moment int64) Date {
+	yearLength := int64(calendar.YearLength())
+	days := moment - calendar.Epoch
+	year := days / yearLength
+	if days%yearLength < 0 {
+		year--
+	}
+	days -= year * yearLength
+	for i, month := range calendar.Months {
+		if days < int64(month.Days) {
+			return Date{Year: int(year), Month: i + 1, Day: int(days) + 1}
+		}
+		days -= int64(month.Days)
+	}
+	// unreachable, days is less than the length of the year
+	return Date{Year: int(year), Month: len(calendar.Months), Day: int(days) + 1}
+}
+
+// Format returns a date as "3 Frostmoon 1024 AR".
+func (calendar *Calendar) Format(date Date) string {
+	month := fmt.Sprint(date.Month)
+	if date.Month >= 1 && date.Month <= len(calendar.Months) {
+		month = calendar.Months[date.Month-1].Name
+	}
+	return strings.TrimSpace(fmt.Sprintf("%d %s %d %s", date.Day, month, date.Year, calendar.Era))
+}
+
+func getCalendarColumnNames() []string {
+	return []string{
+		"id",
+		"name",
+		"era",
+		"epoch",
+	}
+}
+
+func (calendarMapper) TableName() string {
+	return calendarsTableName
+}
+
+func (calendarMapper) Columns() []string {
+	return getCalendarColumnNames()
+}
+
+func (calendarMapper) Values(calendar *Calendar) []any {
+	return []any{
+		calendar.Id,
+		calendar.Name,
+		calendar.Era,
+		calendar.Epoch,
+	}
+}
+
+func (calendarMapper) Scan(scanner data.Scanner) (*Calendar, error) {
+	calendar := Calendar{Months: []Month{}}
+	err := scanner.Scan(
+		&calendar.Id,
+		&calendar.Name,
+		&calendar.Era,
+		&calendar.Epoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+func (calendarMapper) GetId(calendar *Calendar) int {
+	return calendar.Id
+}
+
+func (calendarMapper) SetId(calendar *Calendar, id int) {
+	calendar.Id = id
+}
+
+func getMonthColumnNames() []string {
+	return []string{
+		"id",
+		"calendar_id",
+		"name",
+		"days",
+		"position",
+	}
+}
+
+func (monthMapper) TableName() string {
+	return monthsTableName
+}
+
+func (monthMapper) Columns() []string {
+	return getMonthColumnNames()
+}
+
+func (monthMapper) Values(record *monthRecord) []any {
+	return []any{
+		record.Id,
+		record.CalendarId,
+		record.Month.Name,
+		record.Month.Days,
+		record.Position,
+	}
+}
+
+func (monthMapper) Scan(scanner data.Scanner) (*monthRecord, error) {
+	record := monthRecord{}
+	err := scanner.Scan(
+		&record.Id,
+		&record.CalendarId,
+		&record.Month.Name,
+		&record.Month.Days,
+		&record.Position,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (monthMapper) GetId(record *monthRecord) int {
+	return record.Id
+}
+
+func (monthMapper) SetId(record *monthRecord, id int) {
+	record.Id = id
+}
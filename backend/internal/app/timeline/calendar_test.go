@@ -0,0 +1,118 @@
+package timeline
+
+import (
+	"errors"
+	"testing"
+)
+
+// shire has years of 3 months of 10, 20 and 30 days.
+var shire = &Calendar{
+	Id:    1,
+	Name:  "Shire",
+	Era:   "SR",
+	Epoch: 100,
+	Months: []Month{
+		{Name: "Afteryule", Days: 10},
+		{Name: "Solmath", Days: 20},
+		{Name: "Rethe", Days: 30},
+	},
+}
+
+func TestCalendarValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar Calendar
+		valid    bool
+	}{
+		{"valid", *shire, true},
+		{"no name", Calendar{Name: " ", Months: []Month{{Name: "M", Days: 1}}}, false},
+		{"no month", Calendar{Name: "C"}, false},
+		{"month without name", Calendar{Name: "C", Months: []Month{{Name: "", Days: 1}}}, false},
+		{"month without days", Calendar{Name: "C", Months: []Month{{Name: "M", Days: 0}}}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.calendar.Validate()
+			if test.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !test.valid && !errors.Is(err, ErrInvalidCalendar) {
+				t.Errorf("Validate() = %v, want ErrInvalidCalendar", err)
+			}
+		})
+	}
+}
+
+func TestCalendarMoment(t *testing.T) {
+	tests := []struct {
+		date   Date
+		moment int64
+		err    error
+	}{
+		{Date{Year: 0, Month: 1, Day: 1}, 100, nil},
+		{Date{Year: 0, Month: 1, Day: 10}, 109, nil},
+		{Date{Year: 0, Month: 2, Day: 1}, 110, nil},
+		{Date{Year: 0, Month: 3, Day: 30}, 159, nil},
+		{Date{Year: 1, Month: 1, Day: 1}, 160, nil},
+		{Date{Year: -1, Month: 3, Day: 30}, 99, nil},
+		{Date{Year: -1, Month: 1, Day: 1}, 40, nil},
+		{Date{Year: 0, Month: 0, Day: 1}, 0, ErrInvalidDate},
+		{Date{Year: 0, Month: 4, Day: 1}, 0, ErrInvalidDate},
+		{Date{Year: 0, Month: 1, Day: 11}, 0, ErrInvalidDate},
+		{Date{Year: 0, Month: 1, Day: 0}, 0, ErrInvalidDate},
+	}
+	for _, test := range tests {
+		moment, err := shire.Moment(test.date)
+		if !errors.Is(err, test.err) {
+			t.Errorf("Moment(%v) error = %v, want %v", test.date, err, test.err)
+			continue
+		}
+		if err == nil && moment != test.moment {
+			t.Errorf("Moment(%v) = %d, want %d", test.date, moment, test.moment)
+		}
+	}
+}
+
+func TestCalendarDate(t *testing.T) {
+	for moment := int64(-200); moment <= 400; moment++ {
+		date := shire.Date(moment)
+		back, err := shire.Moment(date)
+		if err != nil {
+			t.Fatalf("Date(%d) = %v, which is invalid: %v", moment, date, err)
+		}
+		if back != moment {
+			t.Fatalf("Moment(Date(%d)) = %d", moment, back)
+		}
+	}
+}
+
+func TestCalendarsShareOrigin(t *testing.T) {
+	// a calendar of 360 days starting 60 days after the one of the shire
+	// starts
+	reckoning := &Calendar{Name: "Reckoning", Epoch: 160, Months: []Month{{Name: "Year", Days: 360}}}
+	moment, err := shire.Moment(Date{Year: 1, Month: 1, Day: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date := reckoning.Date(moment); date != (Date{Year: 0, Month: 1, Day: 5}) {
+		t.Errorf("the day is %v in the other calendar, want year 0, month 1, day 5", date)
+	}
+}
+
+func TestCalendarFormat(t *testing.T) {
+	tests := []struct {
+		calendar *Calendar
+		date     Date
+		want     string
+	}{
+		{shire, Date{Year: 1420, Month: 2, Day: 3}, "3 Solmath 1420 SR"},
+		{shire, Date{Year: -5, Month: 1, Day: 1}, "1 Afteryule -5 SR"},
+		{shire, Date{Year: 2, Month: 7, Day: 1}, "1 7 2 SR"},
+		{&Calendar{Months: shire.Months}, Date{Year: 2, Month: 3, Day: 9}, "9 Rethe 2"},
+	}
+	for _, test := range tests {
+		if got := test.calendar.Format(test.date); got != test.want {
+			t.Errorf("Format(%v) = %q, want %q", test.date, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package timeline
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"talenest/backend/internal/data"
+)
+
+const eventsTableName = "timeline_events"
+
+var (
+	ErrInvalidEvent = errors.New("invalid event")
+	// ErrEventCycle is returned when an event would end up placed after
+	// itself.
+	ErrEventCycle = errors.New("event placed after itself")
+)
+
+// Event is something happening in the stories, attached to a tale and
+// optionally to the chapter it's told in. Its time is either a date of a
+// calendar, or Offset days after the event AfterId, or unknown. Position
+// orders the events happening on the same day.
+type Event struct {
+	Id          int
+	TaleId      int
+	ChapterId   int
+	Title       string
+	Description string
+	CalendarId  int
+	Date        Date
+	AfterId     int
+	Offset      int
+	Position    int
+}
+
+type eventMapper struct{}
+
+func (event *Event) Validate() error {
+	errs := []error{}
+	if strings.TrimSpace(event.Title) == "" {
+		errs = append(errs, errors.New("the title is empty"))
+	}
+	if event.TaleId == 0 && event.ChapterId == 0 {
+		errs = append(errs, errors.New("the event isn't attached to a tale"))
+	}
+	if event.CalendarId != 0 && event.AfterId != 0 {
+		errs = append(errs, errors.New("the event is both dated and placed after another"))
+	}
+	if event.AfterId != 0 && event.AfterId == event.Id {
+		errs = append(errs, ErrEventCycle)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidEvent, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Dated tells whether the event has a date of its own.
+func (event *Event) Dated() bool {
+	return event.CalendarId != 0
+}
+
+// Relative tells whether the event is placed after another one.
+func (event *Event) Relative() bool {
+	return event.AfterId != 0
+}
+
+func getEventColumnNames() []string {
+	return []string{
+		"id",
+		"tale_id",
+		"chapter_id",
+		"title",
+		"description",
+		"calendar_id",
+		"year",
+		"month",
+		"day",
+		"after_id",
+		"offset_days",
+		"position",
+	}
+}
+
+func (eventMapper) TableName() string {
+	return eventsTableName
+}
+
+func (eventMapper) Columns() []string {
+	return getEventColumnNames()
+}
+
+func (eventMapper) Values(event *Event) []any {
+	return []any{
+		event.Id,
+		event.TaleId,
+		nullableId(event.ChapterId),
+		event.Title,
+		event.Description,
+		nullableId(event.CalendarId),
+		event.Date.Year,
+		event.Date.Month,
+		event.Date.Day,
+		nullableId(event.AfterId),
+		event.Offset,
+		event.Position,
+	}
+}
+
+func (eventMapper) Scan(scanner data.Scanner) (*Event, error) {
+	event := Event{}
+	var chapterId, calendarId, afterId sql.NullInt64
+	err := scanner.Scan(
+		&event.Id,
+		&event.TaleId,
+		&chapterId,
+		&event.Title,
+		&event.Description,
+		&calendarId,
+		&event.Date.Year,
+		&event.Date.Month,
+		&event.Date.Day,
+		&afterId,
+		&event.Offset,
+		&event.Position,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.ChapterId = int(chapterId.Int64)
+	event.CalendarId = int(calendarId.Int64)
+	event.AfterId = int(afterId.Int64)
+	return &event, nil
+}
+
+func (eventMapper) GetId(event *Event) int {
+	return event.Id
+}
+
+func (eventMapper) SetId(event *Event, id int) {
+	event.Id = id
+}
+
+func nullableId(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,160 @@
+package timeline
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Entry is an event placed on the timeline. When Known, Moment is the day
+// of the event counted from the common origin of the calendars and Date
+// the day in the calendar it's dated from. Otherwise the time of the event
+// is unknown and Moment counts the days from the undated event it's placed
+// after.
+type Entry struct {
+	Event  Event
+	Moment int64
+	Known  bool
+	Date   string
+	// root is the event the entry is placed from and depth the number of
+	// events in between.
+	root  *Event
+	depth int
+}
+
+// Conflict is an event told in a chapter after a chapter telling an event
+// happening later, a flashback or a mistake.
+type Conflict struct {
+	Event Entry
+	Later Entry
+}
+
+// place computes the time of the events, following the events they are
+// placed after. The calendars are indexed by id.
+func place(events []*Event, calendars map[int]*Calendar) (map[int]*Entry, error) {
+	byId := map[int]*Event{}
+	for _, event := range events {
+		byId[event.Id] = event
+	}
+	entries := map[int]*Entry{}
+	placing := map[int]bool{}
+	var placeEvent func(event *Event) (*Entry, error)
+	placeEvent = func(event *Event) (*Entry, error) {
+		if entry, ok := entries[event.Id]; ok {
+			return entry, nil
+		}
+		if placing[event.Id] {
+			return nil, fmt.Errorf("%w: event %d", ErrEventCycle, event.Id)
+		}
+		placing[event.Id] = true
+		entry := &Entry{Event: *event, root: event}
+		var calendar *Calendar
+		switch {
+		case event.Dated():
+			calendar = calendars[event.CalendarId]
+			if calendar == nil {
+				return nil, fmt.Errorf("%w: event %d has an unknown calendar", ErrInvalidEvent, event.Id)
+			}
+			moment, err := calendar.Moment(event.Date)
+			if err != nil {
+				return nil, fmt.Errorf("event %d: %w", event.Id, err)
+			}
+			entry.Moment, entry.Known = moment, true
+		case event.Relative():
+			after := byId[event.AfterId]
+			if after == nil {
+				return nil, fmt.Errorf("%w: event %d is placed after an unknown event", ErrInvalidEvent, event.Id)
+			}
+			afterEntry, err := placeEvent(after)
+			if err != nil {
+				return nil, err
+			}
+			entry.Moment = afterEntry.Moment + int64(event.Offset)
+			entry.Known = afterEntry.Known
+			entry.root, entry.depth = afterEntry.root, afterEntry.depth+1
+			calendar = calendars[entry.root.CalendarId]
+		}
+		if entry.Known {
+			entry.Date = calendar.Format(calendar.Date(entry.Moment))
+		}
+		entries[event.Id] = entry
+		return entry, nil
+	}
+	for _, event := range events {
+		if _, err := placeEvent(event); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// compareEntries orders the entries in time. The events of unknown time
+// come last, grouped by the undated event they are placed from. An event
+// placed after another on the same day comes after it.
+func compareEntries(a, b *Entry) int {
+	if a.Known != b.Known {
+		if a.Known {
+			return -1
+		}
+		return 1
+	}
+	if !a.Known {
+		if c := cmp.Or(cmp.Compare(a.root.Position, b.root.Position), cmp.Compare(a.root.Id, b.root.Id)); c != 0 {
+			return c
+		}
+	}
+	return cmp.Or(
+		cmp.Compare(a.Moment, b.Moment),
+		cmp.Compare(a.depth, b.depth),
+		cmp.Compare(a.Event.Position, b.Event.Position),
+		cmp.Compare(a.Event.Id, b.Event.Id),
+	)
+}
+
+// sortEntries returns the entries in chronological order.
+func sortEntries(entries []*Entry) []Entry {
+	slices.SortFunc(entries, compareEntries)
+	sorted := make([]Entry, len(entries))
+	for i, entry := range entries {
+		sorted[i] = *entry
+	}
+	return sorted
+}
+
+// findConflicts compares the order the events are told in, given by the
+// position of their chapter in narrative, to the timeline. The events of
+// unknown time or not told in a chapter are left out.
+func findConflicts(timeline []Entry, narrative map[int]int) []Conflict {
+	told := []Entry{}
+	for _, entry := range timeline {
+		if _, ok := narrative[entry.Event.ChapterId]; ok && entry.Known {
+			told = append(told, entry)
+		}
+	}
+	slices.SortStableFunc(told, func(a, b Entry) int {
+		return narrative[a.Event.ChapterId] - narrative[b.Event.ChapterId]
+	})
+	conflicts := []Conflict{}
+	var latest *Entry
+	for start := 0; start < len(told); {
+		// the events of a chapter aren't ordered among themselves
+		end := start
+		for end < len(told) && told[end].Event.ChapterId == told[start].Event.ChapterId {
+			end++
+		}
+		if latest != nil {
+			for _, entry := range told[start:end] {
+				if entry.Moment < latest.Moment {
+					conflicts = append(conflicts, Conflict{Event: entry, Later: *latest})
+				}
+			}
+		}
+		for i := start; i < end; i++ {
+			if latest == nil || told[i].Moment > latest.Moment {
+				latest = &told[i]
+			}
+		}
+		start = end
+	}
+	return conflicts
+}
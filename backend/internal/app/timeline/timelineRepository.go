@@ -0,0 +1,459 @@
+package timeline
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"talenest/backend/internal/data"
+)
+
+const (
+	READ_BY_CALENDAR_STATEMENT = "READ_BY_CALENDAR"
+	READ_BY_AFTER_STATEMENT    = "READ_BY_AFTER"
+	DELETE_MONTHS_STATEMENT    = "DELETE_MONTHS"
+)
+
+// the tree of tales is walked with a recursive query, which the query
+// builders don't cover
+const (
+	SUBTREE_QUERY = `WITH RECURSIVE subtree (id, parent_id) AS (
+    SELECT id, parent_id FROM tales WHERE id = ? AND deleted_at IS NULL
+    UNION ALL
+    SELECT tales.id, tales.parent_id FROM tales JOIN subtree ON tales.parent_id = subtree.id
+    WHERE tales.deleted_at IS NULL
+)
+SELECT id, ifnull(parent_id, 0) FROM subtree;`
+	CHAPTERS_QUERY     = "SELECT id, tale_id FROM chapters ORDER BY id;"
+	CHAPTER_TALE_QUERY = "SELECT tale_id FROM chapters WHERE id = ?;"
+)
+
+type Repository interface {
+	// CreateCalendar saves the calendar with its months.
+	CreateCalendar(calendar *Calendar) (int, error)
+	ReadCalendars() ([]Calendar, error)
+	// UpdateCalendar saves the calendar and replaces its months. It fails
+	// with ErrInvalidDate if an event is dated on a day the calendar no
+	// longer has.
+	UpdateCalendar(calendar *Calendar) error
+	// DeleteCalendar fails with ErrCalendarInUse while events are dated in
+	// the calendar.
+	DeleteCalendar(id int) error
+	// CreateEvent saves an event, attached to the tale of its chapter when
+	// it has one.
+	CreateEvent(event *Event) (int, error)
+	ReadEvent(id int) (*Event, error)
+	// UpdateEvent fails with ErrEventCycle if the event would be placed
+	// after itself.
+	UpdateEvent(event *Event) error
+	// DeleteEvent deletes an event, the events placed after it are placed
+	// from where it was.
+	DeleteEvent(id int) error
+	// ReadTimeline returns the events of a tale and its descendants in
+	// chronological order.
+	ReadTimeline(taleId int) ([]Entry, error)
+	// ReadConflicts returns the events of a tale and its descendants told
+	// in a chapter after the chapter of a later event. The chapters are
+	// told in the order of their tales, parents before their children.
+	ReadConflicts(taleId int) ([]Conflict, error)
+	Close() error
+}
+
+type timelineRepository struct {
+	dbConn    *data.DatabaseConnector
+	calendars *data.Repository[Calendar]
+	months    *data.Repository[monthRecord]
+	events    *data.Repository[Event]
+}
+
+func NewRepository(dbConn *data.DatabaseConnector) (Repository, error) {
+	calendars, err := data.NewRepository[Calendar](dbConn, calendarMapper{})
+	if err != nil {
+		return nil, err
+	}
+	months, err := data.NewRepository[monthRecord](dbConn, monthMapper{})
+	if err != nil {
+		calendars.Close()
+		return nil, err
+	}
+	events, err := data.NewRepository[Event](dbConn, eventMapper{})
+	if err != nil {
+		calendars.Close()
+		months.Close()
+		return nil, err
+	}
+	repo := &timelineRepository{
+		dbConn:    dbConn,
+		calendars: calendars,
+		months:    months,
+		events:    events,
+	}
+
+	err = months.Prepare(DELETE_MONTHS_STATEMENT,
+		data.DeleteByColumnsQuery(monthsTableName, []string{"calendar_id"}))
+	if err != nil {
+		repo.Close()
+		return nil, err
+	}
+	eventQueries := map[string]string{
+		READ_BY_CALENDAR_STATEMENT: data.ReadByColumnQuery(eventsTableName, getEventColumnNames(), "calendar_id"),
+		READ_BY_AFTER_STATEMENT:    data.ReadByColumnQuery(eventsTableName, getEventColumnNames(), "after_id"),
+	}
+	for name, query := range eventQueries {
+		if err := events.Prepare(name, query); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+func (repo timelineRepository) CreateCalendar(calendar *Calendar) (int, error) {
+	calendar.Name = strings.TrimSpace(calendar.Name)
+	if err := calendar.Validate(); err != nil {
+		return 0, err
+	}
+	err := repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		if _, err := repo.calendars.WithTx(tx).Create(calendar); err != nil {
+			return err
+		}
+		return createMonths(repo.months.WithTx(tx), calendar)
+	})
+	if err != nil {
+		calendar.Id = 0
+		return 0, err
+	}
+	return calendar.Id, nil
+}
+
+func createMonths(months *data.Repository[monthRecord], calendar *Calendar) error {
+	for i, month := range calendar.Months {
+		month.Name = strings.TrimSpace(month.Name)
+		record := &monthRecord{CalendarId: calendar.Id, Month: month, Position: i}
+		if _, err := months.Create(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo timelineRepository) ReadCalendars() ([]Calendar, error) {
+	collection, err := repo.calendars.ReadAll()
+	if err != nil {
+		return []Calendar{}, err
+	}
+	records, err := repo.months.ReadAll()
+	if err != nil {
+		return []Calendar{}, err
+	}
+	slices.SortStableFunc(records, func(a, b *monthRecord) int {
+		return a.Position - b.Position
+	})
+	months := map[int][]Month{}
+	for _, record := range records {
+		months[record.CalendarId] = append(months[record.CalendarId], record.Month)
+	}
+	calendars := make([]Calendar, len(collection))
+	for i, calendar := range collection {
+		calendar.Months = append(calendar.Months, months[calendar.Id]...)
+		calendars[i] = *calendar
+	}
+	return calendars, nil
+}
+
+// calendarsById indexes the calendars for placing the events.
+func (repo timelineRepository) calendarsById() (map[int]*Calendar, error) {
+	calendars, err := repo.ReadCalendars()
+	if err != nil {
+		return nil, err
+	}
+	byId := map[int]*Calendar{}
+	for i := range calendars {
+		byId[calendars[i].Id] = &calendars[i]
+	}
+	return byId, nil
+}
+
+func (repo timelineRepository) UpdateCalendar(calendar *Calendar) error {
+	calendar.Name = strings.TrimSpace(calendar.Name)
+	if err := calendar.Validate(); err != nil {
+		return err
+	}
+	dated, err := repo.events.ReadMany(READ_BY_CALENDAR_STATEMENT, calendar.Id)
+	if err != nil {
+		return err
+	}
+	for _, event := range dated {
+		if err := calendar.CheckDate(event.Date); err != nil {
+			return fmt.Errorf("event %d: %w", event.Id, err)
+		}
+	}
+	return repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		if err := repo.calendars.WithTx(tx).Update(calendar); err != nil {
+			return err
+		}
+		months := repo.months.WithTx(tx)
+		if _, err := months.ExecMany(DELETE_MONTHS_STATEMENT, calendar.Id); err != nil {
+			return err
+		}
+		return createMonths(months, calendar)
+	})
+}
+
+func (repo timelineRepository) DeleteCalendar(id int) error {
+	dated, err := repo.events.ReadMany(READ_BY_CALENDAR_STATEMENT, id)
+	if err != nil {
+		return err
+	}
+	if len(dated) > 0 {
+		return fmt.Errorf("%w: %d events are dated in it", ErrCalendarInUse, len(dated))
+	}
+	return repo.calendars.Delete(id)
+}
+
+func (repo timelineRepository) CreateEvent(event *Event) (int, error) {
+	if err := repo.check(event); err != nil {
+		return 0, err
+	}
+	return repo.events.Create(event)
+}
+
+func (repo timelineRepository) ReadEvent(id int) (*Event, error) {
+	return repo.events.ReadById(id)
+}
+
+func (repo timelineRepository) UpdateEvent(event *Event) error {
+	if err := repo.check(event); err != nil {
+		return err
+	}
+	return repo.events.Update(event)
+}
+
+// check validates an event before it's saved, attaching it to the tale of
+// its chapter and placing it among the other events to find cycles and
+// invalid dates.
+func (repo timelineRepository) check(event *Event) error {
+	event.Title = strings.TrimSpace(event.Title)
+	if event.ChapterId != 0 {
+		taleId, err := repo.chapterTale(event.ChapterId)
+		if err != nil {
+			return err
+		}
+		event.TaleId = taleId
+	}
+	if !event.Dated() {
+		event.Date = Date{}
+	}
+	if !event.Relative() {
+		event.Offset = 0
+	}
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	events, err := repo.events.ReadAll()
+	if err != nil {
+		return err
+	}
+	calendars, err := repo.calendarsById()
+	if err != nil {
+		return err
+	}
+	events = slices.DeleteFunc(events, func(other *Event) bool {
+		return other.Id == event.Id
+	})
+	_, err = place(append(events, event), calendars)
+	return err
+}
+
+func (repo timelineRepository) chapterTale(chapterId int) (int, error) {
+	rows, err := repo.dbConn.Query(CHAPTER_TALE_QUERY, []any{chapterId})
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("chapter %d: %w", chapterId, data.ErrNotFound)
+	}
+	var taleId int
+	if err := rows.Scan(&taleId); err != nil {
+		return 0, err
+	}
+	return taleId, nil
+}
+
+func (repo timelineRepository) DeleteEvent(id int) error {
+	event, err := repo.events.ReadById(id)
+	if err != nil {
+		return err
+	}
+	calendars, err := repo.calendarsById()
+	if err != nil {
+		return err
+	}
+	return repo.dbConn.Transaction(func(tx *sql.Tx) error {
+		events := repo.events.WithTx(tx)
+		following, err := events.ReadMany(READ_BY_AFTER_STATEMENT, id)
+		if err != nil {
+			return err
+		}
+		for _, next := range following {
+			if err := rebase(next, event, calendars); err != nil {
+				return err
+			}
+			if err := events.Update(next); err != nil {
+				return err
+			}
+		}
+		return events.Delete(id)
+	})
+}
+
+// rebase places next, placed after event, from where event is placed.
+func rebase(next, event *Event, calendars map[int]*Calendar) error {
+	switch {
+	case event.Dated():
+		calendar := calendars[event.CalendarId]
+		if calendar == nil {
+			return fmt.Errorf("%w: event %d has an unknown calendar", ErrInvalidEvent, event.Id)
+		}
+		moment, err := calendar.Moment(event.Date)
+		if err != nil {
+			return err
+		}
+		next.CalendarId = event.CalendarId
+		next.Date = calendar.Date(moment + int64(next.Offset))
+		next.AfterId, next.Offset = 0, 0
+	case event.Relative():
+		next.AfterId = event.AfterId
+		next.Offset += event.Offset
+	default:
+		next.AfterId, next.Offset = 0, 0
+	}
+	return nil
+}
+
+func (repo timelineRepository) ReadTimeline(taleId int) ([]Entry, error) {
+	tales, err := repo.subtree(taleId)
+	if err != nil {
+		return []Entry{}, err
+	}
+	return repo.timeline(tales)
+}
+
+// timeline returns the events of the tales in chronological order.
+func (repo timelineRepository) timeline(tales map[int]int) ([]Entry, error) {
+	entries, err := repo.place()
+	if err != nil {
+		return []Entry{}, err
+	}
+	selected := []*Entry{}
+	for _, entry := range entries {
+		if _, ok := tales[entry.Event.TaleId]; ok {
+			selected = append(selected, entry)
+		}
+	}
+	return sortEntries(selected), nil
+}
+
+// place places every event of the library, the events of a subtree may be
+// placed after events outside of it.
+func (repo timelineRepository) place() (map[int]*Entry, error) {
+	events, err := repo.events.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := repo.calendarsById()
+	if err != nil {
+		return nil, err
+	}
+	return place(events, calendars)
+}
+
+// subtree returns the tales of the subtree of taleId with their parent.
+func (repo timelineRepository) subtree(taleId int) (map[int]int, error) {
+	rows, err := repo.dbConn.Query(SUBTREE_QUERY, []any{taleId})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tales := map[int]int{}
+	for rows.Next() {
+		var id, parentId int
+		if err := rows.Scan(&id, &parentId); err != nil {
+			return nil, err
+		}
+		tales[id] = parentId
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(tales) == 0 {
+		return nil, fmt.Errorf("tale %d: %w", taleId, data.ErrNotFound)
+	}
+	return tales, nil
+}
+
+func (repo timelineRepository) ReadConflicts(taleId int) ([]Conflict, error) {
+	tales, err := repo.subtree(taleId)
+	if err != nil {
+		return []Conflict{}, err
+	}
+	timeline, err := repo.timeline(tales)
+	if err != nil {
+		return []Conflict{}, err
+	}
+	narrative, err := repo.narrative(taleId, tales)
+	if err != nil {
+		return []Conflict{}, err
+	}
+	return findConflicts(timeline, narrative), nil
+}
+
+// narrative returns the position of the chapters of the subtree in the
+// order they are told: the chapters of a tale in order, then the tales it
+// contains.
+func (repo timelineRepository) narrative(taleId int, tales map[int]int) (map[int]int, error) {
+	rows, err := repo.dbConn.Query(CHAPTERS_QUERY, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	chapters := map[int][]int{}
+	for rows.Next() {
+		var id, chapterTaleId int
+		if err := rows.Scan(&id, &chapterTaleId); err != nil {
+			return nil, err
+		}
+		chapters[chapterTaleId] = append(chapters[chapterTaleId], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	children := map[int][]int{}
+	for id, parentId := range tales {
+		if id != taleId {
+			children[parentId] = append(children[parentId], id)
+		}
+	}
+	narrative := map[int]int{}
+	var tell func(id int)
+	tell = func(id int) {
+		for _, chapterId := range chapters[id] {
+			narrative[chapterId] = len(narrative)
+		}
+		slices.Sort(children[id])
+		for _, child := range children[id] {
+			tell(child)
+		}
+	}
+	tell(taleId)
+	return narrative, nil
+}
+
+func (repo timelineRepository) Close() error {
+	return errors.Join(repo.calendars.Close(), repo.months.Close(), repo.events.Close())
+}
@@ -0,0 +1,127 @@
+package timeline
+
+import (
+	"errors"
+	"slices"
+	"talenest/backend/internal/data/datatest"
+	"testing"
+)
+
+// newTestRepository opens a timeline repository on a new library holding
+// the tale 2 with its child 3 and the tale 4 beside them. The chapters 1
+// and 2 belong to the tale 2, the chapter 3 to its child.
+func newTestRepository(t *testing.T) Repository {
+	t.Helper()
+	dbConn := datatest.Open(t)
+	queries := []string{
+		"INSERT INTO tales (name, parent_id, created_at, updated_at) VALUES ('saga', 1, '', '');",
+		"INSERT INTO tales (name, parent_id, created_at, updated_at) VALUES ('sequel', 2, '', '');",
+		"INSERT INTO tales (name, parent_id, created_at, updated_at) VALUES ('other', 1, '', '');",
+		"INSERT INTO chapters (tale_id, content) VALUES (2, ''), (2, ''), (3, '');",
+	}
+	for _, query := range queries {
+		if _, err := dbConn.ExecuteQuery(query, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := NewRepository(dbConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	return repo
+}
+
+func createEvent(t *testing.T, repo Repository, event *Event) *Event {
+	t.Helper()
+	if _, err := repo.CreateEvent(event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func entryIds(entries []Entry) []int {
+	ids := []int{}
+	for _, entry := range entries {
+		ids = append(ids, entry.Event.Id)
+	}
+	return ids
+}
+
+func TestTimelineRepository(t *testing.T) {
+	repo := newTestRepository(t)
+	calendar := &Calendar{Name: " Shire ", Era: "SR", Epoch: 100, Months: slices.Clone(shire.Months)}
+	if _, err := repo.CreateCalendar(calendar); err != nil {
+		t.Fatal(err)
+	}
+	calendars, err := repo.ReadCalendars()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendars) != 1 || calendars[0].Name != "Shire" || !slices.Equal(calendars[0].Months, shire.Months) {
+		t.Fatalf("ReadCalendars() = %v", calendars)
+	}
+
+	// the party is told in the second chapter, what follows in the first
+	party := createEvent(t, repo, &Event{Title: "party", ChapterId: 2, CalendarId: calendar.Id, Date: Date{Year: 1, Month: 1, Day: 5}})
+	departure := createEvent(t, repo, &Event{Title: "departure", ChapterId: 1, AfterId: party.Id, Offset: 3})
+	createEvent(t, repo, &Event{Title: "return", ChapterId: 3, CalendarId: calendar.Id, Date: Date{Year: 2, Month: 1, Day: 1}})
+	createEvent(t, repo, &Event{Title: "elsewhere", TaleId: 4, CalendarId: calendar.Id, Date: Date{Year: 0, Month: 1, Day: 1}})
+	if departure.TaleId != 2 {
+		t.Errorf("the event of chapter 1 is attached to the tale %d, want 2", departure.TaleId)
+	}
+
+	entries, err := repo.ReadTimeline(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(entryIds(entries), want) {
+		t.Fatalf("ReadTimeline(2) = %v, want %v", entryIds(entries), want)
+	}
+	if entries[1].Moment != entries[0].Moment+3 || entries[1].Date != "8 Afteryule 1 SR" {
+		t.Errorf("the departure is placed on %d (%s), want 3 days after %d", entries[1].Moment, entries[1].Date, entries[0].Moment)
+	}
+	if _, err := repo.ReadTimeline(99); err == nil {
+		t.Error("ReadTimeline() of a missing tale succeeded")
+	}
+
+	conflicts, err := repo.ReadConflicts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Event.Event.Id != party.Id || conflicts[0].Later.Event.Id != departure.Id {
+		t.Errorf("ReadConflicts(2) = %v, want the party told after the departure", conflicts)
+	}
+
+	party.AfterId, party.CalendarId = departure.Id, 0
+	if err := repo.UpdateEvent(party); !errors.Is(err, ErrEventCycle) {
+		t.Errorf("UpdateEvent() placing an event after itself = %v, want %v", err, ErrEventCycle)
+	}
+	party.AfterId, party.CalendarId = 0, calendar.Id
+
+	calendar.Months = []Month{{Name: "Afteryule", Days: 10}, {Name: "Solmath", Days: 2}}
+	if err := repo.UpdateCalendar(calendar); err != nil {
+		t.Fatal(err)
+	}
+	calendar.Months = []Month{{Name: "Afteryule", Days: 4}}
+	if err := repo.UpdateCalendar(calendar); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("UpdateCalendar() dropping a day an event is dated on = %v, want %v", err, ErrInvalidDate)
+	}
+	if err := repo.DeleteCalendar(calendar.Id); !errors.Is(err, ErrCalendarInUse) {
+		t.Errorf("DeleteCalendar() of a calendar in use = %v, want %v", err, ErrCalendarInUse)
+	}
+
+	// the departure is dated where the party was once it's deleted
+	if err := repo.DeleteEvent(party.Id); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := repo.ReadEvent(departure.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Date{Year: 1, Month: 1, Day: 8}); moved.CalendarId != calendar.Id || moved.Date != want || moved.AfterId != 0 {
+		t.Errorf("the departure reads %+v after deleting the party, want dated on %v", moved, want)
+	}
+}
@@ -0,0 +1,162 @@
+package timeline
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func dated(id int, date Date) *Event {
+	return &Event{Id: id, Title: "event", CalendarId: shire.Id, Date: date}
+}
+
+func after(id, afterId, offset int) *Event {
+	return &Event{Id: id, Title: "event", AfterId: afterId, Offset: offset}
+}
+
+func undated(id, position int) *Event {
+	return &Event{Id: id, Title: "event", Position: position}
+}
+
+func TestPlace(t *testing.T) {
+	calendars := map[int]*Calendar{shire.Id: shire}
+	events := []*Event{
+		after(3, 2, 5),
+		dated(1, Date{Year: 0, Month: 1, Day: 1}),
+		after(2, 1, 9),
+		undated(4, 0),
+		after(5, 4, 2),
+	}
+	entries, err := place(events, calendars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		id     int
+		moment int64
+		known  bool
+		date   string
+		depth  int
+	}{
+		{1, 100, true, "1 Afteryule 0 SR", 0},
+		{2, 109, true, "10 Afteryule 0 SR", 1},
+		{3, 114, true, "5 Solmath 0 SR", 2},
+		{4, 0, false, "", 0},
+		{5, 2, false, "", 1},
+	}
+	for _, test := range tests {
+		entry := entries[test.id]
+		if entry == nil {
+			t.Fatalf("event %d isn't placed", test.id)
+		}
+		if entry.Moment != test.moment || entry.Known != test.known || entry.Date != test.date || entry.depth != test.depth {
+			t.Errorf("event %d placed at %d, %v, %q, depth %d, want %d, %v, %q, depth %d", test.id,
+				entry.Moment, entry.Known, entry.Date, entry.depth, test.moment, test.known, test.date, test.depth)
+		}
+	}
+}
+
+func TestPlaceErrors(t *testing.T) {
+	calendars := map[int]*Calendar{shire.Id: shire}
+	tests := []struct {
+		name   string
+		events []*Event
+		err    error
+	}{
+		{"cycle", []*Event{after(1, 2, 1), after(2, 1, 1)}, ErrEventCycle},
+		{"placed after itself", []*Event{after(1, 1, 1)}, ErrEventCycle},
+		{"unknown event", []*Event{after(1, 9, 1)}, ErrInvalidEvent},
+		{"unknown calendar", []*Event{{Id: 1, CalendarId: 9, Date: Date{Month: 1, Day: 1}}}, ErrInvalidEvent},
+		{"invalid date", []*Event{dated(1, Date{Month: 2, Day: 21})}, ErrInvalidDate},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := place(test.events, calendars); !errors.Is(err, test.err) {
+				t.Errorf("place() error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	calendars := map[int]*Calendar{shire.Id: shire}
+	events := []*Event{
+		undated(1, 1),
+		after(2, 1, 0),
+		undated(3, 0),
+		dated(4, Date{Year: 1, Month: 1, Day: 1}),
+		// the same day as 4, after it
+		after(5, 4, 0),
+		dated(6, Date{Year: 0, Month: 1, Day: 1}),
+		after(7, 6, -1),
+	}
+	entries, err := place(events, calendars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed := []*Entry{}
+	for _, event := range events {
+		placed = append(placed, entries[event.Id])
+	}
+	ids := []int{}
+	for _, entry := range sortEntries(placed) {
+		ids = append(ids, entry.Event.Id)
+	}
+	if want := []int{7, 6, 4, 5, 3, 1, 2}; !slices.Equal(ids, want) {
+		t.Errorf("sorted events = %v, want %v", ids, want)
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	entry := func(id, chapterId int, moment int64, known bool) Entry {
+		return Entry{Event: Event{Id: id, ChapterId: chapterId}, Moment: moment, Known: known}
+	}
+	tests := []struct {
+		name      string
+		timeline  []Entry
+		narrative map[int]int
+		conflicts [][2]int
+	}{
+		{
+			name:      "in order",
+			timeline:  []Entry{entry(1, 10, 1, true), entry(2, 20, 2, true)},
+			narrative: map[int]int{10: 0, 20: 1},
+			conflicts: [][2]int{},
+		},
+		{
+			name:      "flashback",
+			timeline:  []Entry{entry(1, 20, 1, true), entry(2, 10, 2, true)},
+			narrative: map[int]int{10: 0, 20: 1},
+			conflicts: [][2]int{{1, 2}},
+		},
+		{
+			name:      "same chapter",
+			timeline:  []Entry{entry(1, 10, 1, true), entry(2, 10, 2, true)},
+			narrative: map[int]int{10: 0},
+			conflicts: [][2]int{},
+		},
+		{
+			name:      "latest event of the chapters before",
+			timeline:  []Entry{entry(1, 30, 1, true), entry(2, 10, 3, true), entry(3, 20, 2, true)},
+			narrative: map[int]int{10: 0, 20: 1, 30: 2},
+			conflicts: [][2]int{{3, 2}, {1, 2}},
+		},
+		{
+			name:      "unknown time and untold events",
+			timeline:  []Entry{entry(1, 20, 1, true), entry(2, 10, 0, false), entry(3, 0, 5, true)},
+			narrative: map[int]int{10: 0, 20: 1},
+			conflicts: [][2]int{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found := [][2]int{}
+			for _, conflict := range findConflicts(test.timeline, test.narrative) {
+				found = append(found, [2]int{conflict.Event.Event.Id, conflict.Later.Event.Id})
+			}
+			if !slices.Equal(found, test.conflicts) {
+				t.Errorf("conflicts = %v, want %v", found, test.conflicts)
+			}
+		})
+	}
+}
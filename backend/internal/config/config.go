@@ -0,0 +1,351 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const CONFIG_FILE_NAME = "config.json"
+const ENV_PREFIX = "TALENEST"
+
+var (
+	journalModes     = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	synchronousModes = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+type Config struct {
+	DataDir  string `mapstructure:"data_dir"`
+	CacheDir string `mapstructure:"cache_dir"`
+
+	// SQLitePath and DuckDBpath are the stores of the current library.
+	// Configurations written before libraries existed only have these.
+	SQLitePath string `mapstructure:"sqlite_path"`
+	DuckDBpath string `mapstructure:"duckdb_path"`
+	// BackupPath holds a directory of backups per library, see
+	// LibraryBackupPath.
+	BackupPath string `mapstructure:"backup_path"`
+	// AttachmentsPath holds a directory of attachments per library, see
+	// LibraryAttachmentsPath.
+	AttachmentsPath string `mapstructure:"attachments_path"`
+
+	Libraries      []LibraryConfig `mapstructure:"libraries"`
+	CurrentLibrary string          `mapstructure:"current_library"`
+	Recent         []string        `mapstructure:"recent_libraries"`
+
+	SQLite     SQLiteConfig     `mapstructure:"sqlite"`
+	Similarity SimilarityConfig `mapstructure:"similarity"`
+
+	configFile string
+}
+
+// SQLiteConfig holds the pragmas applied on every connection to the library
+// and the limits of the connection pool.
+type SQLiteConfig struct {
+	ForeignKeys bool   `mapstructure:"foreign_keys"`
+	JournalMode string `mapstructure:"journal_mode"`
+	Synchronous string `mapstructure:"synchronous"`
+	// BusyTimeout is how long a connection waits for a lock, in milliseconds.
+	BusyTimeout int `mapstructure:"busy_timeout"`
+	// CacheSize follows the pragma: pages when positive, KiB when negative.
+	CacheSize int `mapstructure:"cache_size"`
+
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+}
+
+// SimilarityConfig tunes the detection of similar tales.
+type SimilarityConfig struct {
+	// Threshold is the estimated share of word sequences two tales must have
+	// in common, from 0 to 1, to be similar.
+	Threshold float64 `mapstructure:"threshold"`
+	// AutoLink writes the similar tales found instead of proposing them.
+	AutoLink bool `mapstructure:"auto_link"`
+}
+
+// Load reads the configuration from configFile, or from the platform config
+// directory when it's empty. Every value can be overridden by a TALENEST_*
+// environment variable named after its key, e.g. TALENEST_SQLITE_BUSY_TIMEOUT,
+// and TALENEST_CONFIG names the file when configFile is empty.
+func Load(configFile string) (*Config, error) {
+	paths, err := DefaultPaths()
+	if err != nil {
+		return nil, fmt.Errorf("finding the app directories: %w", err)
+	}
+	if configFile == "" {
+		configFile = os.Getenv(ENV_PREFIX + "_CONFIG")
+	}
+	if configFile == "" {
+		configFile = filepath.Join(paths.ConfigDir, CONFIG_FILE_NAME)
+		if err := moveLegacyDataDir(legacyDataDir(), paths.DataDir); err != nil {
+			return nil, fmt.Errorf("moving the data of an earlier version: %w", err)
+		}
+		if err := moveLegacyConfig(legacyConfigFile(paths.DataDir), configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	v.SetConfigType("json")
+	v.SetEnvPrefix(ENV_PREFIX)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	v.SetDefault("data_dir", paths.DataDir)
+	v.SetDefault("cache_dir", paths.CacheDir)
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", configFile, err)
+	}
+
+	// the stores default to the data directory, which may be overridden
+	dataDir := v.GetString("data_dir")
+	v.SetDefault("sqlite_path", filepath.Join(dataDir, "data", "talenest.db"))
+	v.SetDefault("duckdb_path", filepath.Join(dataDir, "data", "talenest_analytics.duckdb"))
+	v.SetDefault("backup_path", filepath.Join(dataDir, "backups"))
+	v.SetDefault("attachments_path", filepath.Join(dataDir, "attachments"))
+	v.SetDefault("current_library", "")
+
+	v.SetDefault("sqlite.foreign_keys", true)
+	v.SetDefault("sqlite.journal_mode", "WAL")
+	v.SetDefault("sqlite.synchronous", "NORMAL")
+	v.SetDefault("sqlite.busy_timeout", 5000)
+	v.SetDefault("sqlite.cache_size", -16000)
+	v.SetDefault("sqlite.max_open_conns", 4)
+	v.SetDefault("sqlite.max_idle_conns", 4)
+	v.SetDefault("sqlite.conn_max_idle_time", "5m")
+
+	v.SetDefault("similarity.threshold", 0.3)
+	v.SetDefault("similarity.auto_link", false)
+
+	cfg := Config{configFile: configFile}
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", configFile, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration %s: %w", configFile, err)
+	}
+	if err := cfg.initDirectories(); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Libraries) == 0 {
+		// the stores configured before libraries become the default library
+		cfg.Libraries = []LibraryConfig{{
+			Name:       DEFAULT_LIBRARY,
+			SQLitePath: cfg.SQLitePath,
+			DuckDBPath: cfg.DuckDBpath,
+		}}
+		if err := cfg.SetCurrentLibrary(DEFAULT_LIBRARY); err != nil {
+			return nil, fmt.Errorf("saving the default library: %w", err)
+		}
+	}
+	if err := cfg.UseLibrary(cfg.CurrentLibrary); err != nil {
+		// the current library was removed from the file by hand
+		cfg.UseLibrary("")
+	}
+	return &cfg, nil
+}
+
+// ConfigFile returns the path of the configuration file.
+func (cfg *Config) ConfigFile() string {
+	return cfg.configFile
+}
+
+// LibraryAttachmentsPath returns the directory of the attachments of the
+// current library.
+func (cfg *Config) LibraryAttachmentsPath() string {
+	return filepath.Join(cfg.AttachmentsPath, cfg.libraryDirName())
+}
+
+// LibraryBackupPath returns the directory of the backups of the current
+// library, so a library is never restored from the backup of another.
+func (cfg *Config) LibraryBackupPath() string {
+	return filepath.Join(cfg.BackupPath, cfg.libraryDirName())
+}
+
+// libraryDirName names the directories of the current library. It's named
+// after the database of the library, which stays where it is when the
+// library is renamed.
+func (cfg *Config) libraryDirName() string {
+	sum := sha256.Sum256([]byte(cfg.SQLitePath))
+	name := slug(filepath.Base(filepath.Dir(cfg.SQLitePath)))
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
+
+// Validate checks the values of the configuration, normalizing the SQLite
+// modes to upper case.
+func (cfg *Config) Validate() error {
+	errs := []error{}
+	if !filepath.IsAbs(cfg.DataDir) {
+		errs = append(errs, fmt.Errorf("data_dir must be an absolute path, got %q", cfg.DataDir))
+	}
+	if !filepath.IsAbs(cfg.CacheDir) {
+		errs = append(errs, fmt.Errorf("cache_dir must be an absolute path, got %q", cfg.CacheDir))
+	}
+	if cfg.BackupPath == "" {
+		errs = append(errs, errors.New("backup_path is empty"))
+	}
+	if cfg.AttachmentsPath == "" {
+		errs = append(errs, errors.New("attachments_path is empty"))
+	}
+
+	cfg.SQLite.JournalMode = strings.ToUpper(cfg.SQLite.JournalMode)
+	if !slices.Contains(journalModes, cfg.SQLite.JournalMode) {
+		errs = append(errs, fmt.Errorf("sqlite.journal_mode must be one of %s, got %q",
+			strings.Join(journalModes, ", "), cfg.SQLite.JournalMode))
+	}
+	cfg.SQLite.Synchronous = strings.ToUpper(cfg.SQLite.Synchronous)
+	if !slices.Contains(synchronousModes, cfg.SQLite.Synchronous) {
+		errs = append(errs, fmt.Errorf("sqlite.synchronous must be one of %s, got %q",
+			strings.Join(synchronousModes, ", "), cfg.SQLite.Synchronous))
+	}
+	if cfg.SQLite.BusyTimeout < 0 {
+		errs = append(errs, fmt.Errorf("sqlite.busy_timeout must not be negative, got %d", cfg.SQLite.BusyTimeout))
+	}
+	if cfg.SQLite.MaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("sqlite.max_open_conns must not be negative, got %d", cfg.SQLite.MaxOpenConns))
+	}
+	if cfg.SQLite.MaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("sqlite.max_idle_conns must not be negative, got %d", cfg.SQLite.MaxIdleConns))
+	}
+	if cfg.SQLite.ConnMaxIdleTime < 0 {
+		errs = append(errs, fmt.Errorf("sqlite.conn_max_idle_time must not be negative, got %s", cfg.SQLite.ConnMaxIdleTime))
+	}
+	if cfg.Similarity.Threshold <= 0 || cfg.Similarity.Threshold > 1 {
+		errs = append(errs, fmt.Errorf("similarity.threshold must be above 0 and at most 1, got %g", cfg.Similarity.Threshold))
+	}
+
+	names := map[string]bool{}
+	for i, library := range cfg.Libraries {
+		name := strings.ToLower(strings.TrimSpace(library.Name))
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("libraries[%d] has no name", i))
+		case names[name]:
+			errs = append(errs, fmt.Errorf("libraries[%d]: the name %q is used twice", i, library.Name))
+		case library.SQLitePath == "":
+			errs = append(errs, fmt.Errorf("libraries[%d]: %q has no sqlite_path", i, library.Name))
+		}
+		names[name] = true
+	}
+	return errors.Join(errs...)
+}
+
+// initDirectories creates the directories of the application.
+func (cfg *Config) initDirectories() error {
+	directories := []string{
+		filepath.Dir(cfg.configFile),
+		filepath.Join(cfg.DataDir, "data"),
+		cfg.CacheDir,
+		cfg.BackupPath,
+		cfg.AttachmentsPath,
+	}
+	for _, directory := range directories {
+		if err := os.MkdirAll(directory, 0700); err != nil {
+			return fmt.Errorf("creating the app directories: %w", err)
+		}
+	}
+	return nil
+}
+
+// save writes values in the configuration file, leaving the other keys as
+// they are. The file is read again so that the defaults and the environment
+// overrides aren't written.
+func (cfg *Config) save(values map[string]any) error {
+	v := viper.New()
+	v.SetConfigFile(cfg.configFile)
+	v.SetConfigType("json")
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading %s: %w", cfg.configFile, err)
+	}
+	for key, value := range values {
+		v.Set(key, value)
+	}
+	return v.WriteConfigAs(cfg.configFile)
+}
+
+// moveLegacyDataDir moves the data directory of earlier versions, e.g. on
+// macOS, to dataDir unless it already exists. The paths of the legacy
+// configuration, which is moved next, are rewritten to the new directory.
+func moveLegacyDataDir(legacyDir, dataDir string) error {
+	if legacyDir == "" || legacyDir == dataDir {
+		return nil
+	}
+	if _, err := os.Stat(legacyDir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if _, err := os.Stat(dataDir); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dataDir), 0700); err != nil {
+		return err
+	}
+	if err := os.Rename(legacyDir, dataDir); err != nil {
+		return err
+	}
+	configFile := legacyConfigFile(dataDir)
+	content, err := os.ReadFile(configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configFile, rebasePaths(content, legacyDir, dataDir), 0600)
+}
+
+// rebasePaths replaces oldDir and the paths under it by newDir and the same
+// paths under it in a JSON configuration.
+func rebasePaths(content []byte, oldDir, newDir string) []byte {
+	quote := func(path string) []byte {
+		quoted, _ := json.Marshal(path)
+		return quoted
+	}
+	// without the closing quote, so only the start of the path is matched
+	prefix := func(dir string) []byte {
+		quoted := quote(dir + string(filepath.Separator))
+		return quoted[:len(quoted)-1]
+	}
+	content = bytes.ReplaceAll(content, quote(oldDir), quote(newDir))
+	return bytes.ReplaceAll(content, prefix(oldDir), prefix(newDir))
+}
+
+// moveLegacyConfig moves the configuration written in the data directory
+// by earlier versions, unless a configuration already exists.
+func moveLegacyConfig(legacyFile, configFile string) error {
+	content, err := os.ReadFile(legacyFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(configFile); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configFile), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(configFile, content, 0600); err != nil {
+		return err
+	}
+	return os.Remove(legacyFile)
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLoadMovesLegacyData upgrades a configuration written when the data
+// lived in ~/.local/share whatever the platform, which is now only the
+// default on Linux: the XDG data directory stands for the macOS one.
+func TestLoadMovesLegacyData(t *testing.T) {
+	home := t.TempDir()
+	dataHome := filepath.Join(home, "Library", "Application Support")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv(ENV_PREFIX+"_CONFIG", "")
+
+	legacyDir := filepath.Join(home, ".local", "share", APP_DIR_NAME)
+	legacy := map[string]string{
+		"sqlite_path": filepath.Join(legacyDir, "data", "talenest.db"),
+		"duckdb_path": filepath.Join(legacyDir, "data", "talenest_analytics.duckdb"),
+		"backup_path": filepath.Join(legacyDir, "backups"),
+	}
+	content, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string][]byte{
+		legacyConfigFile(legacyDir): content,
+		legacy["sqlite_path"]:       []byte("tales"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataDir := filepath.Join(dataHome, APP_DIR_NAME)
+	if cfg.DataDir != dataDir {
+		t.Fatalf("the data directory is %s, want %s", cfg.DataDir, dataDir)
+	}
+	if want := filepath.Join(dataDir, "data", "talenest.db"); cfg.SQLitePath != want {
+		t.Errorf("the library is %s, want %s", cfg.SQLitePath, want)
+	}
+	if want := filepath.Join(dataDir, "backups"); cfg.BackupPath != want {
+		t.Errorf("the backups are in %s, want %s", cfg.BackupPath, want)
+	}
+	if content, err := os.ReadFile(cfg.SQLitePath); err != nil || string(content) != "tales" {
+		t.Errorf("the library wasn't moved: %q, %v", content, err)
+	}
+	if _, err := os.Stat(legacyDir); !os.IsNotExist(err) {
+		t.Errorf("the legacy directory is still there: %v", err)
+	}
+	if _, err := os.Stat(legacyConfigFile(dataDir)); !os.IsNotExist(err) {
+		t.Errorf("the legacy configuration wasn't moved: %v", err)
+	}
+}
+
+func TestMoveLegacyDataDirKeepsExistingData(t *testing.T) {
+	root := t.TempDir()
+	legacyDir := filepath.Join(root, "legacy")
+	dataDir := filepath.Join(root, "data")
+	for _, dir := range []string{legacyDir, dataDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := moveLegacyDataDir(legacyDir, dataDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(legacyDir); err != nil {
+		t.Errorf("the legacy directory was moved over existing data: %v", err)
+	}
+}
+
+func TestRebasePaths(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`{"sqlite_path":"/old/data/talenest.db"}`, `{"sqlite_path":"/new dir/data/talenest.db"}`},
+		{`{"data_dir":"/old"}`, `{"data_dir":"/new dir"}`},
+		{`{"sqlite_path":"/older/talenest.db"}`, `{"sqlite_path":"/older/talenest.db"}`},
+		{`{"sqlite_path":"/elsewhere/old/talenest.db"}`, `{"sqlite_path":"/elsewhere/old/talenest.db"}`},
+	}
+	for _, test := range tests {
+		if got := string(rebasePaths([]byte(test.content), "/old", "/new dir")); got != test.want {
+			t.Errorf("rebasePaths(%s) = %s, want %s", test.content, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const DEFAULT_LIBRARY = "Default"
+const MAX_RECENT_LIBRARIES = 10
+
+// LibraryConfig is a library registered in the configuration: a SQLite
+// database and its analytics store.
+type LibraryConfig struct {
+	Name       string `mapstructure:"name" json:"name"`
+	SQLitePath string `mapstructure:"sqlite_path" json:"sqlitePath"`
+	DuckDBPath string `mapstructure:"duckdb_path" json:"duckdbPath"`
+}
+
+// Library returns the library registered with name, case insensitively.
+func (cfg *Config) Library(name string) (LibraryConfig, bool) {
+	index := cfg.libraryIndex(name)
+	if index < 0 {
+		return LibraryConfig{}, false
+	}
+	return cfg.Libraries[index], true
+}
+
+func (cfg *Config) libraryIndex(name string) int {
+	return slices.IndexFunc(cfg.Libraries, func(library LibraryConfig) bool {
+		return strings.EqualFold(library.Name, name)
+	})
+}
+
+// RecentLibraries returns the registered libraries last opened, the most
+// recent first.
+func (cfg *Config) RecentLibraries() []LibraryConfig {
+	recent := []LibraryConfig{}
+	for _, name := range cfg.Recent {
+		if library, ok := cfg.Library(name); ok {
+			recent = append(recent, library)
+		}
+	}
+	return recent
+}
+
+// CreateLibrary registers a new library stored in its own directory under
+// the app data directory. The files are created when it's opened.
+func (cfg *Config) CreateLibrary(name string) (LibraryConfig, error) {
+	name, err := cfg.checkLibraryName(name)
+	if err != nil {
+		return LibraryConfig{}, err
+	}
+
+	librariesDir := filepath.Join(cfg.DataDir, "data", "libraries")
+	dir, err := uniqueDir(librariesDir, slug(name))
+	if err != nil {
+		return LibraryConfig{}, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return LibraryConfig{}, err
+	}
+	library := LibraryConfig{
+		Name:       name,
+		SQLitePath: filepath.Join(dir, "talenest.db"),
+		DuckDBPath: filepath.Join(dir, "talenest_analytics.duckdb"),
+	}
+	cfg.Libraries = append(cfg.Libraries, library)
+	if err := cfg.saveLibraries(); err != nil {
+		cfg.Libraries = cfg.Libraries[:len(cfg.Libraries)-1]
+		return LibraryConfig{}, err
+	}
+	return library, nil
+}
+
+// AddLibrary registers an existing database as a library named after its
+// file, or returns the library already using it.
+func (cfg *Config) AddLibrary(sqlitePath string) (LibraryConfig, error) {
+	sqlitePath, err := filepath.Abs(sqlitePath)
+	if err != nil {
+		return LibraryConfig{}, err
+	}
+	for _, library := range cfg.Libraries {
+		if library.SQLitePath == sqlitePath {
+			return library, nil
+		}
+	}
+
+	base := strings.TrimSuffix(filepath.Base(sqlitePath), filepath.Ext(sqlitePath))
+	name := base
+	for i := 2; cfg.libraryIndex(name) >= 0; i++ {
+		name = fmt.Sprintf("%s (%d)", base, i)
+	}
+	library := LibraryConfig{
+		Name:       name,
+		SQLitePath: sqlitePath,
+		DuckDBPath: strings.TrimSuffix(sqlitePath, filepath.Ext(sqlitePath)) + "_analytics.duckdb",
+	}
+	cfg.Libraries = append(cfg.Libraries, library)
+	if err := cfg.saveLibraries(); err != nil {
+		cfg.Libraries = cfg.Libraries[:len(cfg.Libraries)-1]
+		return LibraryConfig{}, err
+	}
+	return library, nil
+}
+
+// RenameLibrary renames a library, its files are left where they are.
+func (cfg *Config) RenameLibrary(name, newName string) error {
+	index := cfg.libraryIndex(name)
+	if index < 0 {
+		return fmt.Errorf("unknown library %q", name)
+	}
+	oldName := cfg.Libraries[index].Name
+	if !strings.EqualFold(oldName, strings.TrimSpace(newName)) {
+		var err error
+		if newName, err = cfg.checkLibraryName(newName); err != nil {
+			return err
+		}
+	}
+	newName = strings.TrimSpace(newName)
+
+	current, recent := cfg.CurrentLibrary, slices.Clone(cfg.Recent)
+	cfg.Libraries[index].Name = newName
+	if strings.EqualFold(cfg.CurrentLibrary, oldName) {
+		cfg.CurrentLibrary = newName
+	}
+	for i, recentName := range cfg.Recent {
+		if strings.EqualFold(recentName, oldName) {
+			cfg.Recent[i] = newName
+		}
+	}
+	if err := cfg.saveLibraries(); err != nil {
+		cfg.Libraries[index].Name = oldName
+		cfg.CurrentLibrary, cfg.Recent = current, recent
+		return err
+	}
+	return nil
+}
+
+// UseLibrary makes name the current library without saving it, e.g. until
+// it's known to open. An empty name means no library.
+func (cfg *Config) UseLibrary(name string) error {
+	library := LibraryConfig{}
+	if name != "" {
+		var ok bool
+		if library, ok = cfg.Library(name); !ok {
+			return fmt.Errorf("unknown library %q", name)
+		}
+	}
+	cfg.CurrentLibrary = library.Name
+	cfg.SQLitePath = library.SQLitePath
+	cfg.DuckDBpath = library.DuckDBPath
+	return nil
+}
+
+// SetCurrentLibrary makes name the library opened at startup and moves it
+// first in the recent libraries. An empty name means no library.
+func (cfg *Config) SetCurrentLibrary(name string) error {
+	current, recent := cfg.CurrentLibrary, cfg.Recent
+	if err := cfg.UseLibrary(name); err != nil {
+		return err
+	}
+	if cfg.CurrentLibrary != "" {
+		cfg.Recent = []string{cfg.CurrentLibrary}
+		for _, recentName := range recent {
+			if !strings.EqualFold(recentName, cfg.CurrentLibrary) && len(cfg.Recent) < MAX_RECENT_LIBRARIES {
+				cfg.Recent = append(cfg.Recent, recentName)
+			}
+		}
+	}
+	if err := cfg.saveLibraries(); err != nil {
+		cfg.Recent = recent
+		cfg.UseLibrary(current)
+		return err
+	}
+	return nil
+}
+
+func (cfg *Config) checkLibraryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("the library name is empty")
+	}
+	if cfg.libraryIndex(name) >= 0 {
+		return "", fmt.Errorf("a library named %q already exists", name)
+	}
+	return name, nil
+}
+
+// saveLibraries writes the libraries in the configuration file. They are
+// written as maps since viper writes structs with their json tags.
+func (cfg *Config) saveLibraries() error {
+	libraries := make([]map[string]any, len(cfg.Libraries))
+	for i, library := range cfg.Libraries {
+		libraries[i] = map[string]any{
+			"name":        library.Name,
+			"sqlite_path": library.SQLitePath,
+			"duckdb_path": library.DuckDBPath,
+		}
+	}
+	return cfg.save(map[string]any{
+		"libraries":        libraries,
+		"current_library":  cfg.CurrentLibrary,
+		"recent_libraries": cfg.Recent,
+	})
+}
+
+// slug turns a library name in a directory name.
+func slug(name string) string {
+	var builder strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			builder.WriteRune(r)
+			dash = false
+		} else if !dash && builder.Len() > 0 {
+			builder.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(builder.String(), "-")
+	if slug == "" {
+		return "library"
+	}
+	return slug
+}
+
+func uniqueDir(parent, name string) (string, error) {
+	dir := filepath.Join(parent, name)
+	for i := 2; ; i++ {
+		_, err := os.Stat(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return dir, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(parent, fmt.Sprintf("%s-%d", name, i))
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const APP_DIR_NAME = "Talenest"
+
+// Paths are the directories of the application on this platform.
+type Paths struct {
+	ConfigDir string
+	DataDir   string
+	CacheDir  string
+}
+
+// DefaultPaths returns the platform directories:
+//   - Linux and BSDs: $XDG_CONFIG_HOME, $XDG_DATA_HOME and $XDG_CACHE_HOME,
+//     defaulting to ~/.config, ~/.local/share and ~/.cache
+//   - macOS: ~/Library/Application Support and ~/Library/Caches
+//   - Windows: %APPDATA% and %LOCALAPPDATA%
+func DefaultPaths() (Paths, error) {
+	switch runtime.GOOS {
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return Paths{}, errors.New("%APPDATA% is not set")
+		}
+		localAppData := os.Getenv("LOCALAPPDATA")
+		if localAppData == "" {
+			localAppData = appData
+		}
+		return Paths{
+			ConfigDir: filepath.Join(appData, APP_DIR_NAME),
+			DataDir:   filepath.Join(appData, APP_DIR_NAME),
+			CacheDir:  filepath.Join(localAppData, APP_DIR_NAME, "cache"),
+		}, nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Paths{}, err
+		}
+		support := filepath.Join(home, "Library", "Application Support", APP_DIR_NAME)
+		return Paths{
+			ConfigDir: support,
+			DataDir:   support,
+			CacheDir:  filepath.Join(home, "Library", "Caches", APP_DIR_NAME),
+		}, nil
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Paths{}, err
+		}
+		return Paths{
+			ConfigDir: filepath.Join(xdgDir("XDG_CONFIG_HOME", home, ".config"), APP_DIR_NAME),
+			DataDir:   filepath.Join(xdgDir("XDG_DATA_HOME", home, ".local", "share"), APP_DIR_NAME),
+			CacheDir:  filepath.Join(xdgDir("XDG_CACHE_HOME", home, ".cache"), APP_DIR_NAME),
+		}, nil
+	}
+}
+
+// xdgDir returns the directory of an XDG variable, which must be absolute
+// to be used, or its default under home.
+func xdgDir(variable, home string, defaultDir ...string) string {
+	if dir := os.Getenv(variable); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(append([]string{home}, defaultDir...)...)
+}
+
+// legacyDataDir is the data directory of earlier versions, which used
+// ~/.local/share on every platform but Windows whatever the XDG variables.
+func legacyDataDir() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", APP_DIR_NAME)
+}
+
+// legacyConfigFile is where the configuration was kept before the platform
+// config directory was used.
+func legacyConfigFile(dataDir string) string {
+	return filepath.Join(dataDir, "config", CONFIG_FILE_NAME)
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+const PREFERENCES_FILE_NAME = "preferences.json"
+
+var (
+	themes        = []string{"system", "light", "dark"}
+	exportFormats = []string{"markdown", "html", "txt", "epub"}
+)
+
+// Preferences are the user choices edited from the app, kept apart from the
+// configuration so they can be changed while it runs.
+type Preferences struct {
+	Theme          string `mapstructure:"theme" json:"theme"`
+	EditorFont     string `mapstructure:"editor_font" json:"editorFont"`
+	EditorFontSize int    `mapstructure:"editor_font_size" json:"editorFontSize"`
+	// AutosaveInterval is in seconds, 0 disables autosave.
+	AutosaveInterval    int    `mapstructure:"autosave_interval" json:"autosaveInterval"`
+	DefaultStatusId     int    `mapstructure:"default_status_id" json:"defaultStatusId"`
+	DefaultExportFormat string `mapstructure:"default_export_format" json:"defaultExportFormat"`
+}
+
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Theme:               "system",
+		EditorFont:          "serif",
+		EditorFontSize:      16,
+		AutosaveInterval:    30,
+		DefaultStatusId:     1,
+		DefaultExportFormat: "markdown",
+	}
+}
+
+func (preferences Preferences) Validate() error {
+	errs := []error{}
+	if !slices.Contains(themes, preferences.Theme) {
+		errs = append(errs, fmt.Errorf("theme must be one of %s, got %q",
+			strings.Join(themes, ", "), preferences.Theme))
+	}
+	if strings.TrimSpace(preferences.EditorFont) == "" {
+		errs = append(errs, errors.New("editor_font is empty"))
+	}
+	if preferences.EditorFontSize < 8 || preferences.EditorFontSize > 72 {
+		errs = append(errs, fmt.Errorf("editor_font_size must be between 8 and 72, got %d", preferences.EditorFontSize))
+	}
+	if preferences.AutosaveInterval < 0 || preferences.AutosaveInterval > 3600 {
+		errs = append(errs, fmt.Errorf("autosave_interval must be between 0 and 3600 seconds, got %d", preferences.AutosaveInterval))
+	}
+	if preferences.DefaultStatusId < 1 {
+		errs = append(errs, fmt.Errorf("default_status_id must be a status id, got %d", preferences.DefaultStatusId))
+	}
+	if !slices.Contains(exportFormats, preferences.DefaultExportFormat) {
+		errs = append(errs, fmt.Errorf("default_export_format must be one of %s, got %q",
+			strings.Join(exportFormats, ", "), preferences.DefaultExportFormat))
+	}
+	return errors.Join(errs...)
+}
+
+// PreferencesStore keeps the preferences in sync with their file, which
+// may also be edited by hand while the app runs, see Watch.
+type PreferencesStore struct {
+	mu          sync.Mutex
+	path        string
+	preferences Preferences
+	watcher     *fsnotify.Watcher
+}
+
+// PreferencesFile returns the preferences file, next to the configuration.
+func (cfg *Config) PreferencesFile() string {
+	return filepath.Join(filepath.Dir(cfg.configFile), PREFERENCES_FILE_NAME)
+}
+
+// LoadPreferences reads the preferences from path. The store is usable even
+// when the file is invalid: it keeps the defaults and the error is returned
+// to be reported.
+func LoadPreferences(path string) (*PreferencesStore, error) {
+	store := &PreferencesStore{
+		path:        path,
+		preferences: DefaultPreferences(),
+	}
+	preferences, err := readPreferences(path)
+	if err != nil {
+		return store, err
+	}
+	store.preferences = preferences
+	return store, nil
+}
+
+func readPreferences(path string) (Preferences, error) {
+	defaults := DefaultPreferences()
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("json")
+	v.SetDefault("theme", defaults.Theme)
+	v.SetDefault("editor_font", defaults.EditorFont)
+	v.SetDefault("editor_font_size", defaults.EditorFontSize)
+	v.SetDefault("autosave_interval", defaults.AutosaveInterval)
+	v.SetDefault("default_status_id", defaults.DefaultStatusId)
+	v.SetDefault("default_export_format", defaults.DefaultExportFormat)
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Preferences{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var preferences Preferences
+	if err := v.Unmarshal(&preferences); err != nil {
+		return Preferences{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err := preferences.Validate(); err != nil {
+		return Preferences{}, fmt.Errorf("invalid preferences %s: %w", path, err)
+	}
+	return preferences, nil
+}
+
+func (store *PreferencesStore) Get() Preferences {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.preferences
+}
+
+// Set validates and saves the preferences.
+func (store *PreferencesStore) Set(preferences Preferences) error {
+	if err := preferences.Validate(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	v := viper.New()
+	v.SetConfigType("json")
+	v.Set("theme", preferences.Theme)
+	v.Set("editor_font", preferences.EditorFont)
+	v.Set("editor_font_size", preferences.EditorFontSize)
+	v.Set("autosave_interval", preferences.AutosaveInterval)
+	v.Set("default_status_id", preferences.DefaultStatusId)
+	v.Set("default_export_format", preferences.DefaultExportFormat)
+	// written aside then renamed so the watcher never reads half a file
+	tmpPath := filepath.Join(filepath.Dir(store.path), ".preferences-tmp.json")
+	if err := v.WriteConfigAs(tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, store.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	store.preferences = preferences
+	return nil
+}
+
+// Watch reloads the preferences when their file changes and calls onChange
+// with the new ones. Invalid files are logged and ignored, the preferences
+// stay as they were.
+func (store *PreferencesStore) Watch(onChange func(Preferences)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// the directory is watched since editors often save by replacing the
+	// file
+	if err := watcher.Add(filepath.Dir(store.path)); err != nil {
+		watcher.Close()
+		return err
+	}
+	store.mu.Lock()
+	store.watcher = watcher
+	store.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(store.path) ||
+					!event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+				if preferences, changed := store.reload(); changed {
+					onChange(preferences)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("watching %s: %v", store.path, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reload reads the file again and reports if the preferences changed,
+// which isn't the case after Set wrote them.
+func (store *PreferencesStore) reload() (Preferences, bool) {
+	preferences, err := readPreferences(store.path)
+	if err != nil {
+		log.Printf("reloading the preferences: %v", err)
+		return Preferences{}, false
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if preferences == store.preferences {
+		return preferences, false
+	}
+	store.preferences = preferences
+	return preferences, true
+}
+
+// Close stops watching the file.
+func (store *PreferencesStore) Close() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.watcher == nil {
+		return nil
+	}
+	err := store.watcher.Close()
+	store.watcher = nil
+	return err
+}
@@ -0,0 +1,99 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// journalSuffixes are the files SQLite keeps next to a database.
+var journalSuffixes = []string{"-journal", "-wal", "-shm"}
+
+type Backup struct {
+	Path    string    `json:"path"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+}
+
+// ListBackups returns the backups found in backupDir, newest first.
+func ListBackups(backupDir string) ([]Backup, error) {
+	entries, err := os.ReadDir(backupDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".db" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, Backup{
+			Path:    filepath.Join(backupDir, entry.Name()),
+			Name:    entry.Name(),
+			Created: info.ModTime(),
+			Size:    info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// SetAside renames the database and its journal files so a new database
+// can take its place, and returns the new path of the database. Nothing is
+// deleted: a broken library may still be recovered by hand.
+func SetAside(dbPath string) (string, error) {
+	asidePath := fmt.Sprintf("%s.aside-%s", dbPath, time.Now().Format("20060102-150405"))
+	if err := os.Rename(dbPath, asidePath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, suffix := range journalSuffixes {
+		err := os.Rename(dbPath+suffix, asidePath+suffix)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return asidePath, err
+		}
+	}
+	return asidePath, nil
+}
+
+// RestoreBackup replaces the database with a copy of backupPath, setting
+// the current database aside first. The database must be closed.
+func RestoreBackup(backupPath, dbPath string) (string, error) {
+	backup, err := os.Open(backupPath)
+	if err != nil {
+		return "", err
+	}
+	defer backup.Close()
+
+	asidePath, err := SetAside(dbPath)
+	if err != nil {
+		return asidePath, err
+	}
+
+	restored, err := os.OpenFile(dbPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return asidePath, err
+	}
+	if _, err := io.Copy(restored, backup); err != nil {
+		restored.Close()
+		return asidePath, err
+	}
+	return asidePath, restored.Close()
+}
@@ -3,11 +3,11 @@ package data
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"talenest/backend/internal/config"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "modernc.org/sqlite"
 )
 
@@ -15,67 +15,117 @@ type DatabaseConnector struct {
 	db *sql.DB
 }
 
-func NewDatabaseConnector(driver, dbPath, migrationPath string) *DatabaseConnector {
-	db, err := sql.Open(driver, dbPath)
-	if err != nil {
-		fmt.Println(err)
-		// Handle error
-		return nil
-	}
-	pingErr := db.Ping()
-	if pingErr != nil {
-		fmt.Println(pingErr)
-		// Handle error
+// NewDatabaseConnector opens the database with the given pragmas and pool
+// limits and applies the pending migrations, backing up the database in
+// backupDir first. Failures are reported as a *StartupError.
+func NewDatabaseConnector(driver, dbPath, backupDir string, options config.SQLiteConfig) (*DatabaseConnector, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0700); err != nil {
+		return nil, newStartupError(dbPath, startupCause(err), err)
 	}
 
-	m, err := migrate.New(
-		"file://"+migrationPath,
-		driver+"://"+dbPath)
+	db, err := sql.Open(driver, sqliteDSN(dbPath, options))
 	if err != nil {
-		log.Fatal(err)
+		return nil, newStartupError(dbPath, CauseUnknown, err)
+	}
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	db.SetMaxIdleConns(options.MaxIdleConns)
+	db.SetConnMaxIdleTime(options.ConnMaxIdleTime)
+	if err := checkDatabase(db); err != nil {
+		db.Close()
+		return nil, newStartupError(dbPath, startupCause(err), err)
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal(err)
+	if err := migrateDatabase(dbPath, backupDir); err != nil {
+		db.Close()
+		return nil, newStartupError(dbPath, startupCause(err), err)
 	}
 	// connected
 	return &DatabaseConnector{
 		db: db,
+	}, nil
+}
+
+// sqliteDSN adds the pragmas to the database path. The driver runs them on
+// every new connection of the pool, since most of them (foreign_keys,
+// busy_timeout, cache_size) only last as long as the connection.
+// The options are expected to be validated, see config.Config.Validate.
+func sqliteDSN(dbPath string, options config.SQLiteConfig) string {
+	foreignKeys := 0
+	if options.ForeignKeys {
+		foreignKeys = 1
 	}
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", options.BusyTimeout))
+	query.Add("_pragma", fmt.Sprintf("foreign_keys(%d)", foreignKeys))
+	query.Add("_pragma", fmt.Sprintf("journal_mode(%s)", options.JournalMode))
+	query.Add("_pragma", fmt.Sprintf("synchronous(%s)", options.Synchronous))
+	query.Add("_pragma", fmt.Sprintf("cache_size(%d)", options.CacheSize))
+	// write transactions take the lock when they begin rather than failing
+	// with SQLITE_BUSY when a read is upgraded to a write
+	query.Set("_txlock", "immediate")
+	return dbPath + "?" + query.Encode()
 }
 
-func (dbConnector *DatabaseConnector) Query(query string) *sql.Rows {
-	rows, err := dbConnector.db.Query(query)
+// checkDatabase makes sure the file can be opened and read as a database.
+func checkDatabase(db *sql.DB) error {
+	if err := db.Ping(); err != nil {
+		return err
+	}
+	var tables int
+	return db.QueryRow("SELECT count(*) FROM sqlite_master").Scan(&tables)
+}
+
+func migrateDatabase(dbPath, backupDir string) error {
+	migrator, err := NewMigrator(dbPath, backupDir)
 	if err != nil {
-		fmt.Println(err)
-		return &sql.Rows{}
+		return err
 	}
-	return rows
+	defer migrator.Close()
+	return migrator.Up()
+}
+
+func (dbConnector *DatabaseConnector) Close() error {
+	return dbConnector.db.Close()
 }
 
-func (dbConnector *DatabaseConnector) InsertQuery(query string, args ...Value) (int64, error) {
+// Query runs a query built by one of the query builders with its bound
+// arguments.
+func (dbConnector *DatabaseConnector) Query(query string, args []any) (*sql.Rows, error) {
+	rows, err := dbConnector.db.Query(query, args...)
+	return rows, translateError(err)
+}
+
+func (dbConnector *DatabaseConnector) InsertQuery(query string, args []any) (int64, error) {
 	result, err := dbConnector.ExecuteQuery(query, args)
 	if err != nil {
-		fmt.Println(err)
 		return 0, err
 	}
 	return result.LastInsertId()
 }
 
-func (dbConnector *DatabaseConnector) ExecuteQuery(query string, args ...[]Value) (sql.Result, error) {
-	result, err := dbConnector.db.Exec(query, args)
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-	return result, err
+func (dbConnector *DatabaseConnector) ExecuteQuery(query string, args []any) (sql.Result, error) {
+	result, err := dbConnector.db.Exec(query, args...)
+	return result, translateError(err)
 }
 
 func (dbConnector *DatabaseConnector) PrepareQuery(query string) (*sql.Stmt, error) {
 	statement, err := dbConnector.db.Prepare(query)
 	if err != nil {
-		// TODO: Handle error
-		fmt.Printf("Error preparing query: %v\n", err)
+		return nil, fmt.Errorf("preparing %q: %w", query, translateError(err))
 	}
 	return statement, nil
 }
+
+// Transaction runs fn in a transaction, committed if fn succeeds and rolled
+// back otherwise. Repositories take part in it through WithTx.
+func (dbConnector *DatabaseConnector) Transaction(fn func(tx *sql.Tx) error) error {
+	tx, err := dbConnector.db.Begin()
+	if err != nil {
+		return translateError(err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return translateError(tx.Commit())
+}
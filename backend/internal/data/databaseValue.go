@@ -2,17 +2,47 @@ package data
 
 import (
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"talenest/backend/internal/utils"
 	"time"
 )
 
+// Value is a value used by the query builders. Builders never inline it in
+// the SQL: it's rendered as a "?" placeholder and its driver.Value is
+// returned among the query arguments. GetValueString is only meant for
+// display.
 type Value interface {
 	GetValueString() string
 	Value() (driver.Value, error)
 }
 
+// bindValue renders value in a query, appending its driver value to args.
+// Tokens are written verbatim and add no argument. Values are checked by
+// the builders when they are set, so conversion errors can't happen here.
+func bindValue(value Value, args *[]any) string {
+	if token, ok := value.(*TokenValue); ok {
+		return token.token
+	}
+	driverValue, _ := value.Value()
+	*args = append(*args, driverValue)
+	return "?"
+}
+
+func checkValues(values ...Value) error {
+	for _, value := range values {
+		if value == nil {
+			return errors.New("query values can't be nil")
+		}
+		if _, err := value.Value(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type IntValue struct {
 	val int
 }
@@ -32,7 +62,7 @@ func (value *IntValue) String() string {
 }
 
 func (value *IntValue) Value() (driver.Value, error) {
-	return value.val, nil
+	return int64(value.val), nil
 }
 
 type FloatValue struct {
@@ -54,7 +84,7 @@ func (value *FloatValue) String() string {
 }
 
 func (value *FloatValue) Value() (driver.Value, error) {
-	return value.val, nil
+	return float64(value.val), nil
 }
 
 type StringValue struct {
@@ -68,7 +98,7 @@ func NewStringValue(val string) *StringValue {
 }
 
 func (value *StringValue) GetValueString() string {
-	return fmt.Sprintf("'%s'", value.val)
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value.val, "'", "''"))
 }
 
 func (value *StringValue) String() string {
@@ -128,5 +158,5 @@ func (value *TimeValue) String() string {
 }
 
 func (value *TimeValue) Value() (driver.Value, error) {
-	return value.val, nil
+	return utils.CleanTime(value.val), nil
 }
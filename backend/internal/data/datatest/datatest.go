@@ -0,0 +1,32 @@
+// Package datatest opens databases for the tests of the repositories.
+package datatest
+
+import (
+	"path/filepath"
+	"talenest/backend/internal/config"
+	"talenest/backend/internal/data"
+	"testing"
+)
+
+// Open opens a migrated library database in a temporary directory, closed
+// when the test ends.
+func Open(t *testing.T) *data.DatabaseConnector {
+	t.Helper()
+	dir := t.TempDir()
+	dbConn, err := data.NewDatabaseConnector("sqlite", filepath.Join(dir, "talenest.db"), filepath.Join(dir, "backups"),
+		config.SQLiteConfig{
+			ForeignKeys:  true,
+			JournalMode:  "WAL",
+			Synchronous:  "NORMAL",
+			BusyTimeout:  1000,
+			MaxOpenConns: 4,
+			MaxIdleConns: 4,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		dbConn.Close()
+	})
+	return dbConn
+}
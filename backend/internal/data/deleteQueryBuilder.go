@@ -5,9 +5,8 @@ import (
 )
 
 type DeleteQueryBuilder struct {
-	query     strings.Builder
+	whereClause
 	tableName string
-	whereList []whereItem
 }
 
 func NewDeleteQueryBuilder(tableName string) *DeleteQueryBuilder {
@@ -16,22 +15,18 @@ func NewDeleteQueryBuilder(tableName string) *DeleteQueryBuilder {
 	}
 }
 
-func (builder *DeleteQueryBuilder) SetWhere(tableName string, col Column, operator string, value Value, logicOperator string) {
-	item := newWhereItem(tableName, col, operator, value, logicOperator)
-	builder.whereList = append(builder.whereList, *item)
-}
-
-func (builder *DeleteQueryBuilder) Build() string {
-	builder.query.WriteString("DELETE FROM ")
-	builder.query.WriteString(builder.tableName)
-	builder.query.WriteRune(' ')
+func (builder *DeleteQueryBuilder) Build() (string, []any) {
+	args := []any{}
+	var query strings.Builder
+	query.WriteString("DELETE FROM ")
+	query.WriteString(builder.tableName)
 
 	// Adding where
-	if builder.whereList != nil || len(builder.whereList) > 0 {
-		builder.query.WriteString("WHERE ")
-		builder.query.WriteString(whereString(builder.whereList))
+	if builder.hasConditions() {
+		query.WriteString(" WHERE ")
+		query.WriteString(whereString(builder.conditions, &args))
 	}
 
-	builder.query.WriteRune(';')
-	return builder.query.String()
+	query.WriteRune(';')
+	return query.String(), args
 }
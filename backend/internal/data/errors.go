@@ -0,0 +1,162 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	// ErrNotFound is returned when the requested row doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a row collides with an existing one,
+	// e.g. on a UNIQUE or PRIMARY KEY constraint.
+	ErrConflict = errors.New("conflict")
+	// ErrConstraint is returned when any table constraint is violated.
+	// The details are available through ConstraintError.
+	ErrConstraint = errors.New("constraint violation")
+	// ErrStale is returned when a row changed since it was read.
+	ErrStale = errors.New("stale data")
+	// ErrBusy is returned when the database is locked by another connection.
+	ErrBusy = errors.New("database busy")
+)
+
+// ConstraintError reports the SQLite constraint violated by a statement.
+type ConstraintError struct {
+	// Kind is the type of constraint: UNIQUE, PRIMARY KEY, FOREIGN KEY,
+	// NOT NULL or CHECK.
+	Kind string
+	// Constraint names the violated constraint as reported by SQLite,
+	// e.g. "tag.name". It's empty for foreign keys.
+	Constraint string
+	Err        error
+}
+
+func (err *ConstraintError) Error() string {
+	if err.Constraint == "" {
+		return fmt.Sprintf("%s constraint failed", err.Kind)
+	}
+	return fmt.Sprintf("%s constraint failed on %s", err.Kind, err.Constraint)
+}
+
+func (err *ConstraintError) Unwrap() error {
+	return err.Err
+}
+
+func (err *ConstraintError) Is(target error) bool {
+	switch target {
+	case ErrConstraint:
+		return true
+	case ErrConflict:
+		return err.Kind == "UNIQUE" || err.Kind == "PRIMARY KEY"
+	}
+	return false
+}
+
+// StaleError is returned when updating an entity whose stored version
+// changed since it was read. Current holds the stored entity so the caller
+// can merge the changes or overwrite it by updating again with its version.
+type StaleError[T any] struct {
+	Table   string
+	Id      int
+	Version int
+	Current *T
+}
+
+func (err *StaleError[T]) Error() string {
+	return fmt.Sprintf("%s %d was modified since version %d", err.Table, err.Id, err.Version)
+}
+
+func (err *StaleError[T]) Is(target error) bool {
+	return target == ErrStale || target == ErrConflict
+}
+
+// OpError wraps an error with the operation and the table it occurred on.
+type OpError struct {
+	Op    string
+	Table string
+	Err   error
+}
+
+func (err *OpError) Error() string {
+	if err.Table == "" {
+		return fmt.Sprintf("%s: %v", err.Op, err.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", err.Op, err.Table, err.Err)
+}
+
+func (err *OpError) Unwrap() error {
+	return err.Err
+}
+
+var constraintKinds = map[int]string{
+	sqlite3.SQLITE_CONSTRAINT_UNIQUE:     "UNIQUE",
+	sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY: "PRIMARY KEY",
+	sqlite3.SQLITE_CONSTRAINT_ROWID:      "PRIMARY KEY",
+	sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY: "FOREIGN KEY",
+	sqlite3.SQLITE_CONSTRAINT_NOTNULL:    "NOT NULL",
+	sqlite3.SQLITE_CONSTRAINT_CHECK:      "CHECK",
+	sqlite3.SQLITE_CONSTRAINT_TRIGGER:    "TRIGGER",
+}
+
+var constraintNameRegexp = regexp.MustCompile(`[A-Z ]+ constraint failed(?:: (.+?))? \(\d+\)$`)
+
+// sqliteCode returns the extended result code of a SQLite error.
+func sqliteCode(err error) (int, bool) {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return 0, false
+	}
+	return sqliteErr.Code(), true
+}
+
+// translateError maps driver errors to the errors of this package, keeping
+// the original error in the chain.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	code, ok := sqliteCode(err)
+	if !ok {
+		return err
+	}
+
+	switch code & 0xff {
+	case sqlite3.SQLITE_CONSTRAINT:
+		kind, ok := constraintKinds[code]
+		if !ok {
+			kind = "UNKNOWN"
+		}
+		constraint := ""
+		if match := constraintNameRegexp.FindStringSubmatch(err.Error()); match != nil {
+			constraint = match[1]
+		}
+		return &ConstraintError{
+			Kind:       kind,
+			Constraint: constraint,
+			Err:        err,
+		}
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return fmt.Errorf("%w: %w", ErrBusy, err)
+	}
+	return err
+}
+
+// wrapError translates err and adds the operation context.
+func wrapError(op string, table string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &OpError{
+		Op:    op,
+		Table: table,
+		Err:   translateError(err),
+	}
+}
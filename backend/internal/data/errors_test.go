@@ -0,0 +1,102 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestTranslateConstraintErrors(t *testing.T) {
+	dbConn := openTestDatabase(t)
+	if _, err := dbConn.ExecuteQuery("INSERT INTO shelf (id, name) VALUES (1, 'fantasy');", nil); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		query      string
+		kind       string
+		constraint string
+		conflict   bool
+	}{
+		{"unique", "INSERT INTO shelf (name) VALUES ('fantasy');", "UNIQUE", "shelf.name", true},
+		{"primary key", "INSERT INTO shelf (id, name) VALUES (1, 'poetry');", "PRIMARY KEY", "shelf.id", true},
+		{"not null", "INSERT INTO book (title) VALUES (NULL);", "NOT NULL", "book.title", false},
+		{"check", "INSERT INTO shelf (name) VALUES ('');", "CHECK", "", false},
+		{"foreign key", "INSERT INTO book (title, shelf_id) VALUES ('orphan', 9);", "FOREIGN KEY", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := dbConn.ExecuteQuery(test.query, nil)
+			var constraintErr *ConstraintError
+			if !errors.As(err, &constraintErr) {
+				t.Fatalf("%v isn't a ConstraintError", err)
+			}
+			if constraintErr.Kind != test.kind {
+				t.Errorf("the kind is %q, want %q", constraintErr.Kind, test.kind)
+			}
+			// SQLite names unnamed CHECK constraints by their expression
+			if test.kind != "CHECK" && constraintErr.Constraint != test.constraint {
+				t.Errorf("the constraint is %q, want %q", constraintErr.Constraint, test.constraint)
+			}
+			if !errors.Is(err, ErrConstraint) {
+				t.Error("the error isn't ErrConstraint")
+			}
+			if errors.Is(err, ErrConflict) != test.conflict {
+				t.Errorf("errors.Is(err, ErrConflict) = %v, want %v", !test.conflict, test.conflict)
+			}
+		})
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	if translateError(nil) != nil {
+		t.Error("translateError(nil) isn't nil")
+	}
+	err := translateError(sql.ErrNoRows)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("translateError(sql.ErrNoRows) = %v, want ErrNotFound wrapping sql.ErrNoRows", err)
+	}
+	other := errors.New("other")
+	if translateError(other) != other {
+		t.Error("translateError changed an error that doesn't come from SQLite")
+	}
+
+	wrapped := wrapError("read 3 from", "book", sql.ErrNoRows)
+	var opErr *OpError
+	if !errors.As(wrapped, &opErr) || opErr.Op != "read 3 from" || opErr.Table != "book" {
+		t.Errorf("wrapError = %#v, want an OpError of the operation", wrapped)
+	}
+	if !errors.Is(wrapped, ErrNotFound) {
+		t.Errorf("wrapError lost ErrNotFound: %v", wrapped)
+	}
+	if wrapError("read", "book", nil) != nil {
+		t.Error("wrapError(nil) isn't nil")
+	}
+}
+
+func TestTranslateBusyError(t *testing.T) {
+	dbConn := openTestDatabase(t)
+	tx, err := dbConn.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("INSERT INTO shelf (name) VALUES ('locked');"); err != nil {
+		t.Fatal(err)
+	}
+
+	// another connection of the pool, with no time to wait for the lock
+	conn, err := dbConn.db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(context.Background(), "PRAGMA busy_timeout = 0;"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO shelf (name) VALUES ('waiting');")
+	if err = translateError(err); !errors.Is(err, ErrBusy) {
+		t.Errorf("a write during another write = %v, want ErrBusy", err)
+	}
+}
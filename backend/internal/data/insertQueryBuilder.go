@@ -2,19 +2,42 @@ package data
 
 import (
 	"errors"
-	"fmt"
 	"strings"
 )
 
 type InsertQueryBuilder struct {
-	query      strings.Builder
 	ignore     bool
 	replace    bool
 	columns    []Column
 	valueLists [][]Value
+	selection  *SelectQueryBuilder
+	upsert     *upsertClause
 	tableName  string
 }
 
+// upsertClause updates the stored row an insert conflicts with.
+type upsertClause struct {
+	conflictColumns []Column
+	updateColumns   []Column
+	where           *WhereGroup
+}
+
+func (clause *upsertClause) build(args *[]any) string {
+	var query strings.Builder
+	query.WriteString(" ON CONFLICT (" + columnsString(clause.conflictColumns, args) + ") DO UPDATE SET ")
+	for i, col := range clause.updateColumns {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		name := col.GetColumnName()
+		query.WriteString(name + " = excluded." + name)
+	}
+	if clause.where != nil {
+		query.WriteString(" WHERE " + whereString(clause.where.conditions, args))
+	}
+	return query.String()
+}
+
 func NewInsertQueryBuilder(tableName string) *InsertQueryBuilder {
 	return &InsertQueryBuilder{
 		tableName: tableName,
@@ -22,6 +45,9 @@ func NewInsertQueryBuilder(tableName string) *InsertQueryBuilder {
 }
 
 func (builder *InsertQueryBuilder) SetIgnore() error {
+	if builder.upsert != nil {
+		return errors.New("You can't set ignore on an upsert")
+	}
 	if builder.replace {
 		return errors.New("You can't set ignore and replace at the same time")
 	}
@@ -34,6 +60,9 @@ func (builder *InsertQueryBuilder) UnsetIgnore() {
 }
 
 func (builder *InsertQueryBuilder) SetReplace() error {
+	if builder.upsert != nil {
+		return errors.New("You can't set replace on an upsert")
+	}
 	if builder.ignore {
 		return errors.New("You can't set ignore and replace at the same time")
 	}
@@ -63,20 +92,32 @@ func (builder *InsertQueryBuilder) AddColumn(col string) error {
 }
 
 func (builder *InsertQueryBuilder) SetValues(values []Value) error {
+	if builder.selection != nil {
+		return errors.New("You can't insert values and the rows of a select at the same time")
+	}
 	if builder.columns != nil && len(builder.columns) != len(values) {
 		return errors.New("The number of columns must be equal to the number of values")
 	}
 	if len(builder.valueLists) > 0 && len(values) != len(builder.valueLists[len(builder.valueLists)-1]) {
 		return errors.New("The number of values must correspond to the already inserted")
 	}
+	if err := checkValues(values...); err != nil {
+		return err
+	}
 	builder.valueLists = append(builder.valueLists, values)
 	return nil
 }
 
 func (builder *InsertQueryBuilder) AddValue(value Value) error {
+	if builder.selection != nil {
+		return errors.New("You can't insert values and the rows of a select at the same time")
+	}
 	if builder.columns != nil && len(builder.columns) != (len(builder.valueLists)+1) {
 		return errors.New("The number of columns must be equal to the number of values")
 	}
+	if err := checkValues(value); err != nil {
+		return err
+	}
 	if len(builder.valueLists) == 0 {
 		builder.valueLists = append(builder.valueLists, []Value{})
 	}
@@ -85,53 +126,108 @@ func (builder *InsertQueryBuilder) AddValue(value Value) error {
 	return nil
 }
 
-func buildValuesString(values []Value) string {
+// SetSelect inserts the rows returned by selection instead of values, its
+// columns matching the ones of the insert.
+func (builder *InsertQueryBuilder) SetSelect(selection *SelectQueryBuilder) error {
+	if selection == nil {
+		return errors.New("the select can't be nil")
+	}
+	if len(builder.valueLists) > 0 {
+		return errors.New("You can't insert values and the rows of a select at the same time")
+	}
+	if builder.upsert != nil {
+		return errors.New("You can't upsert the rows of a select")
+	}
+	if builder.columns != nil && len(selection.columns) != len(builder.columns) {
+		return errors.New("The number of columns must be equal to the number of selected columns")
+	}
+	builder.selection = selection
+	return nil
+}
+
+// SetUpsert makes the insert update the updateColumns of the stored row
+// it conflicts with on conflictColumns, with the inserted values. When
+// where is given, only the stored rows matching it are updated, the others
+// being left as they are.
+func (builder *InsertQueryBuilder) SetUpsert(conflictColumns []Column, updateColumns []Column, where *WhereGroup) error {
+	if builder.ignore || builder.replace {
+		return errors.New("You can't upsert while ignoring or replacing the conflicts")
+	}
+	if builder.selection != nil {
+		return errors.New("You can't upsert the rows of a select")
+	}
+	if len(conflictColumns) == 0 || len(updateColumns) == 0 {
+		return errors.New("an upsert needs conflict and update columns")
+	}
+	if where != nil && !where.hasConditions() {
+		return errors.New("the where group can't be empty")
+	}
+	builder.upsert = &upsertClause{
+		conflictColumns: conflictColumns,
+		updateColumns:   updateColumns,
+		where:           where,
+	}
+	return nil
+}
+
+func buildValuesString(values []Value, args *[]any) string {
 	var builder strings.Builder
 	builder.WriteString("(")
-	builder.WriteString(fmt.Sprintf("%s", values[0]))
+	builder.WriteString(bindValue(values[0], args))
 
 	for i := 1; i < len(values); i++ {
-		builder.WriteString(fmt.Sprintf(", %s", values[i]))
+		builder.WriteString(", " + bindValue(values[i], args))
 	}
 
 	builder.WriteString(")")
 	return builder.String()
 }
 
-func (builder *InsertQueryBuilder) Build() (string, error) {
-	if builder.valueLists == nil || len(builder.valueLists) == 0 {
-		return "", errors.New("No value provided for the insert")
+// Build returns the query and the arguments bound to its placeholders,
+// in order of appearance.
+func (builder *InsertQueryBuilder) Build() (string, []any, error) {
+	if len(builder.valueLists) == 0 && builder.selection == nil {
+		return "", nil, errors.New("No value provided for the insert")
 	}
 
-	builder.query.WriteString("INSERT ")
+	args := []any{}
+	var query strings.Builder
+	query.WriteString("INSERT ")
 	if builder.replace {
-		builder.query.WriteString("OR REPLACE ")
+		query.WriteString("OR REPLACE ")
 	}
 	if builder.ignore {
-		builder.query.WriteString("OR IGNORE ")
+		query.WriteString("OR IGNORE ")
 	}
 
-	builder.query.WriteString("INTO " + builder.tableName + " ")
+	query.WriteString("INTO " + builder.tableName + " ")
 
 	if builder.columns != nil && len(builder.columns) > 0 {
-		builder.query.WriteString("(" + builder.columns[0].String())
+		query.WriteString("(" + builder.columns[0].String())
 		for i := 1; i < len(builder.columns); i++ {
-			builder.query.WriteString(", " + builder.columns[i].String())
+			query.WriteString(", " + builder.columns[i].String())
 		}
-		builder.query.WriteString(") ")
+		query.WriteString(") ")
 	}
 
-	builder.query.WriteString("VALUES ")
-	builder.query.WriteString(buildValuesString(builder.valueLists[0]))
+	if builder.selection != nil {
+		query.WriteString(builder.selection.build(&args))
+		return query.String(), args, nil
+	}
+	query.WriteString("VALUES ")
+	query.WriteString(buildValuesString(builder.valueLists[0], &args))
 	for i := 1; i < len(builder.valueLists); i++ {
-		builder.query.WriteString(", " + buildValuesString(builder.valueLists[i]))
+		query.WriteString(", " + buildValuesString(builder.valueLists[i], &args))
+	}
+	if builder.upsert != nil {
+		query.WriteString(builder.upsert.build(&args))
 	}
 
-	return builder.query.String(), nil
+	return query.String(), args, nil
 }
 
 func (builder *InsertQueryBuilder) String() string {
-	query, err := builder.Build()
+	query, _, err := builder.Build()
 	if err != nil {
 		// TODO: Handle error
 	}
@@ -0,0 +1,122 @@
+package data
+
+import "testing"
+
+func TestInsertQueryBuilder(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(builder *InsertQueryBuilder) error
+		query string
+		args  []any
+	}{
+		{
+			name: "values are bound",
+			build: func(builder *InsertQueryBuilder) error {
+				builder.SetColumns(ConvertToColumns([]string{"title", "status_id"}))
+				return builder.SetValues([]Value{NewStringValue("'); DROP TABLE tale; --"), NewIntValue(1)})
+			},
+			query: "INSERT INTO tale (title, status_id) VALUES (?, ?)",
+			args:  []any{"'); DROP TABLE tale; --", int64(1)},
+		},
+		{
+			name: "several rows",
+			build: func(builder *InsertQueryBuilder) error {
+				builder.SetColumns(ConvertToColumns([]string{"title"}))
+				if err := builder.SetValues([]Value{NewStringValue("a")}); err != nil {
+					return err
+				}
+				return builder.SetValues([]Value{NewStringValue("b")})
+			},
+			query: "INSERT INTO tale (title) VALUES (?), (?)",
+			args:  []any{"a", "b"},
+		},
+		{
+			name: "tokens",
+			build: func(builder *InsertQueryBuilder) error {
+				builder.SetIgnore()
+				builder.SetColumns(ConvertToColumns([]string{"title", "created_at"}))
+				return builder.SetValues([]Value{NewTokenValue("?"), NewTokenValue("CURRENT_TIMESTAMP")})
+			},
+			query: "INSERT OR IGNORE INTO tale (title, created_at) VALUES (?, CURRENT_TIMESTAMP)",
+			args:  []any{},
+		},
+		{
+			name: "rows of a select",
+			build: func(builder *InsertQueryBuilder) error {
+				builder.SetIgnore()
+				builder.SetColumns(ConvertToColumns([]string{"tale_id", "tag_id"}))
+				selection := NewSelectQueryBuilder("tale_tag")
+				selection.SetColumns(ConvertToColumns([]string{"tale_id", "?"}))
+				selection.SetWhere("tale_tag", ConvertToColumns([]string{"tag_id"})[0], "=", NewIntValue(3), "")
+				return builder.SetSelect(selection)
+			},
+			query: "INSERT OR IGNORE INTO tale (tale_id, tag_id) SELECT tale_id, ? FROM tale_tag WHERE tale_tag.tag_id = ?",
+			args:  []any{int64(3)},
+		},
+		{
+			name: "upsert",
+			build: func(builder *InsertQueryBuilder) error {
+				builder.SetColumns(ConvertToColumns([]string{"first_id", "second_id", "score"}))
+				builder.SetValues([]Value{NewIntValue(1), NewIntValue(2), NewTokenValue("?")})
+				where := NewWhereGroup()
+				where.SetWhereNotNull("tale", ConvertToColumns([]string{"score"})[0], "")
+				return builder.SetUpsert(ConvertToColumns([]string{"first_id", "second_id"}),
+					ConvertToColumns([]string{"score"}), where)
+			},
+			query: "INSERT INTO tale (first_id, second_id, score) VALUES (?, ?, ?)" +
+				" ON CONFLICT (first_id, second_id) DO UPDATE SET score = excluded.score WHERE tale.score IS NOT NULL",
+			args: []any{int64(1), int64(2)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := NewInsertQueryBuilder("tale")
+			if err := test.build(builder); err != nil {
+				t.Fatal(err)
+			}
+			query, args, err := builder.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkBuild(t, query, args, test.query, test.args)
+		})
+	}
+}
+
+func TestInsertQueryBuilderErrors(t *testing.T) {
+	builder := NewInsertQueryBuilder("tale")
+	if _, _, err := builder.Build(); err == nil {
+		t.Error("Build accepted an insert without values")
+	}
+	builder.SetColumns(ConvertToColumns([]string{"title", "status_id"}))
+	if err := builder.SetValues([]Value{NewStringValue("a")}); err == nil {
+		t.Error("SetValues accepted fewer values than columns")
+	}
+	if err := builder.SetValues([]Value{NewStringValue("a"), nil}); err == nil {
+		t.Error("SetValues accepted a nil value")
+	}
+	if err := builder.SetSelect(NewSelectQueryBuilder("draft")); err == nil {
+		t.Error("SetSelect accepted a select of other columns")
+	}
+	builder.SetValues([]Value{NewStringValue("a"), NewIntValue(1)})
+	selection := NewSelectQueryBuilder("draft")
+	selection.SetColumns(ConvertToColumns([]string{"title", "status_id"}))
+	if err := builder.SetSelect(selection); err == nil {
+		t.Error("SetSelect accepted an insert of values")
+	}
+	builder.SetIgnore()
+	if err := builder.SetReplace(); err == nil {
+		t.Error("SetReplace accepted an insert already ignoring conflicts")
+	}
+	columns := ConvertToColumns([]string{"title"})
+	if err := builder.SetUpsert(columns, columns, nil); err == nil {
+		t.Error("SetUpsert accepted an insert already ignoring conflicts")
+	}
+	builder.UnsetIgnore()
+	if err := builder.SetUpsert(nil, columns, nil); err == nil {
+		t.Error("SetUpsert accepted no conflict column")
+	}
+	if err := builder.SetUpsert(columns, columns, NewWhereGroup()); err == nil {
+		t.Error("SetUpsert accepted an empty where group")
+	}
+}
@@ -0,0 +1,239 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ForeignKeyViolation is a row referencing a parent row that doesn't exist,
+// as reported by PRAGMA foreign_key_check.
+type ForeignKeyViolation struct {
+	Table  string
+	RowId  int64
+	Parent string
+	// Columns are the columns of Table holding the reference.
+	Columns []string
+	// OnDelete is the action of the foreign key, used to repair the row.
+	OnDelete string
+}
+
+func (violation ForeignKeyViolation) String() string {
+	return fmt.Sprintf("%s row %d: %s references a missing %s row",
+		violation.Table, violation.RowId, strings.Join(violation.Columns, ", "), violation.Parent)
+}
+
+type foreignKey struct {
+	columns  []string
+	onDelete string
+}
+
+// CheckForeignKeys returns the rows breaking a foreign key. They can only
+// appear while foreign keys are off, e.g. in databases written before they
+// were enabled.
+func (dbConnector *DatabaseConnector) CheckForeignKeys() ([]ForeignKeyViolation, error) {
+	return checkForeignKeys(dbConnector.db)
+}
+
+// RepairForeignKeys repairs the rows breaking a foreign key the way the
+// foreign key would have if the parent row had been deleted: the reference
+// is set to NULL or to its default, otherwise the row is deleted. It returns
+// the repaired violations; the rows that can't be repaired are left as they
+// are and reported by the next check.
+func (dbConnector *DatabaseConnector) RepairForeignKeys() ([]ForeignKeyViolation, error) {
+	tx, err := dbConnector.db.Begin()
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback()
+
+	repaired := []ForeignKeyViolation{}
+	attempted := map[string]bool{}
+	for {
+		violations, err := checkForeignKeys(tx)
+		if err != nil {
+			return nil, err
+		}
+		// a default may reference a missing row too: each row is repaired
+		// once, what's left after that is up to the user
+		progress := false
+		for _, violation := range violations {
+			if attempted[violation.String()] {
+				continue
+			}
+			attempted[violation.String()] = true
+			done, err := repairViolation(tx, violation)
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				repaired = append(repaired, violation)
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, translateError(err)
+	}
+	return repaired, nil
+}
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func checkForeignKeys(db queryer) ([]ForeignKeyViolation, error) {
+	rows, err := db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	type check struct {
+		table  string
+		rowId  sql.NullInt64
+		parent string
+		fkId   int
+	}
+	checks := []check{}
+	for rows.Next() {
+		var c check
+		if err := rows.Scan(&c.table, &c.rowId, &c.parent, &c.fkId); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	rows.Close()
+
+	foreignKeys := map[string]map[int]foreignKey{}
+	violations := []ForeignKeyViolation{}
+	for _, c := range checks {
+		if _, ok := foreignKeys[c.table]; !ok {
+			if foreignKeys[c.table], err = listForeignKeys(db, c.table); err != nil {
+				return nil, err
+			}
+		}
+		key := foreignKeys[c.table][c.fkId]
+		violations = append(violations, ForeignKeyViolation{
+			Table:    c.table,
+			RowId:    c.rowId.Int64,
+			Parent:   c.parent,
+			Columns:  key.columns,
+			OnDelete: key.onDelete,
+		})
+	}
+	return violations, nil
+}
+
+func listForeignKeys(db queryer, table string) (map[int]foreignKey, error) {
+	rows, err := db.Query("SELECT id, \"from\", on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq", table)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	foreignKeys := map[int]foreignKey{}
+	for rows.Next() {
+		var id int
+		var column, onDelete string
+		if err := rows.Scan(&id, &column, &onDelete); err != nil {
+			return nil, err
+		}
+		key := foreignKeys[id]
+		key.columns = append(key.columns, column)
+		key.onDelete = onDelete
+		foreignKeys[id] = key
+	}
+	return foreignKeys, translateError(rows.Err())
+}
+
+func repairViolation(tx *sql.Tx, violation ForeignKeyViolation) (bool, error) {
+	if violation.RowId == 0 || len(violation.Columns) == 0 {
+		// WITHOUT ROWID tables can't be repaired by rowid
+		return false, nil
+	}
+
+	var query string
+	switch violation.OnDelete {
+	case "SET NULL":
+		values := make([]string, len(violation.Columns))
+		for i := range values {
+			values[i] = "NULL"
+		}
+		query = fmt.Sprintf("UPDATE %s SET %s WHERE rowid = ?",
+			quoteIdentifier(violation.Table), assignments(violation.Columns, values))
+	case "SET DEFAULT":
+		defaults, err := columnDefaults(tx, violation.Table, violation.Columns)
+		if err != nil {
+			return false, err
+		}
+		query = fmt.Sprintf("UPDATE %s SET %s WHERE rowid = ?",
+			quoteIdentifier(violation.Table), assignments(violation.Columns, defaults))
+	default:
+		query = fmt.Sprintf("DELETE FROM %s WHERE rowid = ?", quoteIdentifier(violation.Table))
+	}
+
+	result, err := tx.Exec(query, violation.RowId)
+	if err != nil {
+		err = translateError(err)
+		if errors.Is(err, ErrConstraint) {
+			// e.g. a NOT NULL column set to NULL, left for the user
+			return false, nil
+		}
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// columnDefaults returns the default expressions of columns, NULL when a
+// column has none.
+func columnDefaults(tx *sql.Tx, table string, columns []string) ([]string, error) {
+	rows, err := tx.Query("SELECT name, dflt_value FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	all := map[string]string{}
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		all[name] = "NULL"
+		if value.Valid {
+			all[name] = value.String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	defaults := make([]string, len(columns))
+	for i, column := range columns {
+		defaults[i] = all[column]
+	}
+	return defaults, nil
+}
+
+func assignments(columns []string, values []string) string {
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		sets[i] = fmt.Sprintf("%s = %s", quoteIdentifier(column), values[i])
+	}
+	return strings.Join(sets, ", ")
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,2 @@
+ALTER TABLE chapters DROP COLUMN version;
+ALTER TABLE tales DROP COLUMN version;
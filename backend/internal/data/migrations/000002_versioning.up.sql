@@ -0,0 +1,2 @@
+ALTER TABLE tales ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE chapters ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
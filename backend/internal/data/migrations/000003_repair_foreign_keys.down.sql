@@ -0,0 +1,89 @@
+-- Restores the tables of the init migration. The seeded statuses are kept
+-- since tales may use them.
+CREATE TABLE tales_old (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    summary TEXT,
+    parent_id INTEGER NOT NULL DEFAULT 0,
+    status_id INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    deleted_at TEXT,
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (parent_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (status_id)
+    REFERENCES status (id)
+        ON UPDATE CASCADE
+        ON DELETE SET DEFAULT
+);
+
+INSERT INTO tales_old (id, name, summary, parent_id, status_id, created_at, updated_at, deleted_at, version)
+SELECT id, name, summary, IFNULL(parent_id, 0), status_id, created_at, updated_at, deleted_at, version
+FROM tales;
+
+DROP TABLE tales;
+ALTER TABLE tales_old RENAME TO tales;
+
+CREATE TABLE chapters_old (
+    id INTEGER PRIMARY KEY,
+    content TEXT,
+    sentiment REAL,
+    tale_id INTEGER NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (tale_id)
+    REFERENCES tale (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO chapters_old (id, content, sentiment, tale_id, version)
+SELECT id, content, sentiment, tale_id, version
+FROM chapters;
+
+DROP TABLE chapters;
+ALTER TABLE chapters_old RENAME TO chapters;
+
+CREATE TABLE tale_tag_old (
+    tale_id INTEGER,
+    tag_id INTEGER,
+    UNIQUE (tale_id, tag_id),
+    FOREIGN KEY (tale_id)
+    REFERENCES tale (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (tag_id)
+    REFERENCES tag (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO tale_tag_old (tale_id, tag_id)
+SELECT tale_id, tag_id
+FROM tale_tag;
+
+DROP TABLE tale_tag;
+ALTER TABLE tale_tag_old RENAME TO tale_tag;
+
+CREATE TABLE is_similar_old (
+    first_tale_id INTEGER,
+    second_tale_id INTEGER,
+    UNIQUE (first_tale_id, second_tale_id),
+    FOREIGN KEY (first_tale_id)
+    REFERENCES tale (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (second_tale_id)
+    REFERENCES tale (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO is_similar_old (first_tale_id, second_tale_id)
+SELECT first_tale_id, second_tale_id
+FROM is_similar;
+
+DROP TABLE is_similar;
+ALTER TABLE is_similar_old RENAME TO is_similar;
@@ -0,0 +1,101 @@
+-- The init migration referenced a missing "tale" table and a status that
+-- was never inserted. The tables are rebuilt with the right references,
+-- keeping their rows: orphans are left for the integrity check to report.
+INSERT OR IGNORE INTO status (id, name, color) VALUES
+    (1, 'New', '008000'),
+    (2, 'Drafting', '1e90ff'),
+    (3, 'Revising', 'ffa500'),
+    (4, 'Finished', '808080');
+
+-- the root tale has no parent instead of the missing tale 0
+CREATE TABLE tales_new (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    summary TEXT,
+    parent_id INTEGER,
+    status_id INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    deleted_at TEXT,
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (parent_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (status_id)
+    REFERENCES status (id)
+        ON UPDATE CASCADE
+        ON DELETE SET DEFAULT
+);
+
+INSERT INTO tales_new (id, name, summary, parent_id, status_id, created_at, updated_at, deleted_at, version)
+SELECT id, name, summary, NULLIF(parent_id, 0), status_id, created_at, updated_at, deleted_at, version
+FROM tales;
+
+DROP TABLE tales;
+ALTER TABLE tales_new RENAME TO tales;
+CREATE INDEX tales_parent_id ON tales (parent_id);
+
+CREATE TABLE chapters_new (
+    id INTEGER PRIMARY KEY,
+    content TEXT,
+    sentiment REAL,
+    tale_id INTEGER NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO chapters_new (id, content, sentiment, tale_id, version)
+SELECT id, content, sentiment, tale_id, version
+FROM chapters;
+
+DROP TABLE chapters;
+ALTER TABLE chapters_new RENAME TO chapters;
+CREATE INDEX chapters_tale_id ON chapters (tale_id);
+
+CREATE TABLE tale_tag_new (
+    tale_id INTEGER,
+    tag_id INTEGER,
+    UNIQUE (tale_id, tag_id),
+    FOREIGN KEY (tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (tag_id)
+    REFERENCES tag (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO tale_tag_new (tale_id, tag_id)
+SELECT tale_id, tag_id
+FROM tale_tag;
+
+DROP TABLE tale_tag;
+ALTER TABLE tale_tag_new RENAME TO tale_tag;
+CREATE INDEX tale_tag_tag_id ON tale_tag (tag_id);
+
+CREATE TABLE is_similar_new (
+    first_tale_id INTEGER,
+    second_tale_id INTEGER,
+    UNIQUE (first_tale_id, second_tale_id),
+    FOREIGN KEY (first_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (second_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO is_similar_new (first_tale_id, second_tale_id)
+SELECT first_tale_id, second_tale_id
+FROM is_similar;
+
+DROP TABLE is_similar;
+ALTER TABLE is_similar_new RENAME TO is_similar;
+CREATE INDEX is_similar_second_tale_id ON is_similar (second_tale_id);
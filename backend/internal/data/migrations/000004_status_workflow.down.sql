@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS tale_status_changes;
+DROP TABLE IF EXISTS status_transitions;
+ALTER TABLE status DROP COLUMN terminal;
+ALTER TABLE status DROP COLUMN position;
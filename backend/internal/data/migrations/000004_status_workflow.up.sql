@@ -0,0 +1,57 @@
+-- Statuses form a workflow: they are ordered, a tale only moves along the
+-- allowed transitions and terminal statuses can't be left.
+ALTER TABLE status ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE status ADD COLUMN terminal INTEGER NOT NULL DEFAULT 0;
+
+UPDATE status SET position = id;
+UPDATE status SET terminal = 1 WHERE name = 'Finished';
+
+CREATE TABLE status_transitions (
+    id INTEGER PRIMARY KEY,
+    from_status_id INTEGER NOT NULL,
+    to_status_id INTEGER NOT NULL,
+    UNIQUE (from_status_id, to_status_id),
+    CHECK (from_status_id <> to_status_id),
+    FOREIGN KEY (from_status_id)
+    REFERENCES status (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (to_status_id)
+    REFERENCES status (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO status_transitions (from_status_id, to_status_id)
+SELECT from_status.id, to_status.id
+FROM status AS from_status, status AS to_status
+WHERE (from_status.name, to_status.name) IN (
+    VALUES
+        ('New', 'Drafting'),
+        ('Drafting', 'Revising'),
+        ('Revising', 'Drafting'),
+        ('Revising', 'Finished')
+);
+
+CREATE TABLE tale_status_changes (
+    id INTEGER PRIMARY KEY,
+    tale_id INTEGER NOT NULL,
+    from_status_id INTEGER,
+    to_status_id INTEGER,
+    changed_by TEXT NOT NULL,
+    changed_at TEXT NOT NULL,
+    FOREIGN KEY (tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (from_status_id)
+    REFERENCES status (id)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL,
+    FOREIGN KEY (to_status_id)
+    REFERENCES status (id)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL
+);
+
+CREATE INDEX tale_status_changes_tale_id ON tale_status_changes (tale_id, changed_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tale_status_changes_changed_at;
+
+DELETE FROM tale_status_changes WHERE from_status_id IS NULL AND changed_by = '';
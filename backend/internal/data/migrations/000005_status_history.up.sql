@@ -0,0 +1,9 @@
+-- Every tale gets its status recorded from its creation, tales created
+-- before the history existed start in their current status.
+INSERT INTO tale_status_changes (tale_id, from_status_id, to_status_id, changed_by, changed_at)
+SELECT id, NULL, status_id, '', created_at
+FROM tales
+WHERE parent_id IS NOT NULL
+    AND id NOT IN (SELECT tale_id FROM tale_status_changes);
+
+CREATE INDEX tale_status_changes_changed_at ON tale_status_changes (changed_at);
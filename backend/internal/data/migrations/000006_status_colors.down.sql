@@ -0,0 +1,2 @@
+-- The normalized colors are still valid.
+SELECT 1;
@@ -0,0 +1,21 @@
+DROP TABLE tag_alias;
+
+-- nested names are flattened into their path, e.g. genre/sci-fi
+CREATE TABLE tag_old (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+WITH RECURSIVE tag_path (id, path) AS (
+    SELECT id, name FROM tag WHERE parent_id IS NULL
+    UNION ALL
+    SELECT tag.id, tag_path.path || '/' || tag.name
+    FROM tag
+    JOIN tag_path ON tag.parent_id = tag_path.id
+)
+INSERT INTO tag_old (id, name)
+SELECT id, path
+FROM tag_path;
+
+DROP TABLE tag;
+ALTER TABLE tag_old RENAME TO tag;
@@ -0,0 +1,33 @@
+-- Tags are nested: a name is only unique among the children of a tag, e.g.
+-- genre/sci-fi and setting/sci-fi. Aliases resolve to a canonical tag.
+CREATE TABLE tag_new (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    parent_id INTEGER,
+    CHECK (parent_id IS NULL OR parent_id <> id),
+    FOREIGN KEY (parent_id)
+    REFERENCES tag (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO tag_new (id, name, parent_id)
+SELECT id, name, NULL
+FROM tag;
+
+DROP TABLE tag;
+ALTER TABLE tag_new RENAME TO tag;
+CREATE UNIQUE INDEX tag_parent_name ON tag (ifnull(parent_id, 0), name);
+CREATE INDEX tag_parent_id ON tag (parent_id);
+
+CREATE TABLE tag_alias (
+    id INTEGER PRIMARY KEY,
+    tag_id INTEGER NOT NULL,
+    alias TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    FOREIGN KEY (tag_id)
+    REFERENCES tag (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX tag_alias_tag_id ON tag_alias (tag_id);
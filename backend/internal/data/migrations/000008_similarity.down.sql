@@ -0,0 +1,4 @@
+DROP TABLE similarity_proposals;
+DROP TABLE tale_signatures;
+
+ALTER TABLE is_similar DROP COLUMN score;
@@ -0,0 +1,32 @@
+-- Similar tales are found from MinHash signatures of their chapters. The
+-- score of automatic links is kept so they can be dropped once the tales
+-- differ, manual links have none.
+ALTER TABLE is_similar ADD COLUMN score REAL;
+
+CREATE TABLE tale_signatures (
+    id INTEGER PRIMARY KEY,
+    tale_id INTEGER NOT NULL UNIQUE,
+    fingerprint TEXT NOT NULL,
+    signature BLOB NOT NULL,
+    FOREIGN KEY (tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE TABLE similarity_proposals (
+    id INTEGER PRIMARY KEY,
+    first_tale_id INTEGER NOT NULL,
+    second_tale_id INTEGER NOT NULL,
+    score REAL NOT NULL,
+    UNIQUE (first_tale_id, second_tale_id),
+    CHECK (first_tale_id < second_tale_id),
+    FOREIGN KEY (first_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (second_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+DROP TABLE chapter_codex;
+DROP TABLE tale_codex;
+DROP TABLE codex_fields;
+DROP TABLE codex_entities;
@@ -0,0 +1,59 @@
+-- The codex holds the characters, places, items and factions of the
+-- stories, with custom fields, linked to the tales and chapters they
+-- appear in.
+CREATE TABLE codex_entities (
+    id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('character', 'place', 'item', 'faction')),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX codex_entities_kind ON codex_entities (kind, name);
+
+CREATE TABLE codex_fields (
+    id INTEGER PRIMARY KEY,
+    entity_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (entity_id, name),
+    FOREIGN KEY (entity_id)
+    REFERENCES codex_entities (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE TABLE tale_codex (
+    entity_id INTEGER NOT NULL,
+    tale_id INTEGER NOT NULL,
+    UNIQUE (entity_id, tale_id),
+    FOREIGN KEY (entity_id)
+    REFERENCES codex_entities (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX tale_codex_tale_id ON tale_codex (tale_id);
+
+CREATE TABLE chapter_codex (
+    entity_id INTEGER NOT NULL,
+    chapter_id INTEGER NOT NULL,
+    UNIQUE (entity_id, chapter_id),
+    FOREIGN KEY (entity_id)
+    REFERENCES codex_entities (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (chapter_id)
+    REFERENCES chapters (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX chapter_codex_chapter_id ON chapter_codex (chapter_id);
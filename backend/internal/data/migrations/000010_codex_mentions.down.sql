@@ -0,0 +1,2 @@
+DROP TABLE codex_mentions;
+DROP TABLE codex_aliases;
@@ -0,0 +1,33 @@
+-- The entities are found in the chapters by their name and their aliases,
+-- each mention is kept with its position in the chapter content.
+CREATE TABLE codex_aliases (
+    id INTEGER PRIMARY KEY,
+    entity_id INTEGER NOT NULL,
+    alias TEXT NOT NULL COLLATE NOCASE,
+    UNIQUE (entity_id, alias),
+    FOREIGN KEY (entity_id)
+    REFERENCES codex_entities (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+-- start_pos and end_pos are byte offsets in the content of the chapter
+CREATE TABLE codex_mentions (
+    id INTEGER PRIMARY KEY,
+    entity_id INTEGER NOT NULL,
+    chapter_id INTEGER NOT NULL,
+    start_pos INTEGER NOT NULL,
+    end_pos INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    FOREIGN KEY (entity_id)
+    REFERENCES codex_entities (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (chapter_id)
+    REFERENCES chapters (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX codex_mentions_chapter_id ON codex_mentions (chapter_id, start_pos);
+CREATE INDEX codex_mentions_entity_id ON codex_mentions (entity_id, chapter_id);
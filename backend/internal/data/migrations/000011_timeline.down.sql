@@ -0,0 +1,3 @@
+DROP TABLE timeline_events;
+DROP TABLE calendar_months;
+DROP TABLE calendars;
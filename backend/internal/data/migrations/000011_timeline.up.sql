@@ -0,0 +1,64 @@
+-- The in-world chronology of the stories: events dated in custom
+-- calendars or relative to another event. The epoch of a calendar is the
+-- day its year 0 starts on, so that the dates of every calendar can be
+-- compared.
+CREATE TABLE calendars (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    era TEXT NOT NULL DEFAULT '',
+    epoch INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE calendar_months (
+    id INTEGER PRIMARY KEY,
+    calendar_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    days INTEGER NOT NULL CHECK (days > 0),
+    position INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (calendar_id)
+    REFERENCES calendars (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX calendar_months_calendar_id ON calendar_months (calendar_id, position);
+
+-- an event is either dated in a calendar or offset by a number of days
+-- from another event, or neither when its time is unknown
+CREATE TABLE timeline_events (
+    id INTEGER PRIMARY KEY,
+    tale_id INTEGER NOT NULL,
+    chapter_id INTEGER,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    calendar_id INTEGER,
+    year INTEGER NOT NULL DEFAULT 0,
+    month INTEGER NOT NULL DEFAULT 0,
+    day INTEGER NOT NULL DEFAULT 0,
+    after_id INTEGER,
+    offset_days INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    CHECK (calendar_id IS NULL OR after_id IS NULL),
+    CHECK (after_id IS NULL OR after_id <> id),
+    FOREIGN KEY (tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (chapter_id)
+    REFERENCES chapters (id)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL,
+    FOREIGN KEY (calendar_id)
+    REFERENCES calendars (id)
+        ON UPDATE CASCADE
+        ON DELETE RESTRICT,
+    FOREIGN KEY (after_id)
+    REFERENCES timeline_events (id)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL
+);
+
+CREATE INDEX timeline_events_tale_id ON timeline_events (tale_id);
+CREATE INDEX timeline_events_chapter_id ON timeline_events (chapter_id);
+CREATE INDEX timeline_events_calendar_id ON timeline_events (calendar_id);
+CREATE INDEX timeline_events_after_id ON timeline_events (after_id);
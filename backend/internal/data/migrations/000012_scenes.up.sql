@@ -0,0 +1,39 @@
+-- Chapters are split into ordered scenes. The content of a chapter is kept
+-- as the content of its scenes joined by scene breaks, see
+-- chapter.SCENE_BREAK. The word count of a scene is counted when it's
+-- read.
+CREATE TABLE scenes (
+    id INTEGER PRIMARY KEY,
+    chapter_id INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    title TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    synopsis TEXT NOT NULL DEFAULT '',
+    pov_id INTEGER,
+    location_id INTEGER,
+    status_id INTEGER,
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (chapter_id)
+    REFERENCES chapters (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (pov_id)
+    REFERENCES codex_entities (id)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL,
+    FOREIGN KEY (location_id)
+    REFERENCES codex_entities (id)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL,
+    FOREIGN KEY (status_id)
+    REFERENCES status (id)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL
+);
+
+CREATE INDEX scenes_chapter_id ON scenes (chapter_id, position);
+
+-- every chapter starts as a single scene
+INSERT INTO scenes (chapter_id, position, content)
+SELECT id, 0, ifnull(content, '')
+FROM chapters;
@@ -0,0 +1,2 @@
+DROP TABLE note_bases;
+DROP TABLE notes;
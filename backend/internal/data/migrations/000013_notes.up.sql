@@ -0,0 +1,31 @@
+-- Notes annotate a range of characters of a chapter. The content the
+-- ranges were last placed on is kept in note_bases, the ranges are moved
+-- through the differences with the current content when the notes are
+-- read, see notes.Repository.
+CREATE TABLE notes (
+    id INTEGER PRIMARY KEY,
+    chapter_id INTEGER NOT NULL,
+    start_pos INTEGER NOT NULL CHECK (start_pos >= 0),
+    end_pos INTEGER NOT NULL CHECK (end_pos >= start_pos),
+    quote TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    resolved_at TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (chapter_id)
+    REFERENCES chapters (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX notes_chapter_id ON notes (chapter_id, start_pos);
+
+CREATE TABLE note_bases (
+    chapter_id INTEGER PRIMARY KEY,
+    content TEXT NOT NULL,
+    FOREIGN KEY (chapter_id)
+    REFERENCES chapters (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
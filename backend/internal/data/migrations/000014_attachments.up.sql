@@ -0,0 +1,41 @@
+-- Attachments are files kept with the stories, e.g. reference images, maps
+-- or PDFs. Their content is stored once per library in files named after
+-- its hash, see attachments.Store, and they're linked to tales and
+-- chapters. The width and height are those of images, 0 otherwise.
+CREATE TABLE attachments (
+    id INTEGER PRIMARY KEY,
+    hash TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    mime_type TEXT NOT NULL DEFAULT 'application/octet-stream',
+    size INTEGER NOT NULL DEFAULT 0,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL
+);
+
+-- a link is either to a tale or to a chapter
+CREATE TABLE attachment_links (
+    id INTEGER PRIMARY KEY,
+    attachment_id INTEGER NOT NULL,
+    tale_id INTEGER,
+    chapter_id INTEGER,
+    CHECK ((tale_id IS NULL) <> (chapter_id IS NULL)),
+    FOREIGN KEY (attachment_id)
+    REFERENCES attachments (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (chapter_id)
+    REFERENCES chapters (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX attachment_links_tale_id ON attachment_links (tale_id, attachment_id)
+WHERE tale_id IS NOT NULL;
+CREATE UNIQUE INDEX attachment_links_chapter_id ON attachment_links (chapter_id, attachment_id)
+WHERE chapter_id IS NOT NULL;
+CREATE INDEX attachment_links_attachment_id ON attachment_links (attachment_id);
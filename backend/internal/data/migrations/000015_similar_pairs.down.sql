@@ -0,0 +1,22 @@
+CREATE TABLE is_similar_old (
+    first_tale_id INTEGER,
+    second_tale_id INTEGER,
+    score REAL,
+    UNIQUE (first_tale_id, second_tale_id),
+    FOREIGN KEY (first_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (second_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO is_similar_old (first_tale_id, second_tale_id, score)
+SELECT first_tale_id, second_tale_id, score
+FROM is_similar;
+
+DROP TABLE is_similar;
+ALTER TABLE is_similar_old RENAME TO is_similar;
+CREATE INDEX is_similar_second_tale_id ON is_similar (second_tale_id);
@@ -0,0 +1,31 @@
+-- Similar tales are stored once, the lower tale id first, as the
+-- similarity proposals are. A pair written both ways keeps the manual link
+-- over the automatic one, links missing a tale or linking a tale to itself
+-- are dropped.
+CREATE TABLE is_similar_new (
+    first_tale_id INTEGER NOT NULL,
+    second_tale_id INTEGER NOT NULL,
+    score REAL,
+    UNIQUE (first_tale_id, second_tale_id),
+    CHECK (first_tale_id < second_tale_id),
+    FOREIGN KEY (first_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (second_tale_id)
+    REFERENCES tales (id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+INSERT INTO is_similar_new (first_tale_id, second_tale_id, score)
+SELECT min(first_tale_id, second_tale_id), max(first_tale_id, second_tale_id),
+    CASE WHEN count(score) < count(*) THEN NULL ELSE max(score) END
+FROM is_similar
+WHERE first_tale_id IS NOT NULL AND second_tale_id IS NOT NULL
+    AND first_tale_id <> second_tale_id
+GROUP BY min(first_tale_id, second_tale_id), max(first_tale_id, second_tale_id);
+
+DROP TABLE is_similar;
+ALTER TABLE is_similar_new RENAME TO is_similar;
+CREATE INDEX is_similar_second_tale_id ON is_similar (second_tale_id);
@@ -0,0 +1,9 @@
+-- Status colors are stored as six lower case hex digits again, as done by
+-- 000006: the color names and hsl() values fall back to gray.
+UPDATE status SET color = lower(ltrim(trim(color), '#'));
+UPDATE status SET color = substr(color, 1, 1) || substr(color, 1, 1)
+    || substr(color, 2, 1) || substr(color, 2, 1)
+    || substr(color, 3, 1) || substr(color, 3, 1)
+WHERE length(color) = 3 AND color NOT GLOB '*[^0-9a-f]*';
+UPDATE status SET color = '808080'
+WHERE color IS NULL OR length(color) <> 6 OR color GLOB '*[^0-9a-f]*';
@@ -0,0 +1,5 @@
+-- Status colors are stored as they were set, in hex, as a CSS color name
+-- or as hsl(), see status.ParseColor. The hex digits stored by 000006 get
+-- their '#' back. The colors 000006 turned gray can't be recovered.
+UPDATE status SET color = '#' || color
+WHERE length(color) = 6 AND color NOT GLOB '*[^0-9a-f]*';
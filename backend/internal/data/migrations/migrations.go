@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS holds the SQL migrations, embedded so they're available wherever the
+// application is installed.
+//
+//go:embed *.sql
+var FS embed.FS
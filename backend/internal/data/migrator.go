@@ -0,0 +1,211 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"talenest/backend/internal/data/migrations"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+type MigrationStatus struct {
+	// Version is the applied version, 0 when no migration ran yet.
+	Version uint
+	// Dirty reports a migration that failed halfway, see Migrator.Force.
+	Dirty bool
+	// Latest is the last available migration.
+	Latest uint
+	// Pending lists the versions not applied yet.
+	Pending []uint
+}
+
+// Migrator applies the embedded migrations to a SQLite database, taking a
+// backup of the database before changing its schema.
+type Migrator struct {
+	migrate   *migrate.Migrate
+	db        *sql.DB
+	dbPath    string
+	backupDir string
+	versions  []uint
+}
+
+func NewMigrator(dbPath, backupDir string) (*Migrator, error) {
+	sourceDriver, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+	versions, err := sourceVersions(sourceDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", sourceDriver, "sqlite://"+dbPath)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	return &Migrator{
+		migrate:   m,
+		db:        db,
+		dbPath:    dbPath,
+		backupDir: backupDir,
+		versions:  versions,
+	}, nil
+}
+
+func sourceVersions(sourceDriver source.Driver) ([]uint, error) {
+	version, err := sourceDriver.First()
+	if err != nil {
+		return nil, err
+	}
+	versions := []uint{version}
+	for {
+		version, err = sourceDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return versions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+}
+
+func (migrator *Migrator) Status() (*MigrationStatus, error) {
+	version, dirty, err := migrator.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, err
+	}
+
+	status := &MigrationStatus{
+		Version: version,
+		Dirty:   dirty,
+		Latest:  migrator.versions[len(migrator.versions)-1],
+	}
+	for _, available := range migrator.versions {
+		if available > version {
+			status.Pending = append(status.Pending, available)
+		}
+	}
+	return status, nil
+}
+
+// Up applies all the pending migrations.
+func (migrator *Migrator) Up() error {
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return migrate.ErrDirty{Version: int(status.Version)}
+	}
+	if len(status.Pending) == 0 {
+		return nil
+	}
+	if err := migrator.backupIfNeeded(status); err != nil {
+		return err
+	}
+	return ignoreNoChange(migrator.migrate.Up())
+}
+
+// To migrates up or down to version. Version 0 reverts every migration.
+func (migrator *Migrator) To(version uint) error {
+	if version != 0 && !slices.Contains(migrator.versions, version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	if status.Version == version && !status.Dirty {
+		return nil
+	}
+	if err := migrator.backupIfNeeded(status); err != nil {
+		return err
+	}
+	if version == 0 {
+		return ignoreNoChange(migrator.migrate.Down())
+	}
+	return ignoreNoChange(migrator.migrate.Migrate(version))
+}
+
+// Down rolls back the last steps migrations.
+func (migrator *Migrator) Down(steps int) error {
+	if steps < 1 {
+		return errors.New("the steps to roll back must be at least 1")
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	if err := migrator.backupIfNeeded(status); err != nil {
+		return err
+	}
+	return ignoreNoChange(migrator.migrate.Steps(-steps))
+}
+
+// Force sets the version without running any migration, clearing the dirty
+// flag. It's meant to recover from a failed migration once the schema has
+// been fixed by hand; -1 means no version.
+func (migrator *Migrator) Force(version int) error {
+	return migrator.migrate.Force(version)
+}
+
+// Backup copies the database in the backup directory and returns the path
+// of the copy.
+func (migrator *Migrator) Backup() (string, error) {
+	version, _, err := migrator.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return "", err
+	}
+	if err := os.MkdirAll(migrator.backupDir, 0700); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%s-v%d.db",
+		strings.TrimSuffix(filepath.Base(migrator.dbPath), filepath.Ext(migrator.dbPath)),
+		time.Now().Format("20060102-150405.000"),
+		version)
+	backupPath := filepath.Join(migrator.backupDir, name)
+	if _, err := migrator.db.Exec("VACUUM INTO ?", backupPath); err != nil {
+		return "", fmt.Errorf("backup to %s: %w", backupPath, translateError(err))
+	}
+	return backupPath, nil
+}
+
+// backupIfNeeded backs up databases that already have a schema, a fresh
+// database has nothing to save.
+func (migrator *Migrator) backupIfNeeded(status *MigrationStatus) error {
+	if status.Version == 0 && !status.Dirty {
+		return nil
+	}
+	_, err := migrator.Backup()
+	return err
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+func (migrator *Migrator) Close() error {
+	sourceErr, databaseErr := migrator.migrate.Close()
+	return errors.Join(sourceErr, databaseErr, migrator.db.Close())
+}
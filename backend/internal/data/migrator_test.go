@@ -0,0 +1,166 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+func newTestMigrator(t *testing.T) (*Migrator, string) {
+	t.Helper()
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backups")
+	migrator, err := NewMigrator(filepath.Join(dir, "talenest.db"), backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		migrator.Close()
+	})
+	return migrator, backupDir
+}
+
+func checkStatus(t *testing.T, migrator *Migrator, version uint, dirty bool) *MigrationStatus {
+	t.Helper()
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != version || status.Dirty != dirty {
+		t.Fatalf("the status is version %d, dirty %v, want version %d, dirty %v",
+			status.Version, status.Dirty, version, dirty)
+	}
+	return status
+}
+
+func checkBackups(t *testing.T, backupDir string, count int) []Backup {
+	t.Helper()
+	backups, err := ListBackups(backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != count {
+		t.Fatalf("%d backups were taken, want %d", len(backups), count)
+	}
+	return backups
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	migrator, backupDir := newTestMigrator(t)
+	status := checkStatus(t, migrator, 0, false)
+	if len(status.Pending) == 0 || status.Pending[len(status.Pending)-1] != status.Latest {
+		t.Fatalf("the pending migrations %v don't end with the latest %d", status.Pending, status.Latest)
+	}
+	latest := status.Latest
+
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	status = checkStatus(t, migrator, latest, false)
+	if len(status.Pending) != 0 {
+		t.Errorf("%v are pending after Up", status.Pending)
+	}
+	// a new database has nothing to back up
+	checkBackups(t, backupDir, 0)
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Up without pending migrations = %v", err)
+	}
+
+	if err := migrator.Down(2); err != nil {
+		t.Fatal(err)
+	}
+	previous := migrator.versions[len(migrator.versions)-3]
+	checkStatus(t, migrator, previous, false)
+	backup := checkBackups(t, backupDir, 1)[0]
+	if !strings.HasSuffix(backup.Name, fmt.Sprintf("-v%d.db", latest)) {
+		t.Errorf("the backup %s isn't named after the version it saves", backup.Name)
+	}
+
+	if err := migrator.To(latest); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(t, migrator, latest, false)
+	if err := migrator.To(0); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(t, migrator, 0, false)
+	checkBackups(t, backupDir, 3)
+
+	if err := migrator.To(latest + 1); err == nil {
+		t.Error("To accepted an unknown version")
+	}
+	if err := migrator.Down(0); err == nil {
+		t.Error("Down accepted 0 steps")
+	}
+}
+
+func TestMigratorDirty(t *testing.T) {
+	migrator, backupDir := newTestMigrator(t)
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	latest := migrator.versions[len(migrator.versions)-1]
+	// a migration that failed halfway
+	if _, err := migrator.db.Exec("UPDATE schema_migrations SET dirty = 1"); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(t, migrator, latest, true)
+
+	var dirty migrate.ErrDirty
+	if err := migrator.Up(); !errors.As(err, &dirty) {
+		t.Fatalf("Up on a dirty database = %v, want ErrDirty", err)
+	}
+	if cause := startupCause(migrator.Up()); cause != CauseMigrationDirty {
+		t.Errorf("the startup cause is %s, want %s", cause, CauseMigrationDirty)
+	}
+	checkBackups(t, backupDir, 0)
+
+	if err := migrator.Force(int(latest)); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(t, migrator, latest, false)
+}
+
+func TestMigratorBackup(t *testing.T) {
+	migrator, backupDir := newTestMigrator(t)
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.db.Exec("INSERT INTO tales (name, created_at, updated_at) VALUES ('saved', '', '')"); err != nil {
+		t.Fatal(err)
+	}
+	path, err := migrator.Backup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups := checkBackups(t, backupDir, 1)
+	if backups[0].Path != path || backups[0].Size == 0 {
+		t.Errorf("ListBackups = %+v, want %s", backups, path)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	titles := []string{}
+	rows, err := db.Query("SELECT name FROM tales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var title string
+		rows.Scan(&title)
+		titles = append(titles, title)
+	}
+	if !slices.Contains(titles, "saved") {
+		t.Errorf("the backup holds the tales %v, want the saved one", titles)
+	}
+}
@@ -0,0 +1,287 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scanner is implemented by *sql.Row and *sql.Rows.
+type Scanner interface {
+	Scan(dest ...any) error
+}
+
+// Mapper describes how an entity is stored in its table.
+type Mapper[T any] interface {
+	TableName() string
+	// Columns returns the column names of the table, the id first: "id",
+	// or "rowid" for the tables without an id column.
+	Columns() []string
+	// Values returns the values of entity in the order of Columns.
+	Values(entity *T) []any
+	// Scan reads an entity from a row selected with Columns.
+	Scan(scanner Scanner) (*T, error)
+	GetId(entity *T) int
+	SetId(entity *T, id int)
+}
+
+// VersionedMapper is implemented by the mappers of entities edited with
+// optimistic concurrency control. The version column must be listed in
+// Columns: it's checked by every update and incremented when it succeeds.
+type VersionedMapper[T any] interface {
+	Mapper[T]
+	VersionColumn() string
+	GetVersion(entity *T) int
+	SetVersion(entity *T, version int)
+}
+
+// Repository provides the CRUD operations of an entity through prepared
+// statements built from its Mapper.
+type Repository[T any] struct {
+	dbConn     *DatabaseConnector
+	mapper     Mapper[T]
+	versioned  VersionedMapper[T]
+	statements map[string]*sql.Stmt
+	// versionIndex is the position of the version column in the values
+	versionIndex int
+	// tx is set on the copies returned by WithTx
+	tx *sql.Tx
+}
+
+func NewRepository[T any](dbConn *DatabaseConnector, mapper Mapper[T]) (*Repository[T], error) {
+	repo := &Repository[T]{
+		dbConn:     dbConn,
+		mapper:     mapper,
+		statements: make(map[string]*sql.Stmt),
+	}
+	tableName := mapper.TableName()
+	columns := mapper.Columns()
+
+	queries := map[string]string{
+		CREATE_STATEMENT:   CreateQuery(tableName, columns),
+		READ_STATEMENT:     ReadByIdQuery(tableName, columns),
+		READ_ALL_STATEMENT: ReadAllQuery(tableName, columns),
+		UPDATE_STATEMENT:   UpdateQuery(tableName, columns),
+		DELETE_STATEMENT:   DeleteByColumnsQuery(tableName, columns[:1]),
+	}
+	if versioned, ok := mapper.(VersionedMapper[T]); ok {
+		repo.versioned = versioned
+		repo.versionIndex = slices.Index(columns, versioned.VersionColumn())
+		if repo.versionIndex < 0 {
+			return nil, fmt.Errorf("the version column %s isn't mapped for %s",
+				versioned.VersionColumn(), tableName)
+		}
+		queries[UPDATE_STATEMENT] = VersionedUpdateQuery(tableName, columns, versioned.VersionColumn())
+	}
+	for name, query := range queries {
+		if err := repo.Prepare(name, query); err != nil {
+			repo.Close()
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+// Prepare registers an additional statement, to be run with ReadMany or
+// Exec.
+func (repo *Repository[T]) Prepare(name string, query string) error {
+	statement, err := repo.dbConn.PrepareQuery(query)
+	if err != nil {
+		return &OpError{Op: "prepare " + name, Table: repo.mapper.TableName(), Err: err}
+	}
+	repo.statements[name] = statement
+	return nil
+}
+
+func (repo *Repository[T]) statement(name string) (*sql.Stmt, error) {
+	statement, ok := repo.statements[name]
+	if !ok {
+		return nil, fmt.Errorf("statement %s not prepared for %s", name, repo.mapper.TableName())
+	}
+	if repo.tx != nil {
+		// closed with the transaction
+		return repo.tx.Stmt(statement), nil
+	}
+	return statement, nil
+}
+
+// WithTx returns a copy of the repository running its statements in tx,
+// see DatabaseConnector.Transaction. The copy must not be closed.
+func (repo *Repository[T]) WithTx(tx *sql.Tx) *Repository[T] {
+	txRepo := *repo
+	txRepo.tx = tx
+	return &txRepo
+}
+
+func (repo *Repository[T]) Create(entity *T) (int, error) {
+	statement, err := repo.statement(CREATE_STATEMENT)
+	if err != nil {
+		return 0, err
+	}
+	values := repo.mapper.Values(entity)
+	// the id is assigned by the database
+	values[0] = nil
+	if repo.versioned != nil {
+		values[repo.versionIndex] = 1
+	}
+
+	result, err := statement.Exec(values...)
+	if err != nil {
+		return 0, wrapError("create", repo.mapper.TableName(), err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, wrapError("create", repo.mapper.TableName(), err)
+	}
+	repo.mapper.SetId(entity, int(id))
+	if repo.versioned != nil {
+		repo.versioned.SetVersion(entity, 1)
+	}
+	return int(id), nil
+}
+
+func (repo *Repository[T]) ReadById(id int) (*T, error) {
+	statement, err := repo.statement(READ_STATEMENT)
+	if err != nil {
+		return nil, err
+	}
+	entity, err := repo.mapper.Scan(statement.QueryRow(id))
+	if err != nil {
+		return nil, wrapError(fmt.Sprintf("read %d from", id), repo.mapper.TableName(), err)
+	}
+	return entity, nil
+}
+
+func (repo *Repository[T]) ReadAll() ([]*T, error) {
+	return repo.ReadMany(READ_ALL_STATEMENT)
+}
+
+// ReadMany runs the named statement and scans every returned row.
+func (repo *Repository[T]) ReadMany(name string, args ...any) ([]*T, error) {
+	statement, err := repo.statement(name)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := statement.Query(args...)
+	if err != nil {
+		return nil, wrapError(opName(name), repo.mapper.TableName(), err)
+	}
+	defer rows.Close()
+
+	entities := []*T{}
+	for rows.Next() {
+		entity, err := repo.mapper.Scan(rows)
+		if err != nil {
+			return nil, wrapError(opName(name), repo.mapper.TableName(), err)
+		}
+		entities = append(entities, entity)
+	}
+	return entities, wrapError(opName(name), repo.mapper.TableName(), rows.Err())
+}
+
+// Update writes entity. For versioned entities the update only succeeds
+// if the stored version matches the one of entity, otherwise a
+// *StaleError holding the stored entity is returned. On success the
+// version of entity is incremented.
+func (repo *Repository[T]) Update(entity *T) error {
+	if repo.versioned == nil {
+		args := append(repo.mapper.Values(entity), repo.mapper.GetId(entity))
+		return repo.Exec(UPDATE_STATEMENT, args...)
+	}
+
+	id := repo.mapper.GetId(entity)
+	version := repo.versioned.GetVersion(entity)
+	args := slices.Delete(repo.mapper.Values(entity), repo.versionIndex, repo.versionIndex+1)
+	args = append(args, id, version)
+
+	err := repo.Exec(UPDATE_STATEMENT, args...)
+	if !errors.Is(err, ErrNotFound) {
+		if err == nil {
+			repo.versioned.SetVersion(entity, version+1)
+		}
+		return err
+	}
+
+	// either the row is gone or its version moved on
+	current, readErr := repo.ReadById(id)
+	if readErr != nil {
+		return readErr
+	}
+	return &StaleError[T]{
+		Table:   repo.mapper.TableName(),
+		Id:      id,
+		Version: version,
+		Current: current,
+	}
+}
+
+func (repo *Repository[T]) Delete(id int) error {
+	return repo.Exec(DELETE_STATEMENT, id)
+}
+
+// Exec runs the named statement, which must affect exactly one row.
+// ErrNotFound is returned when no row is affected.
+func (repo *Repository[T]) Exec(name string, args ...any) error {
+	statement, err := repo.statement(name)
+	if err != nil {
+		return err
+	}
+	op := opName(name)
+	result, err := statement.Exec(args...)
+	if err != nil {
+		return wrapError(op, repo.mapper.TableName(), err)
+	}
+	nRows, err := result.RowsAffected()
+	if err != nil {
+		return wrapError(op, repo.mapper.TableName(), err)
+	}
+	if nRows == 0 {
+		return &OpError{Op: op, Table: repo.mapper.TableName(), Err: ErrNotFound}
+	}
+	if nRows != 1 {
+		return &OpError{
+			Op:    op,
+			Table: repo.mapper.TableName(),
+			Err:   fmt.Errorf("%d rows affected instead of 1", nRows),
+		}
+	}
+	return nil
+}
+
+// ExecMany runs the named statement, which may affect any number of rows,
+// and returns how many it affected.
+func (repo *Repository[T]) ExecMany(name string, args ...any) (int64, error) {
+	statement, err := repo.statement(name)
+	if err != nil {
+		return 0, err
+	}
+	result, err := statement.Exec(args...)
+	if err != nil {
+		return 0, wrapError(opName(name), repo.mapper.TableName(), err)
+	}
+	nRows, err := result.RowsAffected()
+	if err != nil {
+		return 0, wrapError(opName(name), repo.mapper.TableName(), err)
+	}
+	return nRows, nil
+}
+
+// opName turns a statement name like READ_BY_TALE into "read by tale".
+func opName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", " "))
+}
+
+func (repo *Repository[T]) Close() error {
+	var errs error
+	for name, statement := range repo.statements {
+		if statement != nil {
+			if currentErr := statement.Close(); currentErr != nil {
+				errs = errors.Join(errs, currentErr)
+			}
+		}
+		delete(repo.statements, name)
+	}
+	return errs
+}
@@ -0,0 +1,295 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"talenest/backend/internal/config"
+	"testing"
+)
+
+// openTestDatabase opens a migrated database in a temporary directory with
+// the tables of the tests.
+func openTestDatabase(t *testing.T) *DatabaseConnector {
+	t.Helper()
+	dir := t.TempDir()
+	dbConn, err := NewDatabaseConnector("sqlite", filepath.Join(dir, "talenest.db"), filepath.Join(dir, "backups"),
+		config.SQLiteConfig{
+			ForeignKeys:  true,
+			JournalMode:  "WAL",
+			Synchronous:  "NORMAL",
+			BusyTimeout:  1000,
+			MaxOpenConns: 4,
+			MaxIdleConns: 4,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		dbConn.Close()
+	})
+	_, err = dbConn.ExecuteQuery(`
+		CREATE TABLE shelf (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE CHECK(name != '')
+		);
+		CREATE TABLE book (
+			id INTEGER PRIMARY KEY,
+			title TEXT NOT NULL,
+			shelf_id INTEGER REFERENCES shelf(id),
+			version INTEGER NOT NULL DEFAULT 1
+		);`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dbConn
+}
+
+type book struct {
+	Id      int
+	Title   string
+	ShelfId *int
+	Version int
+}
+
+type bookMapper struct{}
+
+func (bookMapper) TableName() string {
+	return "book"
+}
+
+func (bookMapper) Columns() []string {
+	return []string{"id", "title", "shelf_id", "version"}
+}
+
+func (bookMapper) Values(entity *book) []any {
+	return []any{entity.Id, entity.Title, entity.ShelfId, entity.Version}
+}
+
+func (bookMapper) Scan(scanner Scanner) (*book, error) {
+	entity := &book{}
+	err := scanner.Scan(&entity.Id, &entity.Title, &entity.ShelfId, &entity.Version)
+	return entity, err
+}
+
+func (bookMapper) GetId(entity *book) int {
+	return entity.Id
+}
+
+func (bookMapper) SetId(entity *book, id int) {
+	entity.Id = id
+}
+
+// versionedBookMapper checks the version of the books when they're updated.
+type versionedBookMapper struct {
+	bookMapper
+}
+
+func (versionedBookMapper) VersionColumn() string {
+	return "version"
+}
+
+func (versionedBookMapper) GetVersion(entity *book) int {
+	return entity.Version
+}
+
+func (versionedBookMapper) SetVersion(entity *book, version int) {
+	entity.Version = version
+}
+
+func newBookRepository(t *testing.T, dbConn *DatabaseConnector, mapper Mapper[book]) *Repository[book] {
+	t.Helper()
+	repo, err := NewRepository[book](dbConn, mapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	return repo
+}
+
+func TestRepositoryCrud(t *testing.T) {
+	repo := newBookRepository(t, openTestDatabase(t), bookMapper{})
+
+	first := &book{Title: "The Hobbit"}
+	id, err := repo.Create(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == 0 || first.Id != id {
+		t.Fatalf("Create returned %d and set the id to %d", id, first.Id)
+	}
+	if _, err := repo.Create(&book{Title: "Silmarillion"}); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := repo.ReadById(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *read != *first {
+		t.Errorf("ReadById = %+v, want %+v", read, first)
+	}
+
+	first.Title = "There and Back Again"
+	if err := repo.Update(first); err != nil {
+		t.Fatal(err)
+	}
+	if read, _ := repo.ReadById(id); read.Title != first.Title {
+		t.Errorf("the title is %q after the update, want %q", read.Title, first.Title)
+	}
+
+	all, err := repo.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("ReadAll returned %d books, want 2", len(all))
+	}
+
+	if err := repo.Delete(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ReadById(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadById of a deleted book = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete of a deleted book = %v, want ErrNotFound", err)
+	}
+	if err := repo.Update(first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of a deleted book = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRepositoryStatements(t *testing.T) {
+	repo := newBookRepository(t, openTestDatabase(t), bookMapper{})
+	for _, title := range []string{"b", "a", "c"} {
+		if _, err := repo.Create(&book{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	builder := NewSelectQueryBuilder("book")
+	builder.SetColumns(ConvertToColumns(bookMapper{}.Columns()))
+	builder.SetWhere("book", column(t, "title"), "!=", NewTokenValue("?"), "")
+	builder.AddOrderBy(column(t, "title"), "")
+	query, _ := builder.Build()
+	if err := repo.Prepare("READ_OTHERS", query); err != nil {
+		t.Fatal(err)
+	}
+	books, err := repo.ReadMany("READ_OTHERS", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || books[0].Title != "a" || books[1].Title != "c" {
+		t.Errorf("ReadMany = %+v, want a and c", books)
+	}
+
+	if err := repo.Prepare("RENAME_ALL", "UPDATE book SET title = ?;"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Exec("RENAME_ALL", "x"); err == nil {
+		t.Error("Exec accepted a statement affecting 3 rows")
+	}
+	if renamed, err := repo.ExecMany("RENAME_ALL", "x"); err != nil || renamed != 3 {
+		t.Errorf("ExecMany = %d, %v, want 3 rows", renamed, err)
+	}
+	if _, err := repo.ReadMany("UNKNOWN"); err == nil {
+		t.Error("ReadMany ran a statement that isn't prepared")
+	}
+}
+
+func TestRepositoryWithTx(t *testing.T) {
+	dbConn := openTestDatabase(t)
+	repo := newBookRepository(t, dbConn, bookMapper{})
+	failure := errors.New("failure")
+
+	err := dbConn.Transaction(func(tx *sql.Tx) error {
+		if _, err := repo.WithTx(tx).Create(&book{Title: "rolled back"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Transaction = %v, want the error of the function", err)
+	}
+	err = dbConn.Transaction(func(tx *sql.Tx) error {
+		_, err := repo.WithTx(tx).Create(&book{Title: "committed"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	books, err := repo.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 1 || books[0].Title != "committed" {
+		t.Errorf("the books are %+v, want only the committed one", books)
+	}
+}
+
+type unversionedColumnsMapper struct {
+	versionedBookMapper
+}
+
+func (unversionedColumnsMapper) Columns() []string {
+	return []string{"id", "title", "shelf_id"}
+}
+
+func TestNewRepositoryChecksVersionColumn(t *testing.T) {
+	if _, err := NewRepository[book](openTestDatabase(t), unversionedColumnsMapper{}); err == nil {
+		t.Error("NewRepository accepted a version column missing from the columns")
+	}
+}
+
+func TestRepositoryVersionedUpdate(t *testing.T) {
+	repo := newBookRepository(t, openTestDatabase(t), versionedBookMapper{})
+	first := &book{Title: "draft", Version: 1}
+	if _, err := repo.Create(first); err != nil {
+		t.Fatal(err)
+	}
+	stale := *first
+
+	first.Title = "first edit"
+	if err := repo.Update(first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Version != 2 {
+		t.Errorf("the version is %d after the update, want 2", first.Version)
+	}
+
+	stale.Title = "second edit"
+	err := repo.Update(&stale)
+	var staleErr *StaleError[book]
+	if !errors.As(err, &staleErr) {
+		t.Fatalf("Update of an old version = %v, want a StaleError", err)
+	}
+	if !errors.Is(err, ErrStale) || !errors.Is(err, ErrConflict) {
+		t.Errorf("%v isn't ErrStale and ErrConflict", err)
+	}
+	if staleErr.Id != first.Id || staleErr.Version != 1 || *staleErr.Current != *first {
+		t.Errorf("the StaleError is %+v with %+v, want the stored %+v", staleErr, staleErr.Current, first)
+	}
+	if stale.Version != 1 {
+		t.Errorf("a failed update changed the version to %d", stale.Version)
+	}
+
+	// overwriting takes the version of the stored book
+	stale.Version = staleErr.Current.Version
+	if err := repo.Update(&stale); err != nil {
+		t.Fatal(err)
+	}
+	if read, _ := repo.ReadById(first.Id); read.Title != "second edit" || read.Version != 3 {
+		t.Errorf("the stored book is %+v, want the second edit at version 3", read)
+	}
+
+	if err := repo.Delete(first.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Update(&stale); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of a deleted book = %v, want ErrNotFound", err)
+	}
+}
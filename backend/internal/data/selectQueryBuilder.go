@@ -6,9 +6,21 @@ import (
 	"strings"
 )
 
+var aggregateFunctions = map[string]bool{
+	"COUNT":        true,
+	"SUM":          true,
+	"AVG":          true,
+	"MIN":          true,
+	"MAX":          true,
+	"TOTAL":        true,
+	"GROUP_CONCAT": true,
+}
+
 type Column struct {
-	colname string
-	alias   string
+	colname  string
+	alias    string
+	function string
+	subquery *SelectQueryBuilder
 }
 
 func NewColumn(colName string, alias string) (*Column, error) {
@@ -22,18 +34,73 @@ func NewColumn(colName string, alias string) (*Column, error) {
 	}, nil
 }
 
-func (column *Column) String() string {
+// NewAggregateColumn returns a column applying an aggregate function
+// (COUNT, SUM, AVG, MIN, MAX, TOTAL, GROUP_CONCAT) to colName.
+func NewAggregateColumn(function string, colName string, alias string) (*Column, error) {
+	function = strings.ToUpper(strings.TrimSpace(function))
+	if !aggregateFunctions[function] {
+		return nil, fmt.Errorf("invalid aggregate function %q", function)
+	}
+	if colName == "" {
+		return nil, errors.New("the aggregated column name can't be empty")
+	}
+
+	return &Column{
+		colname:  colName,
+		alias:    alias,
+		function: function,
+	}, nil
+}
+
+// NewSubqueryColumn returns a column whose value is the result of a scalar
+// subquery. The alias is mandatory.
+func NewSubqueryColumn(subquery *SelectQueryBuilder, alias string) (*Column, error) {
+	if subquery == nil {
+		return nil, errors.New("the subquery can't be nil")
+	}
+	if alias == "" {
+		return nil, errors.New("a subquery column needs an alias")
+	}
+
+	return &Column{
+		alias:    alias,
+		subquery: subquery,
+	}, nil
+}
+
+// expression renders the column without its alias, qualifying the column
+// name with tableName when given. The arguments of a subquery are appended
+// to args.
+func (column *Column) expression(tableName string, args *[]any) string {
+	if column.subquery != nil {
+		return "(" + column.subquery.build(args) + ")"
+	}
+	name := column.colname
+	if tableName != "" && name != "*" {
+		name = tableName + "." + name
+	}
+	if column.function != "" {
+		return fmt.Sprintf("%s(%s)", column.function, name)
+	}
+	return name
+}
+
+func (column *Column) build(args *[]any) string {
 	if column.alias == "" {
-		return column.colname
+		return column.expression("", args)
 	}
-	return fmt.Sprintf("%s AS %s", column.colname, column.alias)
+	return fmt.Sprintf("%s AS %s", column.expression("", args), column.alias)
+}
+
+func (column *Column) String() string {
+	return column.build(&[]any{})
 }
 
 func (column *Column) GetColumnName() string {
 	if column.alias != "" {
 		return column.alias
 	}
-	return column.colname
+	return column.expression("", &[]any{})
 }
 
 func ConvertToColumns(columns []string) []Column {
@@ -45,126 +112,109 @@ func ConvertToColumns(columns []string) []Column {
 	return result
 }
 
-func ColumnsString(columns []Column) string {
+func columnsString(columns []Column, args *[]any) string {
 	if len(columns) == 0 {
 		return "*"
 	}
 	var builder strings.Builder
 
-	builder.WriteString(columns[0].String())
+	builder.WriteString(columns[0].build(args))
 	for i := 1; i < len(columns); i++ {
 		builder.WriteString(", ")
-		builder.WriteString(columns[i].String())
+		builder.WriteString(columns[i].build(args))
 	}
 
 	return builder.String()
 }
 
-type whereItem struct {
-	tableName     string
-	col           Column
-	operator      string
-	value         Value
-	logicOperator string
-}
-
-func newWhereItem(tableName string, col Column, operator string, value Value, logicOperator string) *whereItem {
-	// TODO: check valid operator
-	// TODO: check valid logicOperator
-	return &whereItem{
-		tableName:     tableName,
-		col:           col,
-		operator:      operator,
-		value:         value,
-		logicOperator: logicOperator,
-	}
-}
-
-func (item *whereItem) String() string {
-	return fmt.Sprintf("%v.%v %v %v", item.tableName, item.col.String(), item.operator, item.value)
-}
-
-func whereString(whereItems []whereItem) string {
-	if len(whereItems) == 0 {
-		return ""
-	}
-	var builder strings.Builder
-
-	builder.WriteString(whereItems[0].String())
-	for i := 1; i < len(whereItems); i++ {
-		builder.WriteString(whereItems[i].String() + " ")
-		builder.WriteString(whereItems[i].logicOperator)
-
-	}
-	return builder.String()
-}
-
 type joinItem struct {
 	sourceTable string
 	sourceField string
 	targetTable string
+	targetAlias string
 	targetField string
 	joinType    string
 }
 
-func newJoinItem(sourceTable, sourceField, targetTable, targetField, joinType string) *joinItem {
-	// TODO: Check joinType
+var joinTypes = map[string]bool{
+	"":            true,
+	"INNER":       true,
+	"LEFT":        true,
+	"LEFT OUTER":  true,
+	"RIGHT":       true,
+	"RIGHT OUTER": true,
+	"FULL":        true,
+	"FULL OUTER":  true,
+	"CROSS":       true,
+}
+
+func newJoinItem(sourceTable, sourceField, targetTable, targetField, joinType string) (*joinItem, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(joinType), " "))
+	if !joinTypes[normalized] {
+		return nil, fmt.Errorf("invalid join type %q", joinType)
+	}
 
 	return &joinItem{
 		sourceTable: sourceTable,
 		sourceField: sourceField,
 		targetField: targetField,
 		targetTable: targetTable,
-		joinType:    joinType,
-	}
+		joinType:    normalized,
+	}, nil
 }
 
 func (item *joinItem) String() string {
-	return fmt.Sprintf("%s JOIN %s ON %s.%s = %s.%s ",
-		item.joinType,
-		item.targetTable,
+	join := "JOIN"
+	if item.joinType != "" {
+		join = item.joinType + " JOIN"
+	}
+	target, targetName := item.targetTable, item.targetTable
+	if item.targetAlias != "" {
+		target, targetName = item.targetTable+" AS "+item.targetAlias, item.targetAlias
+	}
+	return fmt.Sprintf("%s %s ON %s.%s = %s.%s",
+		join,
+		target,
 		item.sourceTable,
 		item.sourceField,
-		item.targetTable,
+		targetName,
 		item.targetField)
-
 }
 
 type orderByItem struct {
-	cols    []Column
-	orderBy string
+	col       Column
+	direction string
 }
 
-func newOrderByItem(cols []Column, orderBy string) *orderByItem {
-	// TODO: check cols
-	return &orderByItem{
-		cols:    cols,
-		orderBy: orderBy,
+func normalizeDirection(direction string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(direction))
+	switch normalized {
+	case "", "ASC", "DESC":
+		return normalized, nil
 	}
+	return "", fmt.Errorf("invalid order direction %q", direction)
 }
 
 func (item *orderByItem) String() string {
-	builder := strings.Builder{}
-	builder.WriteString("ORDER BY ")
-	for i, col := range item.cols {
-		builder.WriteString(col.GetColumnName())
-		if i < len(item.cols)-1 {
-			builder.WriteString(", ")
-		}
+	if item.direction == "" {
+		return item.col.GetColumnName()
 	}
-	builder.WriteString(" ")
-	builder.WriteString(item.orderBy)
-	return builder.String()
+	return item.col.GetColumnName() + " " + item.direction
 }
 
 type SelectQueryBuilder struct {
-	query     strings.Builder
-	columns   []Column
-	tableName string
-	distinct  bool
-	whereList []whereItem
-	orderItem *orderByItem
-	joinItems []joinItem
+	whereClause
+	columns    []Column
+	tableName  string
+	tableAlias string
+	distinct   bool
+	orderItems []orderByItem
+	joinItems  []joinItem
+	groupBy    []Column
+	having     whereClause
+	limit      int
+	hasLimit   bool
+	offset     int
 }
 
 func NewSelectQueryBuilder(tableName string) *SelectQueryBuilder {
@@ -173,65 +223,184 @@ func NewSelectQueryBuilder(tableName string) *SelectQueryBuilder {
 	}
 }
 
+// SetTableAlias names the table alias in the query, e.g. to join it with
+// itself. The conditions and joins then refer to the table by its alias.
+func (builder *SelectQueryBuilder) SetTableAlias(alias string) {
+	builder.tableAlias = alias
+}
+
 func (builder *SelectQueryBuilder) SetDistinct() {
 	builder.distinct = true
 }
 
 func (builder *SelectQueryBuilder) SetColumns(columns []Column) {
-	if len(columns) == 0 {
-		col, _ := NewColumn("*", "")
-		builder.columns = append(builder.columns, *col)
-	}
 	builder.columns = columns
 }
 
-func (builder *SelectQueryBuilder) SetWhere(tableName string, col Column, operator string, value Value, logicOperator string) {
-	item := newWhereItem(tableName, col, operator, value, logicOperator)
-	builder.whereList = append(builder.whereList, *item)
+func (builder *SelectQueryBuilder) AddColumn(column Column) {
+	builder.columns = append(builder.columns, column)
 }
 
+// SetJoin joins targetTable on the equality of its targetField with the
+// sourceField of sourceTable. An empty joinType is a plain JOIN.
 func (builder *SelectQueryBuilder) SetJoin(
-	sourceTable string, sourceField Column, targetTable string, targetField Column, joinType string) {
-	item := newJoinItem(sourceTable, sourceField.GetColumnName(), targetTable, targetField.GetColumnName(), joinType)
+	sourceTable string, sourceField Column, targetTable string, targetField Column, joinType string) error {
+	item, err := newJoinItem(sourceTable, sourceField.GetColumnName(), targetTable, targetField.GetColumnName(), joinType)
+	if err != nil {
+		return err
+	}
 	builder.joinItems = append(builder.joinItems, *item)
+	return nil
+}
+
+// SetJoinAlias is SetJoin naming targetTable alias in the query, so that a
+// table can be joined more than once.
+func (builder *SelectQueryBuilder) SetJoinAlias(
+	sourceTable string, sourceField Column, targetTable string, alias string, targetField Column, joinType string) error {
+	if alias == "" {
+		return errors.New("the alias of the joined table can't be empty")
+	}
+	item, err := newJoinItem(sourceTable, sourceField.GetColumnName(), targetTable, targetField.GetColumnName(), joinType)
+	if err != nil {
+		return err
+	}
+	item.targetAlias = alias
+	builder.joinItems = append(builder.joinItems, *item)
+	return nil
+}
+
+// OrderBy replaces the ordering with columns, all sorted in the same
+// direction.
+func (builder *SelectQueryBuilder) OrderBy(columns []Column, orderBy string) error {
+	direction, err := normalizeDirection(orderBy)
+	if err != nil {
+		return err
+	}
+	builder.orderItems = nil
+	for _, col := range columns {
+		builder.orderItems = append(builder.orderItems, orderByItem{col: col, direction: direction})
+	}
+	return nil
+}
+
+// AddOrderBy appends a column to the ordering with its own direction.
+func (builder *SelectQueryBuilder) AddOrderBy(col Column, direction string) error {
+	direction, err := normalizeDirection(direction)
+	if err != nil {
+		return err
+	}
+	builder.orderItems = append(builder.orderItems, orderByItem{col: col, direction: direction})
+	return nil
+}
+
+func (builder *SelectQueryBuilder) GroupBy(columns []Column) {
+	builder.groupBy = columns
+}
+
+func (builder *SelectQueryBuilder) SetHaving(tableName string, col Column, operator string, value Value, logicOperator string) error {
+	if len(builder.groupBy) == 0 {
+		return errors.New("HAVING requires a GROUP BY")
+	}
+	return builder.having.SetWhere(tableName, col, operator, value, logicOperator)
+}
+
+func (builder *SelectQueryBuilder) SetHavingGroup(group *WhereGroup, logicOperator string) error {
+	if len(builder.groupBy) == 0 {
+		return errors.New("HAVING requires a GROUP BY")
+	}
+	return builder.having.SetWhereGroup(group, logicOperator)
+}
+
+func (builder *SelectQueryBuilder) SetLimit(limit int) error {
+	if limit < 0 {
+		return errors.New("the limit can't be negative")
+	}
+	builder.limit = limit
+	builder.hasLimit = true
+	return nil
 }
 
-func (builder *SelectQueryBuilder) OrderBy(columns []Column, orderBy string) {
-	builder.orderItem = newOrderByItem(columns, orderBy)
+func (builder *SelectQueryBuilder) SetOffset(offset int) error {
+	if offset < 0 {
+		return errors.New("the offset can't be negative")
+	}
+	builder.offset = offset
+	return nil
 }
 
-func (builder *SelectQueryBuilder) Build() string {
-	builder.query.WriteString("SELECT ")
+// build renders the query without the trailing semicolon so it can be
+// nested as a subquery, appending its arguments to args.
+func (builder *SelectQueryBuilder) build(args *[]any) string {
+	var query strings.Builder
+	query.WriteString("SELECT ")
 	if builder.distinct {
 		// Adding DISTINCT
-		builder.query.WriteString("DISTINCT ")
+		query.WriteString("DISTINCT ")
 	}
 
 	// Adding columns
-	builder.query.WriteString(ColumnsString(builder.columns))
-	builder.query.WriteRune(' ')
+	query.WriteString(columnsString(builder.columns, args))
 
 	// Adding FROM
-	builder.query.WriteString(fmt.Sprintf("FROM %s ", builder.tableName))
+	query.WriteString(fmt.Sprintf(" FROM %s", builder.tableName))
+	if builder.tableAlias != "" {
+		query.WriteString(" AS " + builder.tableAlias)
+	}
 
 	// Adding joins
-	if builder.joinItems != nil || len(builder.joinItems) > 0 {
-		for _, join := range builder.joinItems {
-			builder.query.WriteString(join.String())
-		}
+	for _, join := range builder.joinItems {
+		query.WriteString(" " + join.String())
 	}
 
 	// Adding where
-	if builder.whereList != nil || len(builder.whereList) > 0 {
-		builder.query.WriteString("WHERE ")
-		builder.query.WriteString(whereString(builder.whereList))
+	if builder.whereClause.hasConditions() {
+		query.WriteString(" WHERE ")
+		query.WriteString(whereString(builder.whereClause.conditions, args))
+	}
+
+	// Adding group by
+	if len(builder.groupBy) > 0 {
+		query.WriteString(" GROUP BY ")
+		for i, col := range builder.groupBy {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(col.GetColumnName())
+		}
+		if builder.having.hasConditions() {
+			query.WriteString(" HAVING ")
+			query.WriteString(whereString(builder.having.conditions, args))
+		}
 	}
 
 	// Adding order by
-	if builder.orderItem != nil {
-		builder.query.WriteString(builder.orderItem.String())
+	if len(builder.orderItems) > 0 {
+		query.WriteString(" ORDER BY ")
+		for i, item := range builder.orderItems {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(item.String())
+		}
 	}
 
-	builder.query.WriteRune(';')
-	return builder.query.String()
+	// Adding limit and offset, SQLite needs a LIMIT to accept an OFFSET
+	if builder.hasLimit {
+		query.WriteString(fmt.Sprintf(" LIMIT %d", builder.limit))
+	} else if builder.offset > 0 {
+		query.WriteString(" LIMIT -1")
+	}
+	if builder.offset > 0 {
+		query.WriteString(fmt.Sprintf(" OFFSET %d", builder.offset))
+	}
+
+	return query.String()
+}
+
+// Build returns the query and the arguments bound to its placeholders,
+// in order of appearance.
+func (builder *SelectQueryBuilder) Build() (string, []any) {
+	args := []any{}
+	query := builder.build(&args)
+	return query + ";", args
 }
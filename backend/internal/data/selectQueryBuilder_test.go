@@ -0,0 +1,312 @@
+package data
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func column(t *testing.T, name string) Column {
+	t.Helper()
+	column, err := NewColumn(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *column
+}
+
+func values(vals ...any) []Value {
+	result := []Value{}
+	for _, val := range vals {
+		switch val := val.(type) {
+		case int:
+			result = append(result, NewIntValue(val))
+		case string:
+			result = append(result, NewStringValue(val))
+		}
+	}
+	return result
+}
+
+func checkBuild(t *testing.T, query string, args []any, wantQuery string, wantArgs []any) {
+	t.Helper()
+	if query != wantQuery {
+		t.Errorf("query = %q\n want %q", query, wantQuery)
+	}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+}
+
+func TestSelectQueryBuilder(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(t *testing.T, builder *SelectQueryBuilder) error
+		query string
+		args  []any
+	}{
+		{
+			name:  "all columns",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error { return nil },
+			query: "SELECT * FROM tale;",
+			args:  []any{},
+		},
+		{
+			name: "distinct columns and alias",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				builder.SetDistinct()
+				title, _ := NewColumn("title", "name")
+				builder.SetColumns([]Column{column(t, "id"), *title})
+				return nil
+			},
+			query: "SELECT DISTINCT id, title AS name FROM tale;",
+			args:  []any{},
+		},
+		{
+			name: "where values are bound",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				if err := builder.SetWhere("tale", column(t, "title"), "like", NewStringValue("it's%"), ""); err != nil {
+					return err
+				}
+				return builder.SetWhere("tale", column(t, "status_id"), "!=", NewIntValue(2), "or")
+			},
+			query: "SELECT * FROM tale WHERE tale.title LIKE ? OR tale.status_id != ?;",
+			args:  []any{"it's%", int64(2)},
+		},
+		{
+			name: "tokens are written verbatim",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				return builder.SetWhere("tale", column(t, "id"), "=", NewTokenValue("?"), "")
+			},
+			query: "SELECT * FROM tale WHERE tale.id = ?;",
+			args:  []any{},
+		},
+		{
+			name: "in, not in, between and null",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				if err := builder.SetWhereIn("tale", column(t, "id"), values(1, 2), ""); err != nil {
+					return err
+				}
+				if err := builder.SetWhereNotIn("tale", column(t, "status_id"), values(3), ""); err != nil {
+					return err
+				}
+				if err := builder.SetWhereBetween("tale", column(t, "version"), NewIntValue(4), NewIntValue(5), ""); err != nil {
+					return err
+				}
+				if err := builder.SetWhereNull("tale", column(t, "deleted_at"), ""); err != nil {
+					return err
+				}
+				return builder.SetWhereNotNull("tale", column(t, "title"), "")
+			},
+			query: "SELECT * FROM tale WHERE tale.id IN (?, ?) AND tale.status_id NOT IN (?)" +
+				" AND tale.version BETWEEN ? AND ? AND tale.deleted_at IS NULL AND tale.title IS NOT NULL;",
+			args: []any{int64(1), int64(2), int64(3), int64(4), int64(5)},
+		},
+		{
+			name: "nested groups",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				group := NewWhereGroup()
+				group.SetWhere("", column(t, "a"), "=", NewIntValue(1), "")
+				group.SetWhere("", column(t, "b"), "=", NewIntValue(2), "OR")
+				if err := builder.SetWhere("", column(t, "c"), "=", NewIntValue(3), ""); err != nil {
+					return err
+				}
+				return builder.SetWhereGroup(group, "AND")
+			},
+			query: "SELECT * FROM tale WHERE c = ? AND (a = ? OR b = ?);",
+			args:  []any{int64(3), int64(1), int64(2)},
+		},
+		{
+			name: "subqueries bind their arguments in order",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				count := NewSelectQueryBuilder("chapter")
+				total, _ := NewAggregateColumn("count", "*", "")
+				count.SetColumns([]Column{*total})
+				count.SetWhere("chapter", column(t, "title"), "!=", NewStringValue("draft"), "")
+				chapters, _ := NewSubqueryColumn(count, "chapters")
+				builder.SetColumns([]Column{column(t, "id"), *chapters})
+
+				tagged := NewSelectQueryBuilder("tale_tag")
+				tagged.SetColumns([]Column{column(t, "tale_id")})
+				tagged.SetWhere("tale_tag", column(t, "tag_id"), "=", NewIntValue(7), "")
+				return builder.SetWhereInSubquery("tale", column(t, "id"), tagged, "")
+			},
+			query: "SELECT id, (SELECT COUNT(*) FROM chapter WHERE chapter.title != ?) AS chapters FROM tale" +
+				" WHERE tale.id IN (SELECT tale_id FROM tale_tag WHERE tale_tag.tag_id = ?);",
+			args: []any{"draft", int64(7)},
+		},
+		{
+			name: "joins",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				if err := builder.SetJoin("tale", column(t, "id"), "tale_tag", column(t, "tale_id"), ""); err != nil {
+					return err
+				}
+				return builder.SetJoin("tale_tag", column(t, "tag_id"), "tag", column(t, "id"), " left  outer ")
+			},
+			query: "SELECT * FROM tale JOIN tale_tag ON tale.id = tale_tag.tale_id" +
+				" LEFT OUTER JOIN tag ON tale_tag.tag_id = tag.id;",
+			args: []any{},
+		},
+		{
+			name: "table aliases",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				builder.SetTableAlias("first")
+				return builder.SetJoinAlias("first", column(t, "tale_id"), "tale", "second", column(t, "tale_id"), "")
+			},
+			query: "SELECT * FROM tale AS first JOIN tale AS second ON first.tale_id = second.tale_id;",
+			args:  []any{},
+		},
+		{
+			name: "grouping",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				count, _ := NewAggregateColumn("count", "id", "tales")
+				builder.SetColumns([]Column{column(t, "status_id"), *count})
+				builder.GroupBy([]Column{column(t, "status_id")})
+				return builder.SetHaving("", *count, ">", NewIntValue(1), "")
+			},
+			query: "SELECT status_id, COUNT(id) AS tales FROM tale GROUP BY status_id HAVING COUNT(id) > ?;",
+			args:  []any{int64(1)},
+		},
+		{
+			name: "ordering",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				if err := builder.OrderBy([]Column{column(t, "a"), column(t, "b")}, "desc"); err != nil {
+					return err
+				}
+				return builder.AddOrderBy(column(t, "c"), "")
+			},
+			query: "SELECT * FROM tale ORDER BY a DESC, b DESC, c;",
+			args:  []any{},
+		},
+		{
+			name: "limit and offset",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				if err := builder.SetLimit(10); err != nil {
+					return err
+				}
+				return builder.SetOffset(20)
+			},
+			query: "SELECT * FROM tale LIMIT 10 OFFSET 20;",
+			args:  []any{},
+		},
+		{
+			name: "offset without limit",
+			build: func(t *testing.T, builder *SelectQueryBuilder) error {
+				return builder.SetOffset(5)
+			},
+			query: "SELECT * FROM tale LIMIT -1 OFFSET 5;",
+			args:  []any{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := NewSelectQueryBuilder("tale")
+			if err := test.build(t, builder); err != nil {
+				t.Fatal(err)
+			}
+			query, args := builder.Build()
+			checkBuild(t, query, args, test.query, test.args)
+		})
+	}
+}
+
+func TestSelectQueryBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(t *testing.T, builder *SelectQueryBuilder) error
+	}{
+		{"operator", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetWhere("", column(t, "id"), "= 1 OR 1 =", NewIntValue(1), "")
+		}},
+		{"logic operator", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetWhere("", column(t, "id"), "=", NewIntValue(1), "XOR")
+		}},
+		{"nil value", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetWhere("", column(t, "id"), "=", nil, "")
+		}},
+		{"empty in", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetWhereIn("", column(t, "id"), nil, "")
+		}},
+		{"empty group", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetWhereGroup(NewWhereGroup(), "")
+		}},
+		{"join type", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetJoin("tale", column(t, "id"), "tag", column(t, "id"), "NATURAL; DROP TABLE tale; --")
+		}},
+		{"join alias", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetJoinAlias("tale", column(t, "id"), "tale", "", column(t, "id"), "")
+		}},
+		{"having without group", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetHaving("", column(t, "id"), "=", NewIntValue(1), "")
+		}},
+		{"direction", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.AddOrderBy(column(t, "id"), "sideways")
+		}},
+		{"negative limit", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetLimit(-1)
+		}},
+		{"negative offset", func(t *testing.T, builder *SelectQueryBuilder) error {
+			return builder.SetOffset(-1)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.build(t, NewSelectQueryBuilder("tale")); err == nil {
+				t.Error("the builder accepted it")
+			}
+		})
+	}
+	if _, err := NewAggregateColumn("median", "id", ""); err == nil {
+		t.Error("NewAggregateColumn accepted an unknown function")
+	}
+	if _, err := NewSubqueryColumn(NewSelectQueryBuilder("tale"), ""); err == nil {
+		t.Error("NewSubqueryColumn accepted a subquery without alias")
+	}
+}
+
+// TestSelectQueryBuilderRuns runs a built query so the SQL is known to be
+// valid, and that the bound values are compared as values.
+func TestSelectQueryBuilderRuns(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE tale (id INTEGER PRIMARY KEY, title TEXT, status_id INTEGER);
+		INSERT INTO tale (title, status_id) VALUES ('a', 1), ('it''s', 1), ('c', 2), ('d', 2), ('e', 3);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewSelectQueryBuilder("tale")
+	count, _ := NewAggregateColumn("count", "id", "tales")
+	builder.SetColumns([]Column{column(t, "status_id"), *count})
+	group := NewWhereGroup()
+	group.SetWhere("tale", column(t, "title"), "=", NewStringValue("it's"), "")
+	group.SetWhere("tale", column(t, "status_id"), ">", NewIntValue(1), "OR")
+	builder.SetWhereGroup(group, "")
+	builder.GroupBy([]Column{column(t, "status_id")})
+	builder.SetHaving("", *count, ">=", NewIntValue(1), "")
+	builder.AddOrderBy(column(t, "status_id"), "DESC")
+	builder.SetLimit(2)
+
+	query, args := builder.Build()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := [][2]int{}
+	for rows.Next() {
+		var status, tales int
+		if err := rows.Scan(&status, &tales); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, [2]int{status, tales})
+	}
+	if want := [][2]int{{3, 1}, {2, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("%s returned %v, want %v", query, got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type StartupCause string
+
+const (
+	CauseLocked           StartupCause = "locked"
+	CauseCorrupt          StartupCause = "corrupt"
+	CausePermissionDenied StartupCause = "permission_denied"
+	CauseMigrationDirty   StartupCause = "migration_dirty"
+	CauseMigrationFailed  StartupCause = "migration_failed"
+	CauseUnknown          StartupCause = "unknown"
+)
+
+// StartupError is returned when a database can't be opened, with the cause
+// the application can offer a recovery for.
+type StartupError struct {
+	Cause StartupCause
+	Path  string
+	Err   error
+}
+
+func (err *StartupError) Error() string {
+	return fmt.Sprintf("opening %s (%s): %v", err.Path, err.Cause, err.Err)
+}
+
+func (err *StartupError) Unwrap() error {
+	return err.Err
+}
+
+func newStartupError(path string, cause StartupCause, err error) *StartupError {
+	return &StartupError{
+		Cause: cause,
+		Path:  path,
+		Err:   err,
+	}
+}
+
+// startupCause classifies an error met while opening and migrating a
+// database.
+func startupCause(err error) StartupCause {
+	var dirty migrate.ErrDirty
+	if errors.As(err, &dirty) {
+		return CauseMigrationDirty
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return CausePermissionDenied
+	}
+
+	// golang-migrate doesn't expose the driver error through Unwrap
+	var migrateErr database.Error
+	if errors.As(err, &migrateErr) && migrateErr.OrigErr != nil {
+		if cause := startupCause(migrateErr.OrigErr); cause != CauseUnknown {
+			return cause
+		}
+		return CauseMigrationFailed
+	}
+
+	code, ok := sqliteCode(err)
+	if !ok {
+		return CauseUnknown
+	}
+	switch code & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return CauseLocked
+	case sqlite3.SQLITE_CORRUPT, sqlite3.SQLITE_NOTADB:
+		return CauseCorrupt
+	case sqlite3.SQLITE_PERM, sqlite3.SQLITE_READONLY, sqlite3.SQLITE_CANTOPEN, sqlite3.SQLITE_AUTH:
+		return CausePermissionDenied
+	}
+	return CauseUnknown
+}
@@ -8,40 +8,154 @@ const (
 	DELETE_STATEMENT   = "DELETE"
 )
 
+// The statements below are templates for prepared statements: every value
+// is a "?" token filled when the statement is executed, so the builders
+// never bind arguments of their own.
+
 func CreateQuery(tableName string, columnNames []string) string {
 	builder := NewInsertQueryBuilder(tableName)
 	builder.SetColumns(ConvertToColumns(columnNames))
 	builder.SetValues(GetTokens(len(columnNames), "?"))
-	queryStr, _ := builder.Build()
+	queryStr, _, _ := builder.Build()
 	return queryStr
 }
 
+// CreateIgnoreQuery is CreateQuery leaving out a row conflicting with a
+// stored one, e.g. a link made twice.
+func CreateIgnoreQuery(tableName string, columnNames []string) string {
+	builder := NewInsertQueryBuilder(tableName)
+	builder.SetIgnore()
+	builder.SetColumns(ConvertToColumns(columnNames))
+	builder.SetValues(GetTokens(len(columnNames), "?"))
+	queryStr, _, _ := builder.Build()
+	return queryStr
+}
+
+// ReadByIdQuery reads a row by its id, the first of the columns.
 func ReadByIdQuery(tableName string, columnNames []string) string {
+	return ReadByColumnQuery(tableName, columnNames, columnNames[0])
+}
+
+func ReadByColumnQuery(tableName string, columnNames []string, column string) string {
+	builder := NewSelectQueryBuilder(tableName)
+	builder.SetColumns(ConvertToColumns(columnNames))
+	whereColumn, _ := NewColumn(column, "")
+	builder.SetWhere(tableName, *whereColumn, "=", NewTokenValue("?"), "")
+	query, _ := builder.Build()
+	return query
+}
+
+// ReadByColumnOrderedQuery is ReadByColumnQuery sorted by orderColumns,
+// ascending.
+func ReadByColumnOrderedQuery(tableName string, columnNames []string, column string, orderColumns []string) string {
+	builder := NewSelectQueryBuilder(tableName)
+	builder.SetColumns(ConvertToColumns(columnNames))
+	whereColumn, _ := NewColumn(column, "")
+	builder.SetWhere(tableName, *whereColumn, "=", NewTokenValue("?"), "")
+	builder.OrderBy(ConvertToColumns(orderColumns), "ASC")
+	query, _ := builder.Build()
+	return query
+}
+
+// ReadByColumnInQuery is ReadByColumnOrderedQuery matching any of the
+// values of a JSON array, so that one statement reads the rows of any
+// number of values.
+func ReadByColumnInQuery(tableName string, columnNames []string, column string, orderColumns []string) string {
+	values := NewSelectQueryBuilder("json_each(?)")
+	values.SetColumns(ConvertToColumns([]string{"value"}))
 	builder := NewSelectQueryBuilder(tableName)
 	builder.SetColumns(ConvertToColumns(columnNames))
-	idColumn, _ := NewColumn("id", "")
-	builder.SetWhere(tableName, *idColumn, "=", NewTokenValue("?"), "")
-	return builder.Build()
+	whereColumn, _ := NewColumn(column, "")
+	builder.SetWhereInSubquery(tableName, *whereColumn, values, "")
+	builder.OrderBy(ConvertToColumns(orderColumns), "ASC")
+	query, _ := builder.Build()
+	return query
 }
 
 func ReadAllQuery(tableName string, columnNames []string) string {
 	builder := NewSelectQueryBuilder(tableName)
 	builder.SetColumns(ConvertToColumns(columnNames))
-	return builder.Build()
+	query, _ := builder.Build()
+	return query
 }
 
+// UpdateQuery updates every column of a row, the trailing placeholder is
+// its id, the first of the columns.
 func UpdateQuery(tableName string, columnNames []string) string {
-	// skip id
 	builder := NewUpdateQueryBuilder(tableName)
 	builder.SetNewValues(ConvertToColumns(columnNames), GetTokens(len(columnNames), "?"))
-	idCol, _ := NewColumn("id", "")
+	idCol, _ := NewColumn(columnNames[0], "")
+	builder.SetWhere(tableName, *idCol, "=", NewTokenValue("?"), "")
+	query, _ := builder.Build()
+	return query
+}
+
+// VersionedUpdateQuery updates every column but the version, which is
+// incremented. The trailing placeholders are the id, the first of the
+// columns, and the expected version.
+func VersionedUpdateQuery(tableName string, columnNames []string, versionColumn string) string {
+	builder := NewUpdateQueryBuilder(tableName)
+	values := GetTokens(len(columnNames), "?")
+	for i, column := range columnNames {
+		if column == versionColumn {
+			values[i] = NewTokenValue(versionColumn + " + 1")
+		}
+	}
+	builder.SetNewValues(ConvertToColumns(columnNames), values)
+	idCol, _ := NewColumn(columnNames[0], "")
 	builder.SetWhere(tableName, *idCol, "=", NewTokenValue("?"), "")
-	return builder.Build()
+	versionCol, _ := NewColumn(versionColumn, "")
+	builder.SetWhere(tableName, *versionCol, "=", NewTokenValue("?"), "AND")
+	query, _ := builder.Build()
+	return query
 }
 
 func DeleteQuery(tableName string) string {
 	builder := NewDeleteQueryBuilder(tableName)
 	idCol, _ := NewColumn("id", "")
 	builder.SetWhere(tableName, *idCol, "=", NewTokenValue("?"), "")
-	return builder.Build()
+	query, _ := builder.Build()
+	return query
+}
+
+// ReadByColumnsQuery selects the rows matching a value for each of the
+// columns.
+func ReadByColumnsQuery(tableName string, columnNames []string, columns []string) string {
+	builder := NewSelectQueryBuilder(tableName)
+	builder.SetColumns(ConvertToColumns(columnNames))
+	for _, column := range columns {
+		whereColumn, _ := NewColumn(column, "")
+		builder.SetWhere(tableName, *whereColumn, "=", NewTokenValue("?"), "AND")
+	}
+	query, _ := builder.Build()
+	return query
+}
+
+// DeleteByColumnsQuery deletes the rows matching a value for each of the
+// columns, e.g. a link between two entities.
+func DeleteByColumnsQuery(tableName string, columns []string) string {
+	builder := NewDeleteQueryBuilder(tableName)
+	for _, column := range columns {
+		whereColumn, _ := NewColumn(column, "")
+		builder.SetWhere(tableName, *whereColumn, "=", NewTokenValue("?"), "AND")
+	}
+	query, _ := builder.Build()
+	return query
+}
+
+// UpdateColumnsQuery updates the given columns of a row, the trailing
+// placeholder is the id.
+func UpdateColumnsQuery(tableName string, columns []string) string {
+	return UpdateByColumnQuery(tableName, columns, "id")
+}
+
+// UpdateByColumnQuery updates the given columns of the rows matching a
+// value of column, the trailing placeholder.
+func UpdateByColumnQuery(tableName string, columns []string, column string) string {
+	builder := NewUpdateQueryBuilder(tableName)
+	builder.SetNewValues(ConvertToColumns(columns), GetTokens(len(columns), "?"))
+	whereColumn, _ := NewColumn(column, "")
+	builder.SetWhere(tableName, *whereColumn, "=", NewTokenValue("?"), "")
+	query, _ := builder.Build()
+	return query
 }
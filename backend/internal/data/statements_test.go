@@ -0,0 +1,38 @@
+package data
+
+import "testing"
+
+func TestStatementQueries(t *testing.T) {
+	columns := []string{"id", "title", "version"}
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"create", CreateQuery("tale", columns), "INSERT INTO tale (id, title, version) VALUES (?, ?, ?)"},
+		{"create ignore", CreateIgnoreQuery("tale_tag", []string{"tale_id", "tag_id"}),
+			"INSERT OR IGNORE INTO tale_tag (tale_id, tag_id) VALUES (?, ?)"},
+		{"read by id", ReadByIdQuery("tale", columns), "SELECT id, title, version FROM tale WHERE tale.id = ?;"},
+		{"read all", ReadAllQuery("tale", columns), "SELECT id, title, version FROM tale;"},
+		{"update", UpdateQuery("tale", columns), "UPDATE tale SET id = ?, title = ?, version = ? WHERE tale.id = ?;"},
+		{"versioned update", VersionedUpdateQuery("tale", columns, "version"),
+			"UPDATE tale SET id = ?, title = ?, version = version + 1 WHERE tale.id = ? AND tale.version = ?;"},
+		{"delete", DeleteQuery("tale"), "DELETE FROM tale WHERE tale.id = ?;"},
+		{"read by column ordered", ReadByColumnOrderedQuery("chapter", columns, "tale_id", []string{"position", "id"}),
+			"SELECT id, title, version FROM chapter WHERE chapter.tale_id = ? ORDER BY position ASC, id ASC;"},
+		{"read by column in", ReadByColumnInQuery("chapter", columns, "tale_id", []string{"id"}),
+			"SELECT id, title, version FROM chapter WHERE chapter.tale_id IN (SELECT value FROM json_each(?)) ORDER BY id ASC;"},
+		{"read by columns", ReadByColumnsQuery("tale_tag", []string{"tale_id"}, []string{"tale_id", "tag_id"}),
+			"SELECT tale_id FROM tale_tag WHERE tale_tag.tale_id = ? AND tale_tag.tag_id = ?;"},
+		{"delete by columns", DeleteByColumnsQuery("tale_tag", []string{"tale_id", "tag_id"}),
+			"DELETE FROM tale_tag WHERE tale_tag.tale_id = ? AND tale_tag.tag_id = ?;"},
+		{"update columns", UpdateColumnsQuery("tag", []string{"parent_id"}), "UPDATE tag SET parent_id = ? WHERE tag.id = ?;"},
+		{"update by column", UpdateByColumnQuery("tag_alias", []string{"tag_id"}, "tag_id"),
+			"UPDATE tag_alias SET tag_id = ? WHERE tag_alias.tag_id = ?;"},
+	}
+	for _, test := range tests {
+		if test.query != test.want {
+			t.Errorf("%s: %q\n want %q", test.name, test.query, test.want)
+		}
+	}
+}
@@ -6,10 +6,10 @@ import (
 )
 
 type UpdateQueryBuilder struct {
+	whereClause
 	tableName string
 	columns   []Column
 	values    []Value
-	whereList []whereItem
 }
 
 func NewUpdateQueryBuilder(tableName string) *UpdateQueryBuilder {
@@ -17,7 +17,6 @@ func NewUpdateQueryBuilder(tableName string) *UpdateQueryBuilder {
 		tableName: tableName,
 		columns:   []Column{},
 		values:    []Value{},
-		whereList: []whereItem{},
 	}
 }
 
@@ -25,30 +24,31 @@ func (builder *UpdateQueryBuilder) SetNewValues(columns []Column, values []Value
 	if len(columns) != len(values) {
 		return errors.New("columns and values must have the same length")
 	}
+	if err := checkValues(values...); err != nil {
+		return err
+	}
 
 	builder.columns = columns
 	builder.values = values
 	return nil
 }
 
-func (builder *UpdateQueryBuilder) SetWhere(tableName string, col Column, operator string, value Value, logicOperator string) {
-	item := newWhereItem(tableName, col, operator, value, logicOperator)
-	builder.whereList = append(builder.whereList, *item)
-}
-
-func (builder *UpdateQueryBuilder) Build() string {
+// Build returns the query and the arguments bound to its placeholders,
+// in order of appearance.
+func (builder *UpdateQueryBuilder) Build() (string, []any) {
+	args := []any{}
 	query := fmt.Sprintf("UPDATE %s SET ", builder.tableName)
 	for i, column := range builder.columns {
-		query += fmt.Sprintf("%s = %s", column.GetColumnName(), builder.values[i].GetValueString())
+		query += fmt.Sprintf("%s = %s", column.GetColumnName(), bindValue(builder.values[i], &args))
 		if i < len(builder.columns)-1 {
 			query += ", "
 		}
 	}
 
-	if len(builder.whereList) > 0 {
+	if builder.hasConditions() {
 		query += " WHERE "
-		query += whereString(builder.whereList)
+		query += whereString(builder.conditions, &args)
 	}
 	query += ";"
-	return query
+	return query, args
 }
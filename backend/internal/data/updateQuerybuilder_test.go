@@ -0,0 +1,32 @@
+package data
+
+import "testing"
+
+func TestUpdateQueryBuilder(t *testing.T) {
+	builder := NewUpdateQueryBuilder("tale")
+	err := builder.SetNewValues(ConvertToColumns([]string{"title", "version"}),
+		[]Value{NewStringValue("it's"), NewTokenValue("version + 1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.SetWhere("tale", column(t, "id"), "=", NewIntValue(3), "")
+	builder.SetWhere("tale", column(t, "version"), "=", NewIntValue(2), "")
+	query, args := builder.Build()
+	checkBuild(t, query, args,
+		"UPDATE tale SET title = ?, version = version + 1 WHERE tale.id = ? AND tale.version = ?;",
+		[]any{"it's", int64(3), int64(2)})
+
+	if err := builder.SetNewValues(ConvertToColumns([]string{"title"}), nil); err == nil {
+		t.Error("SetNewValues accepted fewer values than columns")
+	}
+}
+
+func TestDeleteQueryBuilder(t *testing.T) {
+	builder := NewDeleteQueryBuilder("tale_tag")
+	builder.SetWhere("tale_tag", column(t, "tale_id"), "=", NewIntValue(1), "")
+	builder.SetWhereIn("tale_tag", column(t, "tag_id"), values(2, 3), "")
+	query, args := builder.Build()
+	checkBuild(t, query, args,
+		"DELETE FROM tale_tag WHERE tale_tag.tale_id = ? AND tale_tag.tag_id IN (?, ?);",
+		[]any{int64(1), int64(2), int64(3)})
+}
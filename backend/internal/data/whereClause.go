@@ -0,0 +1,308 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var comparisonOperators = map[string]bool{
+	"=":        true,
+	"==":       true,
+	"!=":       true,
+	"<>":       true,
+	"<":        true,
+	"<=":       true,
+	">":        true,
+	">=":       true,
+	"IS":       true,
+	"IS NOT":   true,
+	"LIKE":     true,
+	"NOT LIKE": true,
+	"GLOB":     true,
+	"NOT GLOB": true,
+}
+
+func normalizeOperator(operator string) (string, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(operator), " "))
+	if !comparisonOperators[normalized] {
+		return "", fmt.Errorf("invalid operator %q", operator)
+	}
+	return normalized, nil
+}
+
+// normalizeLogicOperator validates the operator joining a condition to the
+// previous one. An empty operator defaults to AND.
+func normalizeLogicOperator(logicOperator string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(logicOperator))
+	switch normalized {
+	case "":
+		return "AND", nil
+	case "AND", "OR":
+		return normalized, nil
+	}
+	return "", fmt.Errorf("invalid logic operator %q", logicOperator)
+}
+
+type condition interface {
+	build(args *[]any) string
+	getLogicOperator() string
+}
+
+type whereItem struct {
+	tableName     string
+	col           Column
+	operator      string
+	value         Value
+	logicOperator string
+}
+
+func newWhereItem(tableName string, col Column, operator string, value Value, logicOperator string) (*whereItem, error) {
+	operator, err := normalizeOperator(operator)
+	if err != nil {
+		return nil, err
+	}
+	logicOperator, err = normalizeLogicOperator(logicOperator)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkValues(value); err != nil {
+		return nil, err
+	}
+	return &whereItem{
+		tableName:     tableName,
+		col:           col,
+		operator:      operator,
+		value:         value,
+		logicOperator: logicOperator,
+	}, nil
+}
+
+func (item *whereItem) getLogicOperator() string {
+	return item.logicOperator
+}
+
+func (item *whereItem) build(args *[]any) string {
+	expression := item.col.expression(item.tableName, args)
+	return fmt.Sprintf("%s %s %s", expression, item.operator, bindValue(item.value, args))
+}
+
+type inItem struct {
+	tableName     string
+	col           Column
+	values        []Value
+	subquery      *SelectQueryBuilder
+	not           bool
+	logicOperator string
+}
+
+func (item *inItem) getLogicOperator() string {
+	return item.logicOperator
+}
+
+func (item *inItem) build(args *[]any) string {
+	var builder strings.Builder
+	builder.WriteString(item.col.expression(item.tableName, args))
+	if item.not {
+		builder.WriteString(" NOT")
+	}
+	builder.WriteString(" IN ")
+	if item.subquery != nil {
+		builder.WriteString("(" + item.subquery.build(args) + ")")
+	} else {
+		builder.WriteString(buildValuesString(item.values, args))
+	}
+	return builder.String()
+}
+
+type betweenItem struct {
+	tableName     string
+	col           Column
+	low           Value
+	high          Value
+	logicOperator string
+}
+
+func (item *betweenItem) getLogicOperator() string {
+	return item.logicOperator
+}
+
+func (item *betweenItem) build(args *[]any) string {
+	expression := item.col.expression(item.tableName, args)
+	return fmt.Sprintf("%s BETWEEN %s AND %s", expression, bindValue(item.low, args), bindValue(item.high, args))
+}
+
+type nullItem struct {
+	tableName     string
+	col           Column
+	not           bool
+	logicOperator string
+}
+
+func (item *nullItem) getLogicOperator() string {
+	return item.logicOperator
+}
+
+func (item *nullItem) build(args *[]any) string {
+	if item.not {
+		return item.col.expression(item.tableName, args) + " IS NOT NULL"
+	}
+	return item.col.expression(item.tableName, args) + " IS NULL"
+}
+
+type groupItem struct {
+	group         *WhereGroup
+	logicOperator string
+}
+
+func (item *groupItem) getLogicOperator() string {
+	return item.logicOperator
+}
+
+func (item *groupItem) build(args *[]any) string {
+	return "(" + whereString(item.group.conditions, args) + ")"
+}
+
+func whereString(conditions []condition, args *[]any) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+
+	builder.WriteString(conditions[0].build(args))
+	for _, item := range conditions[1:] {
+		builder.WriteString(" " + item.getLogicOperator() + " ")
+		builder.WriteString(item.build(args))
+	}
+	return builder.String()
+}
+
+// whereClause holds the conditions of a WHERE (or HAVING) clause. It is
+// embedded by the query builders so they share the same predicates.
+// Each logicOperator joins the condition to the previous one.
+type whereClause struct {
+	conditions []condition
+}
+
+func (clause *whereClause) hasConditions() bool {
+	return len(clause.conditions) > 0
+}
+
+func (clause *whereClause) SetWhere(tableName string, col Column, operator string, value Value, logicOperator string) error {
+	item, err := newWhereItem(tableName, col, operator, value, logicOperator)
+	if err != nil {
+		return err
+	}
+	clause.conditions = append(clause.conditions, item)
+	return nil
+}
+
+func (clause *whereClause) setIn(tableName string, col Column, values []Value, subquery *SelectQueryBuilder, not bool, logicOperator string) error {
+	logicOperator, err := normalizeLogicOperator(logicOperator)
+	if err != nil {
+		return err
+	}
+	if subquery == nil && len(values) == 0 {
+		return errors.New("the IN condition needs at least one value")
+	}
+	if err := checkValues(values...); err != nil {
+		return err
+	}
+	clause.conditions = append(clause.conditions, &inItem{
+		tableName:     tableName,
+		col:           col,
+		values:        values,
+		subquery:      subquery,
+		not:           not,
+		logicOperator: logicOperator,
+	})
+	return nil
+}
+
+func (clause *whereClause) SetWhereIn(tableName string, col Column, values []Value, logicOperator string) error {
+	return clause.setIn(tableName, col, values, nil, false, logicOperator)
+}
+
+func (clause *whereClause) SetWhereNotIn(tableName string, col Column, values []Value, logicOperator string) error {
+	return clause.setIn(tableName, col, values, nil, true, logicOperator)
+}
+
+func (clause *whereClause) SetWhereInSubquery(tableName string, col Column, subquery *SelectQueryBuilder, logicOperator string) error {
+	if subquery == nil {
+		return errors.New("the subquery can't be nil")
+	}
+	return clause.setIn(tableName, col, nil, subquery, false, logicOperator)
+}
+
+func (clause *whereClause) SetWhereNotInSubquery(tableName string, col Column, subquery *SelectQueryBuilder, logicOperator string) error {
+	if subquery == nil {
+		return errors.New("the subquery can't be nil")
+	}
+	return clause.setIn(tableName, col, nil, subquery, true, logicOperator)
+}
+
+func (clause *whereClause) SetWhereBetween(tableName string, col Column, low Value, high Value, logicOperator string) error {
+	logicOperator, err := normalizeLogicOperator(logicOperator)
+	if err != nil {
+		return err
+	}
+	if err := checkValues(low, high); err != nil {
+		return err
+	}
+	clause.conditions = append(clause.conditions, &betweenItem{
+		tableName:     tableName,
+		col:           col,
+		low:           low,
+		high:          high,
+		logicOperator: logicOperator,
+	})
+	return nil
+}
+
+func (clause *whereClause) setNull(tableName string, col Column, not bool, logicOperator string) error {
+	logicOperator, err := normalizeLogicOperator(logicOperator)
+	if err != nil {
+		return err
+	}
+	clause.conditions = append(clause.conditions, &nullItem{
+		tableName:     tableName,
+		col:           col,
+		not:           not,
+		logicOperator: logicOperator,
+	})
+	return nil
+}
+
+func (clause *whereClause) SetWhereNull(tableName string, col Column, logicOperator string) error {
+	return clause.setNull(tableName, col, false, logicOperator)
+}
+
+func (clause *whereClause) SetWhereNotNull(tableName string, col Column, logicOperator string) error {
+	return clause.setNull(tableName, col, true, logicOperator)
+}
+
+// SetWhereGroup adds the conditions of group wrapped in parentheses.
+func (clause *whereClause) SetWhereGroup(group *WhereGroup, logicOperator string) error {
+	logicOperator, err := normalizeLogicOperator(logicOperator)
+	if err != nil {
+		return err
+	}
+	if group == nil || !group.hasConditions() {
+		return errors.New("the where group can't be empty")
+	}
+	clause.conditions = append(clause.conditions, &groupItem{
+		group:         group,
+		logicOperator: logicOperator,
+	})
+	return nil
+}
+
+// WhereGroup is a set of conditions rendered in parentheses, used to nest
+// AND/OR expressions.
+type WhereGroup struct {
+	whereClause
+}
+
+func NewWhereGroup() *WhereGroup {
+	return &WhereGroup{}
+}
@@ -0,0 +1,96 @@
+package text
+
+import (
+	"math"
+	"sort"
+)
+
+// Corpus weighs the terms of its documents by TF-IDF: a term weighs more
+// the more often it's used in a document and the fewer documents use it.
+type Corpus struct {
+	documents map[int]map[string]int
+	lengths   map[int]int
+	// frequencies counts the documents using each term
+	frequencies map[string]int
+}
+
+// Keyword is a term of a document with its weight.
+type Keyword struct {
+	Term   string
+	Weight float64
+}
+
+func NewCorpus() *Corpus {
+	return &Corpus{
+		documents:   map[int]map[string]int{},
+		lengths:     map[int]int{},
+		frequencies: map[string]int{},
+	}
+}
+
+// Add indexes the terms of a document, replacing the ones it had.
+func (corpus *Corpus) Add(id int, terms []string) {
+	corpus.Remove(id)
+	counts := map[string]int{}
+	for _, term := range terms {
+		counts[term]++
+	}
+	for term := range counts {
+		corpus.frequencies[term]++
+	}
+	corpus.documents[id] = counts
+	corpus.lengths[id] = len(terms)
+}
+
+func (corpus *Corpus) Remove(id int) {
+	for term := range corpus.documents[id] {
+		corpus.frequencies[term]--
+		if corpus.frequencies[term] == 0 {
+			delete(corpus.frequencies, term)
+		}
+	}
+	delete(corpus.documents, id)
+	delete(corpus.lengths, id)
+}
+
+func (corpus *Corpus) Len() int {
+	return len(corpus.documents)
+}
+
+// idf is smoothed so that terms used by every document still weigh a
+// little.
+func (corpus *Corpus) idf(term string) float64 {
+	return math.Log(float64(1+len(corpus.documents))/float64(1+corpus.frequencies[term])) + 1
+}
+
+// Weights returns the TF-IDF weight of every term of a document.
+func (corpus *Corpus) Weights(id int) map[string]float64 {
+	weights := map[string]float64{}
+	length := corpus.lengths[id]
+	if length == 0 {
+		return weights
+	}
+	for term, count := range corpus.documents[id] {
+		weights[term] = float64(count) / float64(length) * corpus.idf(term)
+	}
+	return weights
+}
+
+// Keywords returns the limit heaviest terms of a document, all of them
+// when limit is 0.
+func (corpus *Corpus) Keywords(id int, limit int) []Keyword {
+	keywords := []Keyword{}
+	for term, weight := range corpus.Weights(id) {
+		keywords = append(keywords, Keyword{Term: term, Weight: weight})
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Weight != keywords[j].Weight {
+			return keywords[i].Weight > keywords[j].Weight
+		}
+		return keywords[i].Term < keywords[j].Term
+	})
+	if limit > 0 && len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
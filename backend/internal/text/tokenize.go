@@ -0,0 +1,93 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stopWords are the English words too common to tell texts apart.
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`a about above after again against all am an and any are as at
+		be because been before being below between both but by can could did do does doing down
+		during each few for from further had has have having he her here hers herself him himself
+		his how i if in into is it its itself just me more most my myself no nor not now of off on
+		once only or other our ours ourselves out over own same she should so some such than that
+		the their theirs them themselves then there these they this those through to too under
+		until up very was we were what when where which while who whom why will with would you
+		your yours yourself yourselves said says one two also back like well even still`) {
+		stopWords[word] = true
+	}
+}
+
+// Token is a word of a text with its position, in bytes.
+type Token struct {
+	Word  string
+	Start int
+	End   int
+}
+
+// Tokens splits content into words, letters and digits, keeping their
+// positions. Apostrophes and hyphens between two letters are part of the
+// word.
+func Tokens(content string) []Token {
+	tokens := []Token{}
+	start := -1
+	for i, r := range content {
+		inWord := isWordRune(r)
+		if !inWord && start >= 0 && isJoiner(r) {
+			next, _ := utf8.DecodeRuneInString(content[i+utf8.RuneLen(r):])
+			inWord = isWordRune(next)
+		}
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			tokens = append(tokens, Token{Word: content[start:i], Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Word: content[start:], Start: start, End: len(content)})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isJoiner(r rune) bool {
+	return r == '\'' || r == '’' || r == '-'
+}
+
+// Terms returns the normalized words of content, without the stop words,
+// to be indexed.
+func Terms(content string) []string {
+	terms := []string{}
+	for _, token := range Tokens(content) {
+		term := Normalize(token.Word)
+		if term == "" || stopWords[term] {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// Normalize lower cases a word and strips its plural and possessive
+// endings, so that "Dragons" and "dragon's" both give "dragon".
+func Normalize(word string) string {
+	word = strings.ToLower(word)
+	word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return word[:len(word)-1]
+	}
+	return word
+}
@@ -0,0 +1,841 @@
+export namespace api {
+	
+	export class Attachment {
+	    id: number;
+	    hash: string;
+	    name: string;
+	    mimeType: string;
+	    size: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Attachment(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.hash = source["hash"];
+	        this.name = source["name"];
+	        this.mimeType = source["mimeType"];
+	        this.size = source["size"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class AttachmentGarbage {
+	    attachments: Attachment[];
+	    files: number;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttachmentGarbage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.attachments = this.convertValues(source["attachments"], Attachment);
+	        this.files = source["files"];
+	        this.size = source["size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CalendarMonth {
+	    name: string;
+	    days: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalendarMonth(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.days = source["days"];
+	    }
+	}
+	export class Calendar {
+	    id: number;
+	    name: string;
+	    era: string;
+	    epoch: number;
+	    months: CalendarMonth[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Calendar(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.era = source["era"];
+	        this.epoch = source["epoch"];
+	        this.months = this.convertValues(source["months"], CalendarMonth);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Chapter {
+	    id: number;
+	    taleId: number;
+	    content: string;
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Chapter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.taleId = source["taleId"];
+	        this.content = source["content"];
+	        this.version = source["version"];
+	    }
+	}
+	export class ChapterUpdate {
+	    chapter: Chapter;
+	    conflict: boolean;
+	    current?: Chapter;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChapterUpdate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.chapter = this.convertValues(source["chapter"], Chapter);
+	        this.conflict = source["conflict"];
+	        this.current = this.convertValues(source["current"], Chapter);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CodexAppearance {
+	    entityId: number;
+	    chapterId: number;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CodexAppearance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entityId = source["entityId"];
+	        this.chapterId = source["chapterId"];
+	        this.count = source["count"];
+	    }
+	}
+	export class CodexField {
+	    name: string;
+	    value: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CodexField(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.value = source["value"];
+	    }
+	}
+	export class CodexEntity {
+	    id: number;
+	    kind: string;
+	    name: string;
+	    description: string;
+	    fields: CodexField[];
+	    aliases: string[];
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CodexEntity(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.fields = this.convertValues(source["fields"], CodexField);
+	        this.aliases = source["aliases"];
+	        this.version = source["version"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CodexMention {
+	    entityId: number;
+	    chapterId: number;
+	    start: number;
+	    end: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CodexMention(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entityId = source["entityId"];
+	        this.chapterId = source["chapterId"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.text = source["text"];
+	    }
+	}
+	export class FlowPoint {
+	    date: string;
+	    counts: Record<number, number>;
+	
+	    static createFrom(source: any = {}) {
+	        return new FlowPoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.counts = source["counts"];
+	    }
+	}
+	export class LibraryInfo {
+	    name: string;
+	    path: string;
+	    current: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LibraryInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.current = source["current"];
+	    }
+	}
+	export class Note {
+	    id: number;
+	    chapterId: number;
+	    start: number;
+	    end: number;
+	    quote: string;
+	    body: string;
+	    resolved: boolean;
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Note(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.chapterId = source["chapterId"];
+	        this.start = source["start"];
+	        this.end = source["end"];
+	        this.quote = source["quote"];
+	        this.body = source["body"];
+	        this.resolved = source["resolved"];
+	        this.version = source["version"];
+	    }
+	}
+	export class Scene {
+	    id: number;
+	    chapterId: number;
+	    position: number;
+	    title: string;
+	    content: string;
+	    synopsis: string;
+	    povId: number;
+	    locationId: number;
+	    statusId: number;
+	    wordCount: number;
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Scene(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.chapterId = source["chapterId"];
+	        this.position = source["position"];
+	        this.title = source["title"];
+	        this.content = source["content"];
+	        this.synopsis = source["synopsis"];
+	        this.povId = source["povId"];
+	        this.locationId = source["locationId"];
+	        this.statusId = source["statusId"];
+	        this.wordCount = source["wordCount"];
+	        this.version = source["version"];
+	    }
+	}
+	export class SimilarPair {
+	    firstTaleId: number;
+	    secondTaleId: number;
+	    score: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SimilarPair(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.firstTaleId = source["firstTaleId"];
+	        this.secondTaleId = source["secondTaleId"];
+	        this.score = source["score"];
+	    }
+	}
+	export class StartupState {
+	    ready: boolean;
+	    library: string;
+	    cause: string;
+	    message: string;
+	    path: string;
+	    backups: data.Backup[];
+	
+	    static createFrom(source: any = {}) {
+	        return new StartupState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ready = source["ready"];
+	        this.library = source["library"];
+	        this.cause = source["cause"];
+	        this.message = source["message"];
+	        this.path = source["path"];
+	        this.backups = this.convertValues(source["backups"], data.Backup);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Status {
+	    id: number;
+	    name: string;
+	    color: string;
+	    textColor: string;
+	    position: number;
+	    terminal: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.color = source["color"];
+	        this.textColor = source["textColor"];
+	        this.position = source["position"];
+	        this.terminal = source["terminal"];
+	    }
+	}
+	export class StatusChange {
+	    id: number;
+	    taleId: number;
+	    fromStatusId: number;
+	    toStatusId: number;
+	    changedBy: string;
+	    // Go type: time
+	    changedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new StatusChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.taleId = source["taleId"];
+	        this.fromStatusId = source["fromStatusId"];
+	        this.toStatusId = source["toStatusId"];
+	        this.changedBy = source["changedBy"];
+	        this.changedAt = this.convertValues(source["changedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StatusDuration {
+	    statusId: number;
+	    seconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new StatusDuration(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.statusId = source["statusId"];
+	        this.seconds = source["seconds"];
+	    }
+	}
+	export class StatusTransition {
+	    fromStatusId: number;
+	    toStatusId: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new StatusTransition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fromStatusId = source["fromStatusId"];
+	        this.toStatusId = source["toStatusId"];
+	    }
+	}
+	export class TagEdge {
+	    source: number;
+	    target: number;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagEdge(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.target = source["target"];
+	        this.count = source["count"];
+	    }
+	}
+	export class TagInfo {
+	    id: number;
+	    name: string;
+	    path: string;
+	    parentId: number;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.parentId = source["parentId"];
+	        this.count = source["count"];
+	    }
+	}
+	export class TagGraph {
+	    nodes: TagInfo[];
+	    edges: TagEdge[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TagGraph(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodes = this.convertValues(source["nodes"], TagInfo);
+	        this.edges = this.convertValues(source["edges"], TagEdge);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TagSuggestion {
+	    tagId: number;
+	    path: string;
+	    score: number;
+	    match: string;
+	    keywords: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TagSuggestion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tagId = source["tagId"];
+	        this.path = source["path"];
+	        this.score = source["score"];
+	        this.match = source["match"];
+	        this.keywords = source["keywords"];
+	    }
+	}
+	export class Tale {
+	    id: number;
+	    name: string;
+	    summary: string;
+	    parentId: number;
+	    statusId: number;
+	    version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Tale(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.summary = source["summary"];
+	        this.parentId = source["parentId"];
+	        this.statusId = source["statusId"];
+	        this.version = source["version"];
+	    }
+	}
+	export class TaleUpdate {
+	    tale: Tale;
+	    conflict: boolean;
+	    current?: Tale;
+	
+	    static createFrom(source: any = {}) {
+	        return new TaleUpdate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tale = this.convertValues(source["tale"], Tale);
+	        this.conflict = source["conflict"];
+	        this.current = this.convertValues(source["current"], Tale);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimelineEvent {
+	    id: number;
+	    taleId: number;
+	    chapterId: number;
+	    title: string;
+	    description: string;
+	    calendarId: number;
+	    year: number;
+	    month: number;
+	    day: number;
+	    afterId: number;
+	    offset: number;
+	    position: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.taleId = source["taleId"];
+	        this.chapterId = source["chapterId"];
+	        this.title = source["title"];
+	        this.description = source["description"];
+	        this.calendarId = source["calendarId"];
+	        this.year = source["year"];
+	        this.month = source["month"];
+	        this.day = source["day"];
+	        this.afterId = source["afterId"];
+	        this.offset = source["offset"];
+	        this.position = source["position"];
+	    }
+	}
+	export class TimelineEntry {
+	    event: TimelineEvent;
+	    moment: number;
+	    known: boolean;
+	    date: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.event = this.convertValues(source["event"], TimelineEvent);
+	        this.moment = source["moment"];
+	        this.known = source["known"];
+	        this.date = source["date"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimelineConflict {
+	    event: TimelineEntry;
+	    later: TimelineEntry;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.event = this.convertValues(source["event"], TimelineEntry);
+	        this.later = this.convertValues(source["later"], TimelineEntry);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+	export class Workflow {
+	    statuses: Status[];
+	    transitions: StatusTransition[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Workflow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.statuses = this.convertValues(source["statuses"], Status);
+	        this.transitions = this.convertValues(source["transitions"], StatusTransition);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+}
+
+export namespace config {
+	
+	export class Preferences {
+	    theme: string;
+	    editorFont: string;
+	    editorFontSize: number;
+	    autosaveInterval: number;
+	    defaultStatusId: number;
+	    defaultExportFormat: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Preferences(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.theme = source["theme"];
+	        this.editorFont = source["editorFont"];
+	        this.editorFontSize = source["editorFontSize"];
+	        this.autosaveInterval = source["autosaveInterval"];
+	        this.defaultStatusId = source["defaultStatusId"];
+	        this.defaultExportFormat = source["defaultExportFormat"];
+	    }
+	}
+
+}
+
+export namespace data {
+	
+	export class Backup {
+	    path: string;
+	    name: string;
+	    // Go type: time
+	    created: any;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Backup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.created = this.convertValues(source["created"], null);
+	        this.size = source["size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
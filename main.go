@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"flag"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,8 +13,11 @@ import (
 var assets embed.FS
 
 func main() {
+	configFile := flag.String("config", "", "read the configuration from `file`")
+	flag.Parse()
+
 	// Create an instance of the app structure
-	app := NewApp()
+	app := NewApp(*configFile)
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -25,8 +29,21 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
+			app.library,
+			app.preferences,
+			app.tags,
+			app.similarity,
+			app.codex,
+			app.timeline,
+			app.scenes,
+			app.notes,
+			app.attachments,
+			app.statuses,
+			app.tales,
+			app.chapters,
 		},
 	})
 